@@ -0,0 +1,528 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/jinzhu/gorm"
+	"github.com/qor/qor/utils"
+	"github.com/qor/responder"
+	"github.com/qor/session"
+)
+
+var (
+	// AccountDeletionScheduledFlashMessage account deletion scheduled flash message
+	AccountDeletionScheduledFlashMessage = template.HTML("Your account will be deleted, you could cancel it before then")
+	// AccountDeletionCanceledFlashMessage account deletion canceled flash message
+	AccountDeletionCanceledFlashMessage = template.HTML("Your account won't be deleted")
+	// AccountDeletedFlashMessage account deleted flash message
+	AccountDeletedFlashMessage = template.HTML("Your account has been deleted")
+)
+
+// AccountConfig account config, configure how to delete accounts
+type AccountConfig struct {
+	// GracePeriod accounts are kept for the period after deletion requested, users could cancel deletion during it, accounts will be erased by PurgeAccounts after it, default is 0, which erases accounts immediately
+	GracePeriod time.Duration
+	// Anonymize anonymize auth identities and keep user's record instead of deleting them, if UserModel implemented AccountAnonymizer, it will be called to anonymize user's record, auth identities are anonymized in AuthIdentityModel's table with gorm
+	Anonymize bool
+	// AccountDeletionModel a model used to save deletion requests, https://github.com/fahmibaswara/auth/blob/master/auth_identity/account_deletion.go is the default implemention
+	AccountDeletionModel interface{}
+}
+
+// AccountAnonymizer could be implemented by UserModel to anonymize user's personal data when account is erased with Anonymize enabled
+type AccountAnonymizer interface {
+	AnonymizeAccount()
+}
+
+// Account a user's account, includes all auth identities linked to the user
+type Account struct {
+	Claims     *claims.Claims
+	User       interface{}
+	Identities []auth_identity.Basic
+}
+
+// AccountExporter export data of account, which will be included in personal data export
+type AccountExporter func(req *http.Request, account *Account) (interface{}, error)
+
+// AccountEraser erase data of account, anonymize data instead of deleting it if anonymize is true
+type AccountEraser func(req *http.Request, account *Account, anonymize bool) error
+
+// AccountExport personal data export of an account
+type AccountExport struct {
+	ExportedAt          time.Time              `json:"exported_at"`
+	User                interface{}            `json:"user,omitempty"`
+	Providers           []string               `json:"providers"`
+	Identities          []IdentityExport       `json:"identities"`
+	Tokens              []TokenExport          `json:"tokens,omitempty"`
+	DeletionScheduledAt *time.Time             `json:"deletion_scheduled_at,omitempty"`
+	Extra               map[string]interface{} `json:"extra,omitempty"`
+}
+
+// IdentityExport exported auth identity, secrets like encrypted password are not included
+type IdentityExport struct {
+	Provider    string                  `json:"provider"`
+	UID         string                  `json:"uid"`
+	UserID      string                  `json:"user_id,omitempty"`
+	ConfirmedAt *time.Time              `json:"confirmed_at,omitempty"`
+	LockedAt    *time.Time              `json:"locked_at,omitempty"`
+	CreatedAt   *time.Time              `json:"created_at,omitempty"`
+	SignInCount uint                    `json:"sign_in_count"`
+	SignLogs    []auth_identity.SignLog `json:"sign_logs,omitempty"`
+}
+
+// TokenExport exported authentication token, token's value is not included
+type TokenExport struct {
+	Identity   string     `json:"identity"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+type accountIdentity struct {
+	auth_identity.Basic
+	auth_identity.SignLogs
+	CreatedAt *time.Time
+}
+
+func (auth *Auth) initAccountConfig() {
+	if auth.Config.Account.AccountDeletionModel == nil {
+		auth.Config.Account.AccountDeletionModel = &auth_identity.AccountDeletion{}
+	}
+}
+
+// RegisterAccountExporter register exporter to include more data in personal data export, like provider or application specific data
+func (auth *Auth) RegisterAccountExporter(name string, exporter AccountExporter) {
+	if auth.accountExporters == nil {
+		auth.accountExporters = map[string]AccountExporter{}
+	}
+	auth.accountExporters[name] = exporter
+}
+
+// RegisterAccountEraser register eraser to erase more data when account is erased, like provider or application specific data
+func (auth *Auth) RegisterAccountEraser(eraser AccountEraser) {
+	auth.accountErasers = append(auth.accountErasers, eraser)
+}
+
+func accountConditions(claims *claims.Claims) map[string]interface{} {
+	if claims.UserID != "" {
+		return map[string]interface{}{"user_id": claims.UserID}
+	}
+	return map[string]interface{}{"provider": claims.Provider, "uid": claims.Id}
+}
+
+// findAccountIdentities find auth identities of account with their sign logs from AuthIdentityModel's table, which are not provided by IdentityStore
+func (auth *Auth) findAccountIdentities(req *http.Request, claims *claims.Claims) (identities []accountIdentity) {
+	auth.ScopeTenant(req, auth.GetDB(req)).Model(auth.Config.AuthIdentityModel).Where(accountConditions(claims)).Scan(&identities)
+	return
+}
+
+// GetAccount get account of claims, includes user and all linked auth identities
+func (auth *Auth) GetAccount(req *http.Request, claims *claims.Claims) (*Account, error) {
+	identities := auth.findAccountIdentities(req, claims)
+	if len(identities) == 0 {
+		return nil, ErrInvalidAccount
+	}
+
+	account := &Account{Claims: claims}
+	for _, identity := range identities {
+		account.Identities = append(account.Identities, identity.Basic)
+	}
+
+	if auth.Config.UserModel != nil && claims.UserID != "" {
+		if user, err := auth.UserStorer.Get(claims, &Context{Auth: auth, Claims: claims, Request: req}); err == nil {
+			account.User = user
+		}
+	}
+	return account, nil
+}
+
+// ExportAccount export all personal data of claims' account
+func (auth *Auth) ExportAccount(req *http.Request, claims *claims.Claims) (*AccountExport, error) {
+	var (
+		identities = auth.findAccountIdentities(req, claims)
+		uids       []string
+	)
+
+	account, err := auth.GetAccount(req, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AccountExport{ExportedAt: auth.Now(), User: account.User}
+	for _, identity := range identities {
+		export.Providers = append(export.Providers, identity.Provider)
+		export.Identities = append(export.Identities, IdentityExport{
+			Provider:    identity.Provider,
+			UID:         identity.UID,
+			UserID:      identity.UserID,
+			ConfirmedAt: identity.ConfirmedAt,
+			LockedAt:    identity.LockedAt,
+			CreatedAt:   identity.CreatedAt,
+			SignInCount: identity.SignInCount,
+			SignLogs:    identity.Logs,
+		})
+		uids = append(uids, identity.UID)
+	}
+
+	for _, uid := range uids {
+		if token, err := auth.TokenStore.Find(req, uid); err == nil {
+			export.Tokens = append(export.Tokens, TokenExport{Identity: token.Identity, ValidUntil: token.ValidUntil})
+		}
+	}
+
+	if deletion := auth.findAccountDeletion(req, claims); deletion != nil {
+		export.DeletionScheduledAt = &deletion.DeleteAt
+	}
+
+	for name, exporter := range auth.accountExporters {
+		data, err := exporter(req, account)
+		if err != nil {
+			return nil, err
+		}
+
+		if export.Extra == nil {
+			export.Extra = map[string]interface{}{}
+		}
+		export.Extra[name] = data
+	}
+	return export, nil
+}
+
+func (auth *Auth) findAccountDeletion(req *http.Request, claims *claims.Claims) *auth_identity.AccountDeletion {
+	var deletion auth_identity.AccountDeletion
+	if auth.GetDB(req).Model(auth.Config.Account.AccountDeletionModel).Where(map[string]interface{}{
+		"session_key": SessionKey(claims),
+	}).Scan(&deletion).RecordNotFound() {
+		return nil
+	}
+	return &deletion
+}
+
+// AccountDeletionScheduledAt return when claims' account will be erased, returns nil if deletion is not requested
+func (auth *Auth) AccountDeletionScheduledAt(req *http.Request, claims *claims.Claims) *time.Time {
+	if deletion := auth.findAccountDeletion(req, claims); deletion != nil {
+		return &deletion.DeleteAt
+	}
+	return nil
+}
+
+// RequestAccountDeletion request to delete claims' account, it will be erased after Account's GracePeriod, or immediately if there is no grace period, returns when it will be erased
+func (auth *Auth) RequestAccountDeletion(req *http.Request, claims *claims.Claims) (time.Time, error) {
+	var (
+		config   = auth.Config.Account
+		deleteAt = auth.Now().Add(config.GracePeriod)
+		deletion = reflect.New(utils.ModelType(config.AccountDeletionModel)).Interface()
+	)
+
+	if config.GracePeriod <= 0 {
+		return deleteAt, auth.DeleteAccount(req, claims)
+	}
+
+	if _, err := auth.GetAccount(req, claims); err != nil {
+		return deleteAt, err
+	}
+
+	if scheduledAt := auth.AccountDeletionScheduledAt(req, claims); scheduledAt != nil {
+		return *scheduledAt, nil
+	}
+
+	return deleteAt, auth.GetDB(req).Where(map[string]interface{}{
+		"session_key": SessionKey(claims),
+	}).Assign(map[string]interface{}{
+		"provider":  claims.Provider,
+		"uid":       claims.Id,
+		"user_id":   claims.UserID,
+		"tenant_id": claims.TenantID,
+		"delete_at": deleteAt,
+	}).FirstOrCreate(deletion).Error
+}
+
+// CancelAccountDeletion cancel requested deletion of claims' account
+func (auth *Auth) CancelAccountDeletion(req *http.Request, claims *claims.Claims) error {
+	return auth.GetDB(req).Unscoped().Where(map[string]interface{}{
+		"session_key": SessionKey(claims),
+	}).Delete(reflect.New(utils.ModelType(auth.Config.Account.AccountDeletionModel)).Interface()).Error
+}
+
+// DeleteAccount erase claims' account immediately, it deletes or anonymizes auth identities, authentication tokens, user's record, data of registered erasers, and revokes all sessions if SessionRevoker configured
+func (auth *Auth) DeleteAccount(req *http.Request, claims *claims.Claims) (err error) {
+	account, err := auth.GetAccount(req, claims)
+	if err != nil {
+		return err
+	}
+
+	tx := auth.GetDB(req).Begin()
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	txReq := req.WithContext(context.WithValue(req.Context(), utils.ContextDBName, tx))
+	if err = auth.eraseAccount(txReq, account); err != nil {
+		return err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return err
+	}
+
+	auth.InvalidateUser(claims)
+	for _, identity := range account.Identities {
+		auth.InvalidateUser(identity.ToClaims())
+	}
+
+	if auth.Config.SessionRevoker != nil {
+		revoked := map[string]bool{SessionKey(claims): true}
+		auth.Config.SessionRevoker.Revoke(req, claims, auth.Now())
+
+		for _, identity := range account.Identities {
+			if key := SessionKey(identity.ToClaims()); !revoked[key] {
+				revoked[key] = true
+				auth.Config.SessionRevoker.Revoke(req, identity.ToClaims(), auth.Now())
+			}
+		}
+	}
+	return nil
+}
+
+func anonymizedUID(identity auth_identity.Basic) string {
+	digest := sha256.Sum256([]byte(identity.Provider + ":" + identity.UID))
+	return "deleted-" + hex.EncodeToString(digest[:8])
+}
+
+func (auth *Auth) eraseAccount(req *http.Request, account *Account) error {
+	var (
+		tx        = auth.GetDB(req)
+		anonymize = auth.Config.Account.Anonymize
+		uids      []string
+	)
+
+	for _, identity := range account.Identities {
+		uids = append(uids, identity.UID)
+		authIdentity := reflect.New(utils.ModelType(auth.Config.AuthIdentityModel)).Interface()
+		scope := tx.Unscoped().Model(authIdentity).Where(auth.TenantConditions(req, map[string]interface{}{"provider": identity.Provider, "uid": identity.UID}))
+
+		var err error
+		if anonymize {
+			values := map[string]interface{}{
+				"uid":                anonymizedUID(identity),
+				"encrypted_password": "",
+				"confirmed_at":       nil,
+			}
+
+			if tx.NewScope(authIdentity).HasColumn("sign_logs") {
+				values["sign_logs"] = auth_identity.SignLogs{}
+			}
+			err = scope.Updates(values).Error
+		} else {
+			err = auth.IdentityStore.Delete(req, identity.Provider, identity.UID)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, uid := range uids {
+		if err := auth.TokenStore.Delete(req, uid); err != nil {
+			return err
+		}
+	}
+
+	if account.User != nil {
+		var err error
+		if anonymize {
+			if anonymizer, ok := account.User.(AccountAnonymizer); ok {
+				anonymizer.AnonymizeAccount()
+				err = tx.Save(account.User).Error
+			}
+		} else {
+			err = tx.Unscoped().Delete(account.User).Error
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, eraser := range auth.accountErasers {
+		if err := eraser(req, account, anonymize); err != nil {
+			return err
+		}
+	}
+
+	return auth.CancelAccountDeletion(req, account.Claims)
+}
+
+// PurgeAccounts erase accounts whose grace period has passed, should be called periodically if Account's GracePeriod is set, db could be nil, then Auth's DB will be used, returns count of erased accounts
+func (auth *Auth) PurgeAccounts(db *gorm.DB) (int, error) {
+	var (
+		deletions []auth_identity.AccountDeletion
+		count     int
+	)
+
+	if db == nil {
+		db = auth.Config.DB
+	}
+
+	req := (&http.Request{Header: http.Header{}}).WithContext(context.WithValue(context.Background(), utils.ContextDBName, db))
+	if err := db.Model(auth.Config.Account.AccountDeletionModel).Where("delete_at <= ?", auth.Now()).Scan(&deletions).Error; err != nil {
+		return count, err
+	}
+
+	for _, deletion := range deletions {
+		var (
+			claims    = (auth_identity.Basic{Provider: deletion.Provider, UID: deletion.UID, UserID: deletion.UserID, TenantID: deletion.TenantID}).ToClaims()
+			tenantReq = req.WithContext(WithTenant(req.Context(), deletion.TenantID))
+		)
+
+		err := auth.DeleteAccount(tenantReq, claims)
+		if errors.Is(err, ErrInvalidAccount) {
+			err = auth.CancelAccountDeletion(tenantReq, claims)
+		} else if err == nil {
+			count++
+		}
+
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// loadAccountClaims load claims of current user for account pages, responds ErrUnauthorized if not logged
+func loadAccountClaims(context *Context) bool {
+	claims, err := context.SessionStorer.Get(context.Request)
+	if err != nil {
+		respondAccountError(context, ErrUnauthorized)
+		return false
+	}
+	context.Claims = claims
+	return true
+}
+
+// exportAccount download personal data of current user as JSON
+func exportAccount(context *Context) {
+	if !loadAccountClaims(context) {
+		return
+	}
+
+	export, err := context.Auth.ExportAccount(context.Request, context.Claims)
+	if err != nil {
+		respondAccountError(context, err)
+		return
+	}
+
+	w := context.Writer
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="account.json"`)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(export)
+}
+
+// deleteAccountPage render delete account page
+func deleteAccountPage(context *Context) {
+	if !loadAccountClaims(context) {
+		return
+	}
+
+	req := context.Request
+	context.Auth.Config.Render.Funcs(template.FuncMap{
+		"deletion_scheduled_at": func() *time.Time { return context.Auth.AccountDeletionScheduledAt(req, context.Claims) },
+	}).Execute("auth/account/delete", context, req, context.Writer)
+}
+
+// deleteAccount request to delete current user's account
+func deleteAccount(context *Context) {
+	if !loadAccountClaims(context) {
+		return
+	}
+
+	var (
+		w      = context.Writer
+		req    = context.Request
+		claims = context.Claims
+	)
+
+	if claims.IsImpersonating() {
+		respondAccountError(context, ErrImpersonating)
+		return
+	}
+
+	deleteAt, err := context.Auth.RequestAccountDeletion(req, claims)
+	if err != nil {
+		respondAccountError(context, err)
+		return
+	}
+
+	deleted := context.Auth.Config.Account.GracePeriod <= 0
+	if deleted {
+		context.Auth.Delete(w, req)
+	}
+
+	responder.With("html", func() {
+		if deleted {
+			context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.account_deleted", string(AccountDeletedFlashMessage)), Type: "success"})
+			context.Auth.Redirector.Redirect(w, req, "delete_account")
+			return
+		}
+
+		context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.account_deletion_scheduled", string(AccountDeletionScheduledFlashMessage)), Type: "success"})
+		http.Redirect(w, req, context.AuthURL("account/delete"), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": deleted, "delete_at": deleteAt})
+	}).Respond(req)
+}
+
+// cancelDeletion cancel requested deletion of current user's account
+func cancelDeletion(context *Context) {
+	if !loadAccountClaims(context) {
+		return
+	}
+
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	if err := context.Auth.CancelAccountDeletion(req, context.Claims); err != nil {
+		respondAccountError(context, err)
+		return
+	}
+
+	responder.With("html", func() {
+		context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.account_deletion_canceled", string(AccountDeletionCanceledFlashMessage)), Type: "success"})
+		http.Redirect(w, req, context.AuthURL("account/delete"), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": false})
+	}).Respond(req)
+}
+
+func respondAccountError(context *Context, err error) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	responder.With("html", func() {
+		context.Auth.FlashError(w, req, err)
+		if errors.Is(err, ErrUnauthorized) {
+			http.Redirect(w, req, context.AuthURL("login"), http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, req, context.AuthURL("account/delete"), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		context.Auth.WriteError(w, req, err)
+	}).Respond(req)
+}
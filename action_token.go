@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+)
+
+const (
+	// ActionConfirm purpose of confirm account token
+	ActionConfirm = "confirm"
+	// ActionResetPassword purpose of reset password token
+	ActionResetPassword = "reset_password"
+)
+
+// ActionTokenConfig action token config, action tokens are used in links sent to users, like confirm account, reset password
+type ActionTokenConfig struct {
+	// Secret used to sign action tokens, it should be different from session's secret, if blank, it will be derived from default SessionStorer's secret, or a random one if that is blank too
+	Secret        string
+	SigningMethod jwt.SigningMethod
+	// TTL how long tokens of a purpose are valid, default is 72 hours for confirm, 1 hour for reset password and 24 hours for others
+	TTL map[string]time.Duration
+	// Store used to make sure a token could only be used once
+	Store ActionTokenStoreInterface
+}
+
+// ActionClaims action token claims
+type ActionClaims struct {
+	Purpose  string `json:"purpose"`
+	Provider string `json:"provider"`
+	// Binding digest of auth identity's state, token becomes invalid once email, password or confirmation state changed
+	Binding string `json:"binding"`
+	jwt.StandardClaims
+}
+
+// ActionTokenStoreInterface action token store interface, used to track used tokens
+type ActionTokenStoreInterface interface {
+	// Use mark token as used, returns ErrActionTokenUsed if it has been used
+	Use(req *http.Request, tokenID string, expiresAt time.Time) error
+	// IsUsed check if token has been used
+	IsUsed(req *http.Request, tokenID string) bool
+}
+
+func (config *ActionTokenConfig) ttl(purpose string) time.Duration {
+	if ttl, ok := config.TTL[purpose]; ok {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
+func (auth *Auth) initActionTokenConfig() {
+	config := auth.Config.ActionToken
+
+	if config.SigningMethod == nil {
+		config.SigningMethod = jwt.SigningMethodHS256
+	}
+
+	if config.Secret == "" {
+		if storer, ok := auth.SessionStorer.(*SessionStorer); ok && storer.SignedString != "" {
+			config.Secret = storer.SignedString
+		} else {
+			// tokens are never signed with empty key, a random one is used instead, so tokens become invalid after restarted
+			fmt.Println("warning: auth action token's Secret is blank, tokens are signed with a random secret that changes after restarted")
+			secret := make([]byte, 32)
+			rand.Read(secret)
+			config.Secret = hex.EncodeToString(secret)
+		}
+	}
+
+	if config.TTL == nil {
+		config.TTL = map[string]time.Duration{}
+	}
+
+	if _, ok := config.TTL[ActionConfirm]; !ok {
+		config.TTL[ActionConfirm] = 72 * time.Hour
+	}
+
+	if _, ok := config.TTL[ActionResetPassword]; !ok {
+		config.TTL[ActionResetPassword] = time.Hour
+	}
+
+	if config.Store == nil {
+		config.Store = &MemoryActionTokenStore{}
+	}
+
+	if store, ok := config.Store.(*MemoryActionTokenStore); ok && store.Clock == nil {
+		store.Clock = auth.Config.Clock
+	}
+
+	if store, ok := config.Store.(*DBActionTokenStore); ok {
+		if store.Auth == nil {
+			store.Auth = auth
+		}
+
+		if store.UsedActionTokenModel == nil {
+			store.UsedActionTokenModel = &auth_identity.UsedActionToken{}
+		}
+	}
+}
+
+// signingKey derive action token's signing key, so action tokens never share key with sessions
+func (config *ActionTokenConfig) signingKey() []byte {
+	mac := hmac.New(sha256.New, []byte(config.Secret))
+	mac.Write([]byte("auth action token"))
+	return mac.Sum(nil)
+}
+
+func (config *ActionTokenConfig) binding(purpose string, authInfo *auth_identity.Basic) string {
+	var confirmedAt string
+	if authInfo.ConfirmedAt != nil {
+		confirmedAt = authInfo.ConfirmedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	mac := hmac.New(sha256.New, config.signingKey())
+	fmt.Fprintf(mac, "%v\n%v\n%v\n%v\n%v", purpose, authInfo.Provider, authInfo.UID, authInfo.EncryptedPassword, confirmedAt)
+	if authInfo.TenantID != "" {
+		// tokens are bound to tenant, so they are rejected by other tenants even if there is an auth identity with same uid
+		fmt.Fprintf(mac, "\n%v", authInfo.TenantID)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (auth *Auth) findAuthInfo(req *http.Request, provider string, uid string) (*auth_identity.Basic, error) {
+	authInfo, err := auth.IdentityStore.Find(req, provider, uid)
+	if err == ErrIdentityNotFound {
+		return nil, ErrInvalidAccount
+	}
+	return authInfo, err
+}
+
+// NewActionToken generate a signed, expiring token for purpose, which is bound to claims' auth identity's current state
+func (auth *Auth) NewActionToken(req *http.Request, purpose string, claims *claims.Claims) (string, error) {
+	var config = auth.Config.ActionToken
+
+	authInfo, err := auth.findAuthInfo(req, claims.Provider, claims.Id)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	now := auth.Now()
+	actionClaims := ActionClaims{
+		Purpose:  purpose,
+		Provider: authInfo.Provider,
+		Binding:  config.binding(purpose, authInfo),
+	}
+	actionClaims.Id = hex.EncodeToString(nonce)
+	actionClaims.Subject = authInfo.UID
+	actionClaims.IssuedAt = now.Unix()
+	actionClaims.ExpiresAt = now.Add(config.ttl(purpose)).Unix()
+
+	return jwt.NewWithClaims(config.SigningMethod, actionClaims).SignedString(config.signingKey())
+}
+
+// ValidateActionToken validate token is issued for purpose, not expired, not used, and its auth identity hasn't changed since issued, returns the auth identity
+func (auth *Auth) ValidateActionToken(req *http.Request, purpose string, tokenString string) (*auth_identity.Basic, *ActionClaims, error) {
+	var config = auth.Config.ActionToken
+
+	// expiration is checked with Auth's Clock
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &ActionClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != config.SigningMethod {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return config.signingKey(), nil
+	})
+
+	if err != nil {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	actionClaims, ok := token.Claims.(*ActionClaims)
+	if !ok || !token.Valid || actionClaims.Purpose != purpose || actionClaims.ExpiresAt == 0 {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	if !actionClaims.VerifyExpiresAt(auth.Now().Unix(), true) {
+		return nil, nil, ErrActionTokenExpired
+	}
+
+	if config.Store.IsUsed(req, actionClaims.Id) {
+		return nil, nil, ErrActionTokenUsed
+	}
+
+	authInfo, err := auth.findAuthInfo(req, actionClaims.Provider, actionClaims.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	if !hmac.Equal([]byte(config.binding(purpose, authInfo)), []byte(actionClaims.Binding)) {
+		return nil, nil, ErrInvalidActionToken
+	}
+	return authInfo, actionClaims, nil
+}
+
+// ConsumeActionToken validate token like ValidateActionToken, and mark it as used
+func (auth *Auth) ConsumeActionToken(req *http.Request, purpose string, tokenString string) (*auth_identity.Basic, error) {
+	authInfo, actionClaims, err := auth.ValidateActionToken(req, purpose, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.Config.ActionToken.Store.Use(req, actionClaims.Id, time.Unix(actionClaims.ExpiresAt, 0)); err != nil {
+		return nil, err
+	}
+	return authInfo, nil
+}
+
+// MemoryActionTokenStore action token store that keeps used tokens in memory, only works for single process deployment
+type MemoryActionTokenStore struct {
+	// Clock current time used to remove expired tokens, default is time.Now, Auth will set it to its Clock if blank
+	Clock func() time.Time
+
+	mutex sync.Mutex
+	used  map[string]time.Time
+}
+
+// Use mark token as used, returns ErrActionTokenUsed if it has been used
+func (store *MemoryActionTokenStore) Use(req *http.Request, tokenID string, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	if store.Clock != nil {
+		now = store.Clock()
+	}
+
+	if store.used == nil {
+		store.used = map[string]time.Time{}
+	}
+
+	for id, expiresAt := range store.used {
+		if now.After(expiresAt) {
+			delete(store.used, id)
+		}
+	}
+
+	if _, ok := store.used[tokenID]; ok {
+		return ErrActionTokenUsed
+	}
+	store.used[tokenID] = expiresAt
+	return nil
+}
+
+// IsUsed check if token has been used
+func (store *MemoryActionTokenStore) IsUsed(req *http.Request, tokenID string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	_, ok := store.used[tokenID]
+	return ok
+}
+
+// DBActionTokenStore action token store that saves used tokens into database with UsedActionTokenModel, which should have unique index of `token_id`
+type DBActionTokenStore struct {
+	Auth *Auth
+	// UsedActionTokenModel a model used to save used tokens, https://github.com/fahmibaswara/auth/blob/master/auth_identity/used_action_token.go is the default implemention
+	UsedActionTokenModel interface{}
+}
+
+// Use mark token as used, returns ErrActionTokenUsed if it has been used
+func (store *DBActionTokenStore) Use(req *http.Request, tokenID string, expiresAt time.Time) error {
+	var (
+		tx        = store.Auth.GetDB(req)
+		usedToken = reflect.New(utils.ModelType(store.UsedActionTokenModel)).Interface()
+	)
+
+	// remove expired tokens, they will be rejected by expiration check
+	tx.Unscoped().Where("expires_at < ?", store.Auth.Now()).Delete(reflect.New(utils.ModelType(store.UsedActionTokenModel)).Interface())
+
+	scope := tx.NewScope(usedToken)
+	scope.SetColumn("TokenID", tokenID)
+	scope.SetColumn("ExpiresAt", expiresAt)
+
+	// token is inserted without checking first, so concurrent requests are rejected by unique index of `token_id`
+	if err := tx.Create(usedToken).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrActionTokenUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// IsUsed check if token has been used
+func (store *DBActionTokenStore) IsUsed(req *http.Request, tokenID string) bool {
+	usedToken := reflect.New(utils.ModelType(store.UsedActionTokenModel)).Interface()
+	return !store.Auth.GetDB(req).Where(map[string]interface{}{"token_id": tokenID}).First(usedToken).RecordNotFound()
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/authority"
+)
+
+// Admin admin pages to manage users and auth identities, mounted under `{Auth Prefix}/admin`, auth identities are searched in AuthIdentityModel's table with gorm
+type Admin struct {
+	*Config
+}
+
+// Config admin config
+type Config struct {
+	Auth      *auth.Auth
+	Authority *authority.Authority
+	// Roles roles allowed to access admin pages, default is `admin`, roles should be registered with Authority's Role
+	Roles []string
+	// PerPage how many identities are listed in a page, default is 25
+	PerPage int
+	// AuditLogModel a model used to save admin actions, https://github.com/fahmibaswara/auth/blob/master/auth_identity/audit_log.go is the default implemention
+	AuditLogModel interface{}
+}
+
+// New initialize Admin, and register its routes into Auth
+func New(config *Config) *Admin {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.Auth == nil {
+		panic("Auth should not be nil for Admin")
+	}
+
+	if config.Authority == nil {
+		panic("Authority should not be nil for Admin")
+	}
+
+	if len(config.Roles) == 0 {
+		config.Roles = []string{"admin"}
+	}
+
+	if config.PerPage <= 0 {
+		config.PerPage = 25
+	}
+
+	if config.AuditLogModel == nil {
+		config.AuditLogModel = &auth_identity.AuditLog{}
+	}
+
+	config.Auth.Render.RegisterViewPath("github.com/fahmibaswara/auth/admin/views")
+
+	admin := &Admin{Config: config}
+	admin.registerRoutes()
+	return admin
+}
+
+// URL generate URL for admin pages
+func (admin *Admin) URL(pth string) string {
+	return admin.Auth.AuthURL("admin/" + pth)
+}
+
+// registerRoutes register routes of admin pages, only users have admin's Roles could access them
+func (admin *Admin) registerRoutes() {
+	// impersonated users don't have admin's Roles, so it is authorized by impersonator in claims
+	admin.Auth.AddRoute(auth.Route{Method: "POST", Path: "admin/stop_impersonating", Description: "stop impersonating user, back to impersonator", Handler: admin.stopImpersonatingHandler})
+
+	for _, route := range []auth.Route{
+		{Method: "GET", Path: "admin", Description: "redirect to identities page", Handler: func(context *auth.Context) {
+			http.Redirect(context.Writer, context.Request, admin.URL("identities"), http.StatusSeeOther)
+		}},
+		// eg: /admin/identities?q=jinzhu
+		{Method: "GET", Path: "admin/identities", Description: "list and search auth identities", Handler: admin.identitiesHandler},
+		// eg: /admin/identities/password/jinzhu@example.org
+		{Method: "GET", Path: "admin/identities/:provider/:uid", Description: "render auth identity", Handler: func(context *auth.Context) {
+			admin.identityHandler(context, context.Param("provider"), context.Param("uid"))
+		}},
+		// eg: POST /admin/identities/password/jinzhu@example.org/impersonate
+		{Method: "POST", Path: "admin/identities/:provider/:uid/impersonate", Description: "impersonate user of auth identity", Handler: func(context *auth.Context) {
+			admin.impersonateHandler(context, context.Param("provider"), context.Param("uid"))
+		}},
+		// eg: POST /admin/identities/password/jinzhu@example.org/lock
+		{Method: "POST", Path: "admin/identities/:provider/:uid/:action", Description: "run action on auth identity, like lock, unlock, confirm", Handler: func(context *auth.Context) {
+			admin.actionHandler(context, context.Param("provider"), context.Param("uid"), context.Param("action"))
+		}},
+	} {
+		route.Handler = admin.authorize(route.Handler)
+		admin.Auth.AddRoute(route)
+	}
+}
+
+// authorize wrap handler, only users have admin's Roles could access it
+func (admin *Admin) authorize(handler func(*auth.Context)) func(*auth.Context) {
+	return func(context *auth.Context) {
+		admin.Authority.Authorize(admin.Roles...)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handler(context)
+		})).ServeHTTP(context.Writer, context.Request)
+	}
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/jinzhu/copier"
+	"github.com/qor/qor/utils"
+)
+
+// Audit record action done by current user to target into audit logs, actions done while impersonating are recorded as done by the impersonator
+func (admin *Admin) Audit(req *http.Request, action string, target *claims.Claims, detail string) error {
+	var (
+		auditLog = reflect.New(utils.ModelType(admin.AuditLogModel)).Interface()
+		record   = auth_identity.AuditLog{Action: action, Detail: detail, IP: req.RemoteAddr, TenantID: admin.Auth.GetTenant(req)}
+	)
+
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		record.IP = host
+	}
+
+	if actor, err := admin.Auth.SessionStorer.Get(req); err == nil {
+		if actor.IsImpersonating() {
+			// actions done while impersonating are recorded as done by the admin
+			record.ActorProvider, record.ActorUID, record.ActorUserID = actor.Impersonator.Provider, actor.Impersonator.UID, actor.Impersonator.UserID
+		} else {
+			record.ActorProvider, record.ActorUID, record.ActorUserID = actor.Provider, actor.Id, actor.UserID
+		}
+	}
+
+	if target != nil {
+		record.Provider, record.UID, record.UserID = target.Provider, target.Id, target.UserID
+	}
+
+	copier.Copy(auditLog, &record)
+	return admin.Auth.GetDB(req).Create(auditLog).Error
+}
+
+// AuditLogs return latest audit logs of target, return all latest audit logs if target is nil
+func (admin *Admin) AuditLogs(req *http.Request, target *claims.Claims, limit int) (auditLogs []auth_identity.AuditLog) {
+	tx := admin.Auth.ScopeTenant(req, admin.Auth.GetDB(req)).Model(admin.AuditLogModel)
+
+	if target != nil {
+		tx = tx.Where(map[string]interface{}{"provider": target.Provider, "uid": target.Id})
+	}
+
+	tx.Order("id desc").Limit(limit).Scan(&auditLogs)
+	return
+}
@@ -0,0 +1,354 @@
+package admin
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/fahmibaswara/auth/providers/password"
+	"github.com/qor/responder"
+	"github.com/qor/session"
+)
+
+var (
+	// ErrIdentityNotFound auth identity not found error
+	ErrIdentityNotFound = &auth.Error{Code: "identity_not_found", Status: http.StatusNotFound, Message: "Identity not found", MessageID: "admin.errors.identity_not_found"}
+	// ErrUnknownAction unknown admin action error
+	ErrUnknownAction = &auth.Error{Code: "unknown_admin_action", Status: http.StatusNotFound, Message: "Unknown action", MessageID: "admin.errors.unknown_action"}
+	// ErrPasswordResetUnsupported force password reset for non password identity error
+	ErrPasswordResetUnsupported = &auth.Error{Code: "password_reset_unsupported", Status: http.StatusUnprocessableEntity, Message: "Password could only be reset for password identities", MessageID: "admin.errors.password_reset_unsupported"}
+
+	// ActionFlashMessages flash messages shown after admin actions succeed, keyed by action
+	ActionFlashMessages = map[string]string{
+		"confirm":         "Confirmed the identity",
+		"unconfirm":       "Unconfirmed the identity",
+		"lock":            "Locked the identity",
+		"unlock":          "Unlocked the identity",
+		"reset_password":  "Password has been reset, an email with reset password instructions has been sent",
+		"revoke_sessions": "Revoked all sessions",
+		"delete":          "Removed the identity",
+	}
+)
+
+// Identity auth identity shown in admin pages, secrets like encrypted password are not included
+type Identity struct {
+	Provider    string                  `json:"provider"`
+	UID         string                  `json:"uid"`
+	UserID      string                  `json:"user_id,omitempty"`
+	TenantID    string                  `json:"tenant_id,omitempty"`
+	ConfirmedAt *time.Time              `json:"confirmed_at,omitempty"`
+	LockedAt    *time.Time              `json:"locked_at,omitempty"`
+	CreatedAt   *time.Time              `json:"created_at,omitempty"`
+	SignInCount uint                    `json:"sign_in_count"`
+	SignLogs    []auth_identity.SignLog `json:"sign_logs,omitempty"`
+}
+
+// ToClaims convert to auth Claims
+func (identity Identity) ToClaims() *claims.Claims {
+	return auth_identity.Basic{Provider: identity.Provider, UID: identity.UID, UserID: identity.UserID, TenantID: identity.TenantID}.ToClaims()
+}
+
+type identityRecord struct {
+	auth_identity.Basic
+	auth_identity.SignLogs
+	CreatedAt *time.Time
+}
+
+func (record identityRecord) toIdentity() Identity {
+	return Identity{
+		Provider:    record.Provider,
+		UID:         record.UID,
+		UserID:      record.UserID,
+		TenantID:    record.TenantID,
+		ConfirmedAt: record.ConfirmedAt,
+		LockedAt:    record.LockedAt,
+		CreatedAt:   record.CreatedAt,
+		SignInCount: record.SignInCount,
+		SignLogs:    record.Logs,
+	}
+}
+
+// IdentityURL generate URL of identity's admin page
+func (admin *Admin) IdentityURL(identity Identity) string {
+	return admin.URL("identities/" + url.PathEscape(identity.Provider) + "/" + url.PathEscape(identity.UID))
+}
+
+// SearchIdentities search auth identities by uid or user id, and provider, returns identities of page and total count
+func (admin *Admin) SearchIdentities(req *http.Request, query string, provider string, page int) (identities []Identity, total int) {
+	var (
+		records []identityRecord
+		tx      = admin.Auth.ScopeTenant(req, admin.Auth.GetDB(req)).Model(admin.Auth.Config.AuthIdentityModel)
+	)
+
+	if query != "" {
+		tx = tx.Where("uid LIKE ? OR user_id = ?", "%"+query+"%", query)
+	}
+
+	if provider != "" {
+		tx = tx.Where("provider = ?", provider)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	tx.Count(&total)
+	tx.Order("uid").Offset((page - 1) * admin.PerPage).Limit(admin.PerPage).Scan(&records)
+
+	for _, record := range records {
+		identities = append(identities, record.toIdentity())
+	}
+	return
+}
+
+// FindIdentity find auth identity with provider and uid
+func (admin *Admin) FindIdentity(req *http.Request, provider string, uid string) (*Identity, error) {
+	var record identityRecord
+
+	if admin.Auth.GetDB(req).Model(admin.Auth.Config.AuthIdentityModel).Where(admin.Auth.TenantConditions(req, map[string]interface{}{
+		"provider": provider,
+		"uid":      uid,
+	})).Scan(&record).RecordNotFound() {
+		return nil, ErrIdentityNotFound
+	}
+
+	identity := record.toIdentity()
+	return &identity, nil
+}
+
+// LinkedIdentities find other auth identities of identity's user
+func (admin *Admin) LinkedIdentities(req *http.Request, identity *Identity) (identities []Identity) {
+	var records []identityRecord
+
+	if identity.UserID != "" {
+		admin.Auth.ScopeTenant(req, admin.Auth.GetDB(req)).Model(admin.Auth.Config.AuthIdentityModel).Where("user_id = ? AND NOT (provider = ? AND uid = ?)", identity.UserID, identity.Provider, identity.UID).Scan(&records)
+	}
+
+	for _, record := range records {
+		identities = append(identities, record.toIdentity())
+	}
+	return
+}
+
+func (admin *Admin) identitiesHandler(context *auth.Context) {
+	var (
+		w          = context.Writer
+		req        = context.Request
+		query      = req.URL.Query().Get("q")
+		provider   = req.URL.Query().Get("provider")
+		page, _    = strconv.Atoi(req.URL.Query().Get("page"))
+		identities []Identity
+		total      int
+		pageURL    = func(page int) string {
+			values := url.Values{"q": {query}, "provider": {provider}, "page": {strconv.Itoa(page)}}
+			return admin.URL("identities") + "?" + values.Encode()
+		}
+	)
+
+	if page < 1 {
+		page = 1
+	}
+	identities, total = admin.SearchIdentities(req, query, provider, page)
+
+	responder.With("html", func() {
+		admin.Auth.Config.Render.Funcs(template.FuncMap{
+			"identities":   func() []Identity { return identities },
+			"total":        func() int { return total },
+			"query":        func() string { return query },
+			"provider":     func() string { return provider },
+			"providers":    func() []auth.Provider { return admin.Auth.GetTenantProviders(req) },
+			"identity_url": admin.IdentityURL,
+			"prev_page_url": func() string {
+				if page > 1 {
+					return pageURL(page - 1)
+				}
+				return ""
+			},
+			"next_page_url": func() string {
+				if page*admin.PerPage < total {
+					return pageURL(page + 1)
+				}
+				return ""
+			},
+		}).Execute("auth/admin/identities", context, req, w)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"identities": identities,
+			"total":      total,
+			"page":       page,
+			"per_page":   admin.PerPage,
+		})
+	}).Respond(req)
+}
+
+func (admin *Admin) identityHandler(context *auth.Context, provider string, uid string) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	identity, err := admin.FindIdentity(req, provider, uid)
+	if err != nil {
+		admin.respondError(context, err, admin.URL("identities"))
+		return
+	}
+
+	var (
+		user       interface{}
+		linked     = admin.LinkedIdentities(req, identity)
+		auditLogs  = admin.AuditLogs(req, identity.ToClaims(), 20)
+		actionURLs = map[string]string{}
+	)
+
+	if admin.Auth.Config.UserModel != nil && identity.UserID != "" {
+		user, _ = admin.Auth.UserStorer.Get(identity.ToClaims(), context)
+	}
+
+	for action := range ActionFlashMessages {
+		actionURLs[action] = admin.IdentityURL(*identity) + "/" + action
+	}
+	actionURLs["impersonate"] = admin.IdentityURL(*identity) + "/impersonate"
+
+	responder.With("html", func() {
+		admin.Auth.Config.Render.Funcs(template.FuncMap{
+			"identity":          func() *Identity { return identity },
+			"linked_identities": func() []Identity { return linked },
+			"user":              func() interface{} { return user },
+			"audit_logs":        func() []auth_identity.AuditLog { return auditLogs },
+			"identity_url":      admin.IdentityURL,
+			"action_url":        func(action string) string { return actionURLs[action] },
+		}).Execute("auth/admin/identity", context, req, w)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"identity":          identity,
+			"linked_identities": linked,
+			"user":              user,
+			"audit_logs":        auditLogs,
+		})
+	}).Respond(req)
+}
+
+// DoAction do admin action to identity, supported actions are keys of ActionFlashMessages, the action will be recorded into audit logs
+func (admin *Admin) DoAction(context *auth.Context, identity *Identity, action string) error {
+	var (
+		req    = context.Request
+		store  = admin.Auth.IdentityStore
+		claims = identity.ToClaims()
+	)
+
+	authInfo, err := store.Find(req, identity.Provider, identity.UID)
+	if err == auth.ErrIdentityNotFound {
+		return ErrIdentityNotFound
+	} else if err != nil {
+		return err
+	}
+
+	switch action {
+	case "confirm":
+		now := admin.Auth.Now()
+		authInfo.ConfirmedAt = &now
+		err = store.Update(req, authInfo)
+	case "unconfirm":
+		authInfo.ConfirmedAt = nil
+		err = store.Update(req, authInfo)
+	case "lock":
+		err = admin.Auth.Lock(req, claims)
+	case "unlock":
+		err = admin.Auth.Unlock(req, claims)
+	case "reset_password":
+		provider, ok := admin.Auth.GetTenantProvider(req, identity.Provider).(*password.Provider)
+		if !ok {
+			return ErrPasswordResetUnsupported
+		}
+
+		// clear current password, so it can't be used to sign in anymore
+		authInfo.EncryptedPassword = ""
+		if err = store.Update(req, authInfo); err == nil {
+			var currentUser interface{}
+			if currentUser, err = admin.Auth.UserStorer.Get(claims, context); err == nil {
+				err = provider.ResetPasswordMailer(identity.UID, context, claims, currentUser)
+			}
+		}
+
+		if err == nil && admin.Auth.Config.SessionRevoker != nil {
+			err = admin.Auth.RevokeSessions(nil, req, claims)
+		}
+	case "revoke_sessions":
+		err = admin.Auth.RevokeSessions(nil, req, claims)
+	case "delete":
+		if err = store.Delete(req, identity.Provider, identity.UID); err == nil {
+			err = admin.Auth.TokenStore.Delete(req, identity.UID)
+		}
+	default:
+		return ErrUnknownAction
+	}
+
+	if err != nil {
+		return err
+	}
+
+	admin.Auth.InvalidateUser(claims)
+	return admin.Audit(req, "admin."+action, claims, "")
+}
+
+func (admin *Admin) actionHandler(context *auth.Context, provider string, uid string, action string) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	identity, err := admin.FindIdentity(req, provider, uid)
+	if err == nil {
+		err = admin.DoAction(context, identity, action)
+	}
+
+	if err != nil {
+		backURL := admin.URL("identities")
+		if identity != nil {
+			backURL = admin.IdentityURL(*identity)
+		}
+		admin.respondError(context, err, backURL)
+		return
+	}
+
+	responder.With("html", func() {
+		redirectURL := admin.IdentityURL(*identity)
+		if action == "delete" {
+			redirectURL = admin.URL("identities")
+		}
+
+		context.SessionStorer.Flash(w, req, session.Message{Message: context.T("admin.flash."+action, ActionFlashMessages[action]), Type: "success"})
+		http.Redirect(w, req, redirectURL, http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		if updated, err := admin.FindIdentity(req, provider, uid); err == nil {
+			identity = updated
+		} else {
+			identity = nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"action": action, "identity": identity})
+	}).Respond(req)
+}
+
+func (admin *Admin) respondError(context *auth.Context, err error, redirectURL string) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	responder.With("html", func() {
+		admin.Auth.FlashError(w, req, err)
+		http.Redirect(w, req, redirectURL, http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		admin.Auth.WriteError(w, req, err)
+	}).Respond(req)
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/authtest"
+)
+
+func TestRevokeTenantSessions(t *testing.T) {
+	Auth := authtest.New(&auth.Config{TenantResolver: &auth.HeaderTenantResolver{Tenants: []string{"a", "b"}}})
+
+	var (
+		req      = httptest.NewRequest("POST", "/", nil)
+		issuedAt = Auth.Now().Add(-time.Minute).Unix()
+		sessions = map[string]bool{"a": true, "b": false}
+		record   = identityRecord{Basic: auth_identity.Basic{Provider: "password", UID: "jinzhu@example.com", UserID: "1", TenantID: "a"}}
+	)
+
+	if err := Auth.RevokeSessions(nil, req, record.toIdentity().ToClaims()); err != nil {
+		t.Fatalf("failed to revoke sessions, got %v", err)
+	}
+
+	for tenant, revoked := range sessions {
+		session := auth_identity.Basic{Provider: "password", UID: "jinzhu@example.com", UserID: "1", TenantID: tenant}.ToClaims()
+		session.IssuedAt = issuedAt
+
+		if Auth.SessionRevoker.IsRevoked(req, session) != revoked {
+			t.Errorf("session of tenant %v should be revoked: %v", tenant, revoked)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/responder"
+	"github.com/qor/session"
+)
+
+var (
+	// ErrAlreadyImpersonating impersonate while signed in as another user error
+	ErrAlreadyImpersonating = &auth.Error{Code: "already_impersonating", Status: http.StatusConflict, Message: "You are already signed in as another user", MessageID: "admin.errors.already_impersonating"}
+	// ErrNotImpersonating stop impersonating while not signed in as another user error
+	ErrNotImpersonating = &auth.Error{Code: "not_impersonating", Status: http.StatusBadRequest, Message: "You are not signed in as another user", MessageID: "admin.errors.not_impersonating"}
+
+	// ImpersonateFlashMessage impersonate success flash message
+	ImpersonateFlashMessage = template.HTML("You are signed in as the user now")
+	// StopImpersonatingFlashMessage stop impersonating success flash message
+	StopImpersonatingFlashMessage = template.HTML("You are back to your account")
+
+	// ImpersonationBannerTemplate template of impersonation banner, refer Admin.ImpersonationBanner
+	ImpersonationBannerTemplate = template.Must(template.New("impersonation_banner").Parse(`<div class="impersonation-banner" style="text-align: center;">
+  {{.Message}} <strong>{{.UID}}</strong>
+  <form action="{{.StopURL}}" method="POST" style="display: inline;">
+    <input type="submit" value="{{.Submit}}">
+  </form>
+</div>`))
+)
+
+// Impersonate sign current admin in as identity, admin's own identity is kept in claims' Impersonator, so the admin could return to it with StopImpersonating
+func (admin *Admin) Impersonate(w http.ResponseWriter, req *http.Request, identity *Identity) error {
+	current, err := admin.Auth.SessionStorer.Get(req)
+	if err != nil {
+		return auth.ErrUnauthorized
+	}
+
+	if current.IsImpersonating() {
+		return ErrAlreadyImpersonating
+	}
+
+	var (
+		now    = admin.Auth.Now()
+		target = identity.ToClaims()
+	)
+
+	target.Impersonator = &claims.Impersonator{
+		Provider:  current.Provider,
+		UID:       current.Id,
+		UserID:    current.UserID,
+		StartedAt: &now,
+	}
+
+	if err = admin.Audit(req, "admin.impersonate", target, ""); err != nil {
+		return err
+	}
+	return admin.Auth.Login(w, req, target)
+}
+
+// StopImpersonating return current admin back to own session, returns the impersonated user's claims
+func (admin *Admin) StopImpersonating(w http.ResponseWriter, req *http.Request) (*claims.Claims, error) {
+	current, err := admin.Auth.SessionStorer.Get(req)
+	if err != nil {
+		return nil, auth.ErrUnauthorized
+	}
+
+	if !current.IsImpersonating() {
+		return nil, ErrNotImpersonating
+	}
+
+	var (
+		impersonator = current.Impersonator
+		original     = &claims.Claims{Provider: impersonator.Provider, UserID: impersonator.UserID, TenantID: current.TenantID}
+		detail       string
+	)
+	original.Id = impersonator.UID
+
+	if impersonator.StartedAt != nil {
+		detail = "duration: " + admin.Auth.Now().Sub(*impersonator.StartedAt).Round(time.Second).String()
+	}
+
+	if err = admin.Audit(req, "admin.stop_impersonating", current, detail); err != nil {
+		return nil, err
+	}
+
+	if err = admin.Auth.Login(w, req, original); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// ImpersonationBanner render a banner with a button to return to admin's own session when the admin is signed in as another user, it is blank otherwise
+func (admin *Admin) ImpersonationBanner(req *http.Request) template.HTML {
+	current, err := admin.Auth.SessionStorer.Get(req)
+	if err != nil || !current.IsImpersonating() {
+		return ""
+	}
+
+	var result bytes.Buffer
+	ImpersonationBannerTemplate.Execute(&result, map[string]string{
+		"Message": admin.Auth.Translate(req, "admin.impersonation.banner", "You are signed in as"),
+		"UID":     current.Id,
+		"StopURL": admin.URL("stop_impersonating"),
+		"Submit":  admin.Auth.Translate(req, "admin.impersonation.stop", "Back to your account"),
+	})
+	return template.HTML(result.String())
+}
+
+func (admin *Admin) impersonateHandler(context *auth.Context, provider string, uid string) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	identity, err := admin.FindIdentity(req, provider, uid)
+	if err == nil {
+		err = admin.Impersonate(w, req, identity)
+	}
+
+	if err != nil {
+		backURL := admin.URL("identities")
+		if identity != nil {
+			backURL = admin.IdentityURL(*identity)
+		}
+		admin.respondError(context, err, backURL)
+		return
+	}
+
+	responder.With("html", func() {
+		context.SessionStorer.Flash(w, req, session.Message{Message: context.T("admin.flash.impersonate", string(ImpersonateFlashMessage)), Type: "success"})
+		context.Auth.Redirector.Redirect(w, req, "impersonate")
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"impersonating": identity})
+	}).Respond(req)
+}
+
+func (admin *Admin) stopImpersonatingHandler(context *auth.Context) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	target, err := admin.StopImpersonating(w, req)
+	if err != nil {
+		admin.respondError(context, err, "/")
+		return
+	}
+
+	responder.With("html", func() {
+		context.SessionStorer.Flash(w, req, session.Message{Message: context.T("admin.flash.stop_impersonating", string(StopImpersonatingFlashMessage)), Type: "success"})
+		http.Redirect(w, req, admin.IdentityURL(Identity{Provider: target.Provider, UID: target.Id}), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"impersonating": nil})
+	}).Respond(req)
+}
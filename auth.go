@@ -2,11 +2,15 @@ package auth
 
 import (
 	"fmt"
+	"net/http"
+	"net/mail"
 	"strings"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
+	"github.com/fahmibaswara/auth/i18n"
 	"github.com/jinzhu/gorm"
 	"github.com/qor/mailer"
 	"github.com/qor/mailer/logger"
@@ -20,7 +24,16 @@ type Auth struct {
 	*Config
 	// Embed SessionStorer to match Authority's AuthInterface
 	SessionStorerInterface
-	providers []Provider
+	providers      []Provider
+	mailPreviews   map[string]func(*Context) Mail
+	localeFromUser bool
+	rolesFromUser  bool
+
+	accountExporters map[string]AccountExporter
+	accountErasers   []AccountEraser
+	routes           []Route
+	tenants          map[string]*tenantProviders
+	configured       map[string]bool
 }
 
 // SMSSender Interface
@@ -53,8 +66,21 @@ type Config struct {
 
 	// Auth is using [Render](https://github.com/qor/render) to render pages, you could configure it with your project's Render if you have advanced usage like [BindataFS](https://github.com/qor/bindatafs)
 	Render *render.Render
+	// I18n translates flash messages, errors, views and mails, messages are keyed by message ID, refer https://github.com/fahmibaswara/auth/blob/master/i18n/id.go for built-in translations
+	I18n *i18n.Translator
+	// LocaleCookieName cookie used to save user's chosen locale, default value is `locale`
+	LocaleCookieName string
+	// ReturnToCookieName cookie used to save where to return after signed in, set it with `{Auth Prefix}/login?return_to=/orders`, default value is `auth_return_to`
+	ReturnToCookieName string
+	// Clock return current time, which is used to issue and check expiration of tokens, sessions, account deletions, cached users, and by Authority, default is time.Now, it could be replaced in tests, refer authtest.Clock
+	Clock func() time.Time
+	// ErrorLogger log errors responded by handlers, default is DefaultErrorLogger, which logs internal causes of errors
+	ErrorLogger func(req *http.Request, err *Error)
+
 	// Auth is using [Mailer](https://github.com/qor/mailer) to send email, by default, it will print email into console, you need to configure it to send real one
 	Mailer *mailer.Mailer
+	// Mail configure sender, reply-to, subjects, locale of mails sent by Auth
+	Mail *MailConfig
 
 	Confirmable    bool
 	ConfirmMailer  func(email string, context *Context, claims *claims.Claims, currentUser interface{}) error
@@ -64,8 +90,22 @@ type Config struct {
 	SMSSender SMSSender
 	// UserToken a model used to save token that generated for authentication via Phone, https://github.com/fahmibaswara/auth/blob/master/auth_identity/auth_token.go is the default implemention
 	UserTokenModel interface{}
+	// IdentityStore is an interface that defined how to find/save auth identities, Auth provides a gorm based one `GormIdentityStore` (default), a database/sql based one `SQLIdentityStore` and an in-memory one `MemoryIdentityStore`, note account export and erasure, admin pages, password history, and UserStorer without UserModel still query AuthIdentityModel's table in DB with gorm
+	IdentityStore IdentityStore
+	// TokenStore is an interface that defined how to find/save tokens sent to users, like phone verification codes, default is GormTokenStore, which saves UserTokenModel into DB
+	TokenStore TokenStore
+	// TenantResolver resolve tenant of requests, auth identities, tokens and sessions are isolated by tenant if it is configured, e.g. `&auth.HostTenantResolver{Domain: "example.com"}`, refer RegisterTenantProvider for tenants' own providers
+	TenantResolver TenantResolver
 	// UserStorer is an interface that defined how to get/save user, Auth provides a default one based on AuthIdentityModel, UserModel's definition
 	UserStorer UserStorerInterface
+	// UserCache cache users loaded by UserStorer across requests, e.g. `&auth.MemoryUserCache{TTL: time.Minute}`, call Auth.InvalidateUser after changed users, it is disabled if blank
+	UserCache UserCacheInterface
+	// ActionToken configure tokens used in links sent to users, like confirm account, reset password
+	ActionToken *ActionTokenConfig
+	// Account configure how to delete accounts, refer `{Auth Prefix}/account/delete`
+	Account *AccountConfig
+	// SessionRevoker is an interface that defined how to revoke sessions, e.g. sign out other devices after changed password, sessions can't be revoked if it is blank, Auth provides an in-memory implementation `MemorySessionRevoker` and a database based one `DBSessionRevoker`
+	SessionRevoker SessionRevokerInterface
 	// SessionStorer is an interface that defined how to encode/validate/save/destroy session data and flash messages between requests, Auth provides a default method do the job, to use the default value, don't forgot to mount SessionManager's middleware into your router to save session data correctly. refer [session](https://github.com/qor/session) for more details
 	SessionStorer SessionStorerInterface
 	// Redirector redirect user to a new page after registered, logged, confirmed...
@@ -77,6 +117,8 @@ type Config struct {
 	RegisterHandler func(*Context, func(*Context) (*claims.Claims, error))
 	// LogoutHandler defined behaviour when request `{Auth Prefix}/logout`, default behaviour defined in http://godoc.org/github.com/fahmibaswara/auth#pkg-variables
 	LogoutHandler func(*Context)
+	// ProviderTokenHandler called when providers issued access tokens, like logged or linked with OAuth providers, save tokens if you need to call providers' APIs, refresh them with RefreshProviderToken, sync profiles with SyncProfile
+	ProviderTokenHandler func(context *Context, claims *claims.Claims, token *ProviderToken) error
 }
 
 // New initialize Auth
@@ -109,6 +151,38 @@ func New(config *Config) *Auth {
 		})
 	}
 
+	if config.I18n == nil {
+		config.I18n = i18n.New("en")
+	}
+
+	if config.LocaleCookieName == "" {
+		config.LocaleCookieName = "locale"
+	}
+
+	if config.ReturnToCookieName == "" {
+		config.ReturnToCookieName = "auth_return_to"
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	if config.ErrorLogger == nil {
+		config.ErrorLogger = DefaultErrorLogger
+	}
+
+	if config.Mail == nil {
+		config.Mail = &MailConfig{}
+	}
+
+	if config.Mail.From == nil {
+		config.Mail.From = &mail.Address{Address: "admin@example.org"}
+	}
+
+	if config.Mail.Locale == nil {
+		config.Mail.Locale = DefaultMailLocale
+	}
+
 	if config.ConfirmMailer == nil {
 		config.ConfirmMailer = DefaultConfirmationMailer
 	}
@@ -125,6 +199,10 @@ func New(config *Config) *Auth {
 		config.SMSSender = &DefaultSMSSender{}
 	}
 
+	if config.Account == nil {
+		config.Account = &AccountConfig{}
+	}
+
 	if config.UserStorer == nil {
 		config.UserStorer = &UserStorer{}
 	}
@@ -134,11 +212,12 @@ func New(config *Config) *Auth {
 			SessionName:    "_auth_session",
 			SessionManager: manager.SessionManager,
 			SigningMethod:  jwt.SigningMethodHS256,
+			SessionRevoker: config.SessionRevoker,
 		}
 	}
 
 	if config.Redirector == nil {
-		config.Redirector = &Redirector{redirect_back.New(&redirect_back.Config{
+		config.Redirector = &Redirector{RedirectBack: redirect_back.New(&redirect_back.Config{
 			SessionManager:  manager.SessionManager,
 			IgnoredPrefixes: []string{config.URLPrefix},
 		})}
@@ -161,10 +240,67 @@ func New(config *Config) *Auth {
 	}
 
 	config.Render.RegisterViewPath("github.com/fahmibaswara/auth/views")
+	config.Mailer.RegisterViewPath("github.com/fahmibaswara/auth/views/mailers")
 
 	auth := &Auth{Config: config}
 
+	if revoker, ok := config.SessionRevoker.(*DBSessionRevoker); ok {
+		if revoker.Auth == nil {
+			revoker.Auth = auth
+		}
+
+		if revoker.SessionRevocationModel == nil {
+			revoker.SessionRevocationModel = &auth_identity.SessionRevocation{}
+		}
+	}
+
+	if config.IdentityStore == nil {
+		config.IdentityStore = &GormIdentityStore{}
+	}
+
+	if store, ok := config.IdentityStore.(*GormIdentityStore); ok && store.Auth == nil {
+		store.Auth = auth
+	}
+
+	if config.TokenStore == nil {
+		config.TokenStore = &GormTokenStore{}
+	}
+
+	if store, ok := config.TokenStore.(*GormTokenStore); ok && store.Auth == nil {
+		store.Auth = auth
+	}
+
+	if storer, ok := config.SessionStorer.(*SessionStorer); ok && storer.Clock == nil {
+		storer.Clock = config.Clock
+	}
+
+	if store, ok := config.IdentityStore.(*SQLIdentityStore); ok && store.Clock == nil {
+		store.Clock = config.Clock
+	}
+
+	if cache, ok := config.UserCache.(*MemoryUserCache); ok && cache.Clock == nil {
+		cache.Clock = config.Clock
+	}
+
+	if config.TenantResolver != nil {
+		auth.initTenantStores()
+	}
+
 	auth.SessionStorerInterface = config.SessionStorer
 
+	if redirector, ok := config.Redirector.(*Redirector); ok && redirector.Auth == nil {
+		redirector.Auth = auth
+	}
+
+	if config.ActionToken == nil {
+		config.ActionToken = &ActionTokenConfig{}
+	}
+	auth.initActionTokenConfig()
+	auth.registerDefaultMailPreviews()
+	auth.localeFromUser = auth.userHasLocale()
+	auth.rolesFromUser = auth.userHasRoles()
+	auth.initAccountConfig()
+	auth.registerDefaultRoutes()
+
 	return auth
 }
@@ -0,0 +1,18 @@
+package auth_identity
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AccountDeletion account deletion request, the account will be erased after DeleteAt
+type AccountDeletion struct {
+	gorm.Model
+	SessionKey string `gorm:"unique_index"`
+	Provider   string
+	UID        string `gorm:"column:uid"`
+	UserID     string
+	DeleteAt   time.Time `gorm:"index"`
+	TenantID   string
+}
@@ -0,0 +1,20 @@
+package auth_identity
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// AuditLog audit log of actions done by admins, Actor* fields are the admin's auth identity, Provider, UID, UserID are the target's
+type AuditLog struct {
+	gorm.Model
+	Action        string `gorm:"index"`
+	ActorProvider string
+	ActorUID      string
+	ActorUserID   string
+	Provider      string
+	UID           string `gorm:"column:uid;index"`
+	UserID        string
+	IP            string
+	Detail        string
+	TenantID      string `gorm:"index"`
+}
@@ -16,11 +16,14 @@ type AuthIdentity struct {
 
 // Basic basic information about auth identity
 type Basic struct {
-	Provider          string // phone, email, wechat, github...
-	UID               string `gorm:"column:uid"`
+	Provider          string `gorm:"unique_index:idx_auth_identity_provider_uid"` // phone, email, wechat, github...
+	UID               string `gorm:"column:uid;unique_index:idx_auth_identity_provider_uid"`
 	EncryptedPassword string
 	UserID            string
 	ConfirmedAt       *time.Time
+	LockedAt          *time.Time
+	// TenantID tenant of auth identity, provider and uid are unique in a tenant, refer auth.TenantResolver
+	TenantID string `gorm:"index;unique_index:idx_auth_identity_provider_uid"`
 }
 
 // ToClaims convert to auth Claims
@@ -29,5 +32,6 @@ func (basic Basic) ToClaims() *claims.Claims {
 	claims.Provider = basic.Provider
 	claims.Id = basic.UID
 	claims.UserID = basic.UserID
+	claims.TenantID = basic.TenantID
 	return &claims
 }
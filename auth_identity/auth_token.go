@@ -9,4 +9,12 @@ type AuthToken struct {
 	Identity   string
 	Token      string
 	ValidUntil *time.Time
+	// Channel channel the token was sent with, like `sms`, `email`
+	Channel string
+	// Attempts failed attempts to check the token
+	Attempts int
+	// SentAt when the token was sent
+	SentAt *time.Time
+	// TenantID tenant of token, refer auth.TenantResolver
+	TenantID string
 }
@@ -0,0 +1,14 @@
+package auth_identity
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// PasswordHistory previously used encrypted password of an auth identity
+type PasswordHistory struct {
+	gorm.Model
+	Provider          string
+	UID               string `gorm:"column:uid"`
+	EncryptedPassword string
+	TenantID          string
+}
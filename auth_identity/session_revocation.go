@@ -0,0 +1,12 @@
+package auth_identity
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// SessionRevocation sessions of SessionKey's user that issued before RevokedBefore (unix time) are revoked
+type SessionRevocation struct {
+	gorm.Model
+	SessionKey    string `gorm:"unique_index"`
+	RevokedBefore int64
+}
@@ -0,0 +1,14 @@
+package auth_identity
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// UsedActionToken action token that has been used
+type UsedActionToken struct {
+	gorm.Model
+	TokenID   string `gorm:"unique_index"`
+	ExpiresAt *time.Time
+}
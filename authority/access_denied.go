@@ -0,0 +1,84 @@
+package authority
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/qor/responder"
+)
+
+// ErrAccessDenied access denied error, responded to signed in users that not allowed
+var ErrAccessDenied = &auth.Error{Code: "access_denied", Status: http.StatusForbidden, Message: string(AccessDeniedFlashMessage), MessageID: "authority.flash.access_denied"}
+
+// NewUnauthenticatedHandler new handler for requests that not signed in, it responds 401 with `WWW-Authenticate` header for JSON requests, and redirects others to loginURL with current URL as `return_to`
+func NewUnauthenticatedHandler(Auth AuthInterface, loginURL string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		responder.With("html", func() {
+			values := url.Values{"return_to": {req.URL.RequestURI()}}
+			http.Redirect(w, req, loginURL+"?"+values.Encode(), http.StatusSeeOther)
+		}).With([]string{"json"}, func() {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="auth"`)
+			writeError(Auth, w, req, auth.ErrUnauthorized)
+		}).Respond(req)
+	}
+}
+
+// NewForbiddenHandler new handler for signed in requests that not allowed, it responds 403 with `WWW-Authenticate` header for JSON requests, and renders `auth/access_denied` page with 403 for others
+func NewForbiddenHandler(Auth AuthInterface) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		responder.With("html", func() {
+			if Auth, ok := Auth.(*auth.Auth); ok {
+				w.WriteHeader(http.StatusForbidden)
+				Auth.Config.Render.Execute("auth/access_denied", &auth.Context{Auth: Auth, Request: req, Writer: w}, req, w)
+				return
+			}
+			http.Error(w, ErrAccessDenied.Message, http.StatusForbidden)
+		}).With([]string{"json"}, func() {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="auth", error="insufficient_scope"`)
+			writeError(Auth, w, req, ErrAccessDenied)
+		}).Respond(req)
+	}
+}
+
+func writeError(Auth AuthInterface, w http.ResponseWriter, req *http.Request, err *auth.Error) {
+	if writer, ok := Auth.(interface {
+		WriteError(w http.ResponseWriter, req *http.Request, err error)
+	}); ok {
+		writer.WriteError(w, req, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(auth.ErrorResponse{Code: err.Code, Message: err.Message})
+}
+
+// WithDeniedHandlers return a copy of authority that uses specified handlers when access denied, use it to customize handlers of routes, blank handlers are not changed
+func (authority *Authority) WithDeniedHandlers(unauthenticatedHandler func(http.ResponseWriter, *http.Request), accessDeniedHandler func(http.ResponseWriter, *http.Request)) *Authority {
+	var (
+		copied = *authority
+		config = *authority.Config
+	)
+
+	if unauthenticatedHandler != nil {
+		config.UnauthenticatedHandler = unauthenticatedHandler
+	}
+
+	if accessDeniedHandler != nil {
+		config.AccessDeniedHandler = accessDeniedHandler
+	}
+
+	copied.Config = &config
+	return &copied
+}
+
+// deny respond request that not allowed with UnauthenticatedHandler if not signed in, AccessDeniedHandler otherwise
+func (authority *Authority) deny(w http.ResponseWriter, req *http.Request) {
+	if _, err := authority.getClaims(req); err != nil {
+		authority.UnauthenticatedHandler(w, req)
+		return
+	}
+	authority.AccessDeniedHandler(w, req)
+}
@@ -0,0 +1,70 @@
+package authority
+
+import (
+	"sync"
+	"time"
+)
+
+// Activity activity of a session
+type Activity struct {
+	LastActiveAt                     *time.Time
+	LongestDistractionSinceLastLogin *time.Duration
+}
+
+// ActivityStore save activity of sessions in server side, so activity of sessions that can't be re-issued, like bearer tokens of API clients, could be tracked
+type ActivityStore interface {
+	Get(key string) (Activity, bool)
+	Set(key string, activity Activity)
+}
+
+// MemoryActivityStore in-memory activity store, activity is kept in current process only
+type MemoryActivityStore struct {
+	// Expiration activity inactive longer than it will be removed, default is 24 hours
+	Expiration time.Duration
+	// Clock current time used to remove expired activities, default is time.Now, Authority will set it to its Clock if blank
+	Clock func() time.Time
+
+	mutex      sync.Mutex
+	activities map[string]Activity
+	prunedAt   time.Time
+}
+
+// Get get activity of session key
+func (store *MemoryActivityStore) Get(key string) (Activity, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	activity, ok := store.activities[key]
+	return activity, ok
+}
+
+// Set save activity of session key
+func (store *MemoryActivityStore) Set(key string, activity Activity) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.activities == nil {
+		store.activities = map[string]Activity{}
+	}
+	store.activities[key] = activity
+
+	expiration := store.Expiration
+	if expiration <= 0 {
+		expiration = 24 * time.Hour
+	}
+
+	now := time.Now()
+	if store.Clock != nil {
+		now = store.Clock()
+	}
+
+	// remove expired activities
+	if now.Sub(store.prunedAt) > expiration/24 {
+		for key, activity := range store.activities {
+			if activity.LastActiveAt == nil || now.Sub(*activity.LastActiveAt) > expiration {
+				delete(store.activities, key)
+			}
+		}
+		store.prunedAt = now
+	}
+}
@@ -3,8 +3,10 @@ package authority
 import (
 	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/claims"
 	"github.com/qor/middlewares"
 	"github.com/qor/roles"
 	"github.com/qor/session"
@@ -18,6 +20,8 @@ var (
 // Authority authority struct
 type Authority struct {
 	*Config
+	rules    map[string]Rule
+	policies map[string]Policy
 }
 
 // AuthInterface auth interface
@@ -26,11 +30,37 @@ type AuthInterface interface {
 	GetCurrentUser(req *http.Request) interface{}
 }
 
+// RolesLoader could be implemented by Auth to reload roles and permissions of claims, refer Config's RolesRefreshInterval
+type RolesLoader interface {
+	LoadRoles(req *http.Request, claims *claims.Claims) error
+}
+
 // Config authority config
 type Config struct {
-	Auth                AuthInterface
-	Role                *roles.Role
+	Auth AuthInterface
+	Role *roles.Role
+	// AccessDeniedHandler handle signed in requests that not allowed, default is NewForbiddenHandler
 	AccessDeniedHandler func(w http.ResponseWriter, req *http.Request)
+	// UnauthenticatedHandler handle requests that not signed in, default is NewUnauthenticatedHandler, AccessDeniedHandler is used if only it is configured
+	UnauthenticatedHandler func(w http.ResponseWriter, req *http.Request)
+	// LoginURL login page that unauthenticated users are redirected to, default is Auth's `{Auth Prefix}/login`
+	LoginURL string
+	// ClaimsRoles check roles with session claims' Roles, Permissions and registered authority Rules only, without getting current user, so services could authorize without database
+	ClaimsRoles bool
+	// RBAC database backed roles and permissions, roles assigned in RBAC are checked by Authorize, Allow too, refer Can
+	RBAC *RBAC
+	// ActivityInterval only record activity of session, and re-issue it, if its last activity is older than the interval, default is 0, which records activity on every request, longest distraction might be longer than actual up to the interval
+	ActivityInterval time.Duration
+	// ActivitySkipPaths requests whose path has any of prefixes won't be recorded as activity, like `/assets/`
+	ActivitySkipPaths []string
+	// ActivitySkipper requests it returns true won't be recorded as activity
+	ActivitySkipper func(req *http.Request) bool
+	// ActivityStore save activity of sessions in server side, sessions from Authorization header like API clients' are tracked only when it is configured, Authority provides an in-memory implementation `MemoryActivityStore`
+	ActivityStore ActivityStore
+	// RolesRefreshInterval reload roles and permissions in session claims if they were loaded before the interval, it requires Auth to implement RolesLoader
+	RolesRefreshInterval time.Duration
+	// Clock return current time used to check rules, policies, activities and RBAC cache, default is Auth's Now if Auth implements it, or time.Now
+	Clock func() time.Time
 }
 
 // New initialize Authority
@@ -47,11 +77,51 @@ func New(config *Config) *Authority {
 		config.Role = roles.Global
 	}
 
+	if config.LoginURL == "" {
+		config.LoginURL = "/"
+		if Auth, ok := config.Auth.(interface {
+			AuthURL(pth string) string
+		}); ok {
+			config.LoginURL = Auth.AuthURL("login")
+		}
+	}
+
+	if config.UnauthenticatedHandler == nil {
+		if config.AccessDeniedHandler != nil {
+			config.UnauthenticatedHandler = config.AccessDeniedHandler
+		} else {
+			config.UnauthenticatedHandler = NewUnauthenticatedHandler(config.Auth, config.LoginURL)
+		}
+	}
+
 	if config.AccessDeniedHandler == nil {
-		config.AccessDeniedHandler = NewAccessDeniedHandler(config.Auth, "/")
+		config.AccessDeniedHandler = NewForbiddenHandler(config.Auth)
+	}
+
+	if config.Clock == nil {
+		config.Clock = time.Now
+		if Auth, ok := config.Auth.(interface {
+			Now() time.Time
+		}); ok {
+			config.Clock = Auth.Now
+		}
+	}
+
+	if config.RBAC != nil && config.RBAC.Clock == nil {
+		config.RBAC.Clock = config.Clock
+	}
+
+	if store, ok := config.ActivityStore.(*MemoryActivityStore); ok && store.Clock == nil {
+		store.Clock = config.Clock
+	}
+
+	if config.RBAC != nil && config.RBAC.DB == nil {
+		if Auth, ok := config.Auth.(*auth.Auth); ok {
+			config.RBAC.DB = Auth.Config.DB
+		}
 	}
 
-	authority := &Authority{Config: config}
+	authority := &Authority{Config: config, rules: map[string]Rule{}, policies: map[string]Policy{}}
 
 	middlewares.Use(middlewares.Middleware{
 		Name:        "authority",
@@ -67,25 +137,42 @@ func New(config *Config) *Authority {
 func (authority *Authority) Authorize(roles ...string) func(http.Handler) http.Handler {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if authority.ClaimsRoles {
+				if claims, err := authority.getClaims(req); err == nil && (len(roles) == 0 || authority.claimsHasRole(req, claims, roles...) || authority.rbacHasRole(req, roles...)) {
+					handler.ServeHTTP(w, req)
+					return
+				}
+
+				authority.deny(w, req)
+				return
+			}
+
 			var currentUser interface{}
 
 			// Get current user from request
 			currentUser = authority.Auth.GetCurrentUser(req)
 
-			if (len(roles) == 0 && currentUser != nil) || authority.Role.HasRole(req, currentUser, roles...) {
+			if (len(roles) == 0 && currentUser != nil) || authority.Role.HasRole(req, currentUser, roles...) || authority.rbacHasRole(req, roles...) {
 				handler.ServeHTTP(w, req)
 				return
 			}
 
-			authority.AccessDeniedHandler(w, req)
+			authority.deny(w, req)
 		})
 	}
 }
 
-// NewAccessDeniedHandler new access denied handler
+// NewAccessDeniedHandler new access denied handler that flashes AccessDeniedFlashMessage and redirects to redirectPath
 func NewAccessDeniedHandler(Auth AuthInterface, redirectPath string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, req *http.Request) {
-		Auth.Flash(w, req, session.Message{Message: AccessDeniedFlashMessage})
+		message := AccessDeniedFlashMessage
+		if translator, ok := Auth.(interface {
+			Translate(req *http.Request, id string, fallback string) string
+		}); ok {
+			message = template.HTML(translator.Translate(req, "authority.flash.access_denied", string(AccessDeniedFlashMessage)))
+		}
+
+		Auth.Flash(w, req, session.Message{Message: message})
 		http.Redirect(w, req, redirectPath, http.StatusSeeOther)
 	}
 }
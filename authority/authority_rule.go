@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/fahmibaswara/auth/claims"
 	"github.com/qor/roles"
 )
 
@@ -11,16 +12,18 @@ import (
 type Rule struct {
 	TimeoutSinceLastLogin            time.Duration
 	LongestDistractionSinceLastLogin time.Duration
+	// DenyImpersonation deny admins who are signed in as another user, use it for sensitive actions
+	DenyImpersonation bool
 }
 
 // Handler generate roles checker
 func (authority Authority) Handler(rule Rule) roles.Checker {
 	return func(req *http.Request, user interface{}) bool {
-		claims, _ := authority.Auth.Get(req)
+		claims, _ := authority.getClaims(req)
 
 		// Check Last Auth
 		if rule.TimeoutSinceLastLogin > 0 {
-			if claims == nil || claims.LastLoginAt == nil || time.Now().Add(-rule.TimeoutSinceLastLogin).After(*claims.LastLoginAt) {
+			if claims == nil || claims.LastLoginAt == nil || authority.Clock().Add(-rule.TimeoutSinceLastLogin).After(*claims.LastLoginAt) {
 				return false
 			}
 		}
@@ -32,17 +35,42 @@ func (authority Authority) Handler(rule Rule) roles.Checker {
 			}
 		}
 
+		// Check Impersonation
+		if rule.DenyImpersonation && claims.IsImpersonating() {
+			return false
+		}
+
 		return true
 	}
 }
 
 // Register register authority rule into Role
 func (authority *Authority) Register(name string, rule Rule) {
+	authority.rules[name] = rule
 	authority.Config.Role.Register(name, authority.Handler(rule))
 }
 
 // Allow Check allow role or not
 func (authority *Authority) Allow(role string, req *http.Request) bool {
+	if authority.ClaimsRoles {
+		claims, err := authority.getClaims(req)
+		return err == nil && (authority.claimsHasRole(req, claims, role) || authority.rbacHasRole(req, role))
+	}
+
 	currentUser := authority.Auth.GetCurrentUser(req)
-	return authority.Role.HasRole(req, currentUser, role)
+	return authority.Role.HasRole(req, currentUser, role) || authority.rbacHasRole(req, role)
+}
+
+// claimsHasRole check if claims has any of roles, a role is matched if it is included in claims' Roles or Permissions, or it is a registered Rule that passed
+func (authority *Authority) claimsHasRole(req *http.Request, claims *claims.Claims, roles ...string) bool {
+	if claims.HasRole(roles...) || claims.HasPermission(roles...) {
+		return true
+	}
+
+	for _, role := range roles {
+		if rule, ok := authority.rules[role]; ok && authority.Handler(rule)(req, nil) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,45 +1,126 @@
 package authority
 
 import (
+	"context"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/claims"
 	"github.com/qor/qor/utils"
 )
 
-// ClaimsContextKey authority claims key
+// ClaimsContextKey authority claims key, claims updated by Middleware are saved into request's context with it
 var ClaimsContextKey utils.ContextKey = "authority_claims"
 
-// Middleware authority middleware used to record activity time
+// Middleware authority middleware used to record activity time, and reload roles of session if RolesRefreshInterval configured
 func (authority *Authority) Middleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = auth.WithRequestCache(req)
+
+		if authority.skipActivity(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
 		if claims, err := authority.Auth.Get(req); err == nil {
-			var zero time.Duration
+			var (
+				now         = authority.Clock()
+				activityKey string
+				// sessions from Authorization header can't be re-issued, their activity is only saved in ActivityStore
+				fromHeader = req.Header.Get("Authorization") != ""
+			)
 
-			lastActiveAt := claims.LastActiveAt
-			if lastActiveAt != nil {
-				lastDistractionTime := time.Now().Sub(*lastActiveAt)
-				if claims.LongestDistractionSinceLastLogin == nil || *claims.LongestDistractionSinceLastLogin < lastDistractionTime {
-					claims.LongestDistractionSinceLastLogin = &lastDistractionTime
+			if authority.ActivityStore != nil {
+				activityKey = sessionActivityKey(claims)
+				if activity, ok := authority.ActivityStore.Get(activityKey); ok && activity.LastActiveAt != nil {
+					if claims.LastActiveAt == nil || activity.LastActiveAt.After(*claims.LastActiveAt) {
+						claims.LastActiveAt = activity.LastActiveAt
+						claims.LongestDistractionSinceLastLogin = activity.LongestDistractionSinceLastLogin
+					}
 				}
+			}
 
-				if claims.LastLoginAt != nil {
-					if claims.LastLoginAt.After(*claims.LastActiveAt) {
-						claims.LongestDistractionSinceLastLogin = &zero
-					} else if loggedDuration := claims.LastActiveAt.Sub(*claims.LastLoginAt); *claims.LongestDistractionSinceLastLogin > loggedDuration {
-						claims.LongestDistractionSinceLastLogin = &loggedDuration
-					}
+			var (
+				recordActivity = claims.LastActiveAt == nil || now.Sub(*claims.LastActiveAt) >= authority.ActivityInterval
+				reloadRoles    = authority.RolesRefreshInterval > 0 && (claims.RolesLoadedAt == nil || now.Sub(*claims.RolesLoadedAt) > authority.RolesRefreshInterval)
+			)
+
+			if recordActivity {
+				updateDistraction(claims, now)
+				claims.LastActiveAt = &now
+
+				if authority.ActivityStore != nil {
+					authority.ActivityStore.Set(activityKey, Activity{LastActiveAt: claims.LastActiveAt, LongestDistractionSinceLastLogin: claims.LongestDistractionSinceLastLogin})
 				}
-			} else {
-				claims.LongestDistractionSinceLastLogin = &zero
 			}
 
-			now := time.Now()
-			claims.LastActiveAt = &now
+			// Reload roles
+			if reloadRoles {
+				if loader, ok := authority.Auth.(RolesLoader); ok {
+					loader.LoadRoles(req, claims)
+				}
+			}
 
-			authority.Auth.Update(w, req, claims)
+			if (recordActivity || reloadRoles) && !fromHeader {
+				authority.Auth.Update(w, req, claims)
+			}
+
+			req = req.WithContext(context.WithValue(req.Context(), ClaimsContextKey, claims))
 		}
 
 		handler.ServeHTTP(w, req)
 	})
 }
+
+// updateDistraction update longest distraction since last login with time passed since last active
+func updateDistraction(claims *claims.Claims, now time.Time) {
+	var zero time.Duration
+
+	lastActiveAt := claims.LastActiveAt
+	if lastActiveAt != nil {
+		lastDistractionTime := now.Sub(*lastActiveAt)
+		if claims.LongestDistractionSinceLastLogin == nil || *claims.LongestDistractionSinceLastLogin < lastDistractionTime {
+			claims.LongestDistractionSinceLastLogin = &lastDistractionTime
+		}
+
+		if claims.LastLoginAt != nil {
+			if claims.LastLoginAt.After(*claims.LastActiveAt) {
+				claims.LongestDistractionSinceLastLogin = &zero
+			} else if loggedDuration := claims.LastActiveAt.Sub(*claims.LastLoginAt); *claims.LongestDistractionSinceLastLogin > loggedDuration {
+				claims.LongestDistractionSinceLastLogin = &loggedDuration
+			}
+		}
+	} else {
+		claims.LongestDistractionSinceLastLogin = &zero
+	}
+}
+
+func (authority *Authority) skipActivity(req *http.Request) bool {
+	for _, prefix := range authority.ActivitySkipPaths {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+
+	return authority.ActivitySkipper != nil && authority.ActivitySkipper(req)
+}
+
+// sessionActivityKey key of session's activity, sessions of same user signed in at different time are tracked separately
+func sessionActivityKey(claims *claims.Claims) string {
+	return auth.SessionKey(claims) + ":" + strconv.FormatInt(claims.IssuedAt, 10)
+}
+
+// getClaims get claims of request, claims saved by Middleware or auth's middleware are used if exists
+func (authority *Authority) getClaims(req *http.Request) (*claims.Claims, error) {
+	if claims, ok := req.Context().Value(ClaimsContextKey).(*claims.Claims); ok && claims != nil {
+		return claims, nil
+	}
+
+	if claims := auth.ClaimsFromContext(req.Context()); claims != nil {
+		return claims, nil
+	}
+	return authority.Auth.Get(req)
+}
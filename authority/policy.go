@@ -0,0 +1,184 @@
+package authority
+
+import (
+	"context"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+)
+
+// PolicyResourceContextKey context key of resource loaded by AuthorizePolicy, get it in wrapped handler with `req.Context().Value(authority.PolicyResourceContextKey)`
+var PolicyResourceContextKey utils.ContextKey = "authority_policy_resource"
+
+// Policy attribute based rule, decides if subject is allowed to access resource in environment
+type Policy func(attrs *Attributes) bool
+
+// ResourceLoader load resource that is going to be accessed from request
+type ResourceLoader func(req *http.Request) (interface{}, error)
+
+// Attributes attributes that policies are evaluated with, subject is current session's claims and current user, resource is what is going to be accessed, environment is time, IP and request
+type Attributes struct {
+	// Claims current session's claims, it is nil if not signed in
+	Claims   *claims.Claims
+	Resource interface{}
+	Time     time.Time
+	IP       string
+	Request  *http.Request
+
+	authority   *Authority
+	currentUser interface{}
+	userLoaded  bool
+}
+
+// CurrentUser get current user, it is loaded only when used, so policies that only check claims won't hit database
+func (attrs *Attributes) CurrentUser() interface{} {
+	if !attrs.userLoaded {
+		attrs.currentUser = attrs.authority.Auth.GetCurrentUser(attrs.Request)
+		attrs.userLoaded = true
+	}
+	return attrs.currentUser
+}
+
+// AllOf policy that passed if all policies passed
+func AllOf(policies ...Policy) Policy {
+	return func(attrs *Attributes) bool {
+		for _, policy := range policies {
+			if !policy(attrs) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf policy that passed if any of policies passed
+func AnyOf(policies ...Policy) Policy {
+	return func(attrs *Attributes) bool {
+		for _, policy := range policies {
+			if policy(attrs) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not policy that passed if policy not passed
+func Not(policy Policy) Policy {
+	return func(attrs *Attributes) bool {
+		return !policy(attrs)
+	}
+}
+
+// HasRole policy that passed if claims has any of roles
+func HasRole(roles ...string) Policy {
+	return func(attrs *Attributes) bool {
+		return attrs.Claims.HasRole(roles...)
+	}
+}
+
+// FromNetworks policy that passed if request is from any of networks, networks are in CIDR notation like `10.0.0.0/8`
+func FromNetworks(networks ...string) Policy {
+	var ipNets []*net.IPNet
+	for _, network := range networks {
+		if _, ipNet, err := net.ParseCIDR(network); err == nil {
+			ipNets = append(ipNets, ipNet)
+		}
+	}
+
+	return func(attrs *Attributes) bool {
+		if ip := net.ParseIP(attrs.IP); ip != nil {
+			for _, ipNet := range ipNets {
+				if ipNet.Contains(ip) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Between policy that passed if current time's clock is between start and end, like `Between(9*time.Hour, 18*time.Hour)`, the window crosses midnight if start is after end, like `Between(22*time.Hour, 6*time.Hour)`
+func Between(start time.Duration, end time.Duration) Policy {
+	return func(attrs *Attributes) bool {
+		var (
+			year, month, day = attrs.Time.Date()
+			clock            = attrs.Time.Sub(time.Date(year, month, day, 0, 0, 0, 0, attrs.Time.Location()))
+		)
+
+		if start > end {
+			return clock >= start || clock < end
+		}
+		return clock >= start && clock < end
+	}
+}
+
+// RulePolicy policy that passed if authority rule passed, e.g. require last login in an hour
+func (authority *Authority) RulePolicy(rule Rule) Policy {
+	checker := authority.Handler(rule)
+	return func(attrs *Attributes) bool {
+		return checker(attrs.Request, nil)
+	}
+}
+
+// RegisterPolicy register policy with name
+func (authority *Authority) RegisterPolicy(name string, policy Policy) {
+	authority.policies[name] = policy
+}
+
+// NewAttributes build attributes of request and resource
+func (authority *Authority) NewAttributes(req *http.Request, resource interface{}) *Attributes {
+	attrs := &Attributes{Resource: resource, Time: authority.Clock(), IP: req.RemoteAddr, Request: req, authority: authority}
+	attrs.Claims, _ = authority.getClaims(req)
+
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		attrs.IP = host
+	}
+	return attrs
+}
+
+// Permit check if current user is allowed to access resource by policy, unregistered policies are never passed
+func (authority *Authority) Permit(req *http.Request, name string, resource interface{}) bool {
+	policy, ok := authority.policies[name]
+	return ok && policy(authority.NewAttributes(req, resource))
+}
+
+// AuthorizePolicy authorize current user to access wrapped handler by policy, resource is loaded with loader, and saved into request's context with PolicyResourceContextKey, access is denied if failed to load the resource
+func (authority *Authority) AuthorizePolicy(name string, loader ResourceLoader) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var resource interface{}
+			var err error
+
+			if loader != nil {
+				resource, err = loader(req)
+			}
+
+			if err == nil && authority.Permit(req, name, resource) {
+				handler.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), PolicyResourceContextKey, resource)))
+				return
+			}
+
+			authority.deny(w, req)
+		})
+	}
+}
+
+// FuncMap template helpers to check permissions of request, `allow "role"`, `can "resource" "action"`, `permit "policy" resource`
+func (authority *Authority) FuncMap(req *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"allow": func(role string) bool {
+			return authority.Allow(role, req)
+		},
+		"can": func(resource string, action string) bool {
+			return authority.Can(req, resource, action)
+		},
+		"permit": func(name string, resource interface{}) bool {
+			return authority.Permit(req, name, resource)
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package authority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth/authtest"
+	"github.com/fahmibaswara/auth/claims"
+)
+
+func TestBetween(t *testing.T) {
+	cases := []struct {
+		name   string
+		start  time.Duration
+		end    time.Duration
+		hour   int
+		passed bool
+	}{
+		{name: "in office hours", start: 9 * time.Hour, end: 18 * time.Hour, hour: 10, passed: true},
+		{name: "at start of office hours", start: 9 * time.Hour, end: 18 * time.Hour, hour: 9, passed: true},
+		{name: "at end of office hours", start: 9 * time.Hour, end: 18 * time.Hour, hour: 18},
+		{name: "before office hours", start: 9 * time.Hour, end: 18 * time.Hour, hour: 8},
+		{name: "night shift before midnight", start: 22 * time.Hour, end: 6 * time.Hour, hour: 23, passed: true},
+		{name: "night shift after midnight", start: 22 * time.Hour, end: 6 * time.Hour, hour: 2, passed: true},
+		{name: "after night shift", start: 22 * time.Hour, end: 6 * time.Hour, hour: 6},
+		{name: "in day of night shift", start: 22 * time.Hour, end: 6 * time.Hour, hour: 12},
+	}
+
+	for _, c := range cases {
+		attrs := &Attributes{Time: time.Date(2020, 1, 1, c.hour, 0, 0, 0, time.UTC)}
+		if passed := Between(c.start, c.end)(attrs); passed != c.passed {
+			t.Errorf("%v: should be %v, got %v", c.name, c.passed, passed)
+		}
+	}
+}
+
+func TestPolicyClock(t *testing.T) {
+	Auth := authtest.New(nil)
+	Auth.Clock.Set(time.Date(2020, 1, 1, 23, 0, 0, 0, time.Local))
+
+	Authority := New(&Config{Auth: Auth})
+	Authority.RegisterPolicy("night_shift", Between(22*time.Hour, 6*time.Hour))
+	Authority.RegisterPolicy("recent_login", Authority.RulePolicy(Rule{TimeoutSinceLastLogin: time.Hour}))
+
+	now := Auth.Now()
+	req, _ := Auth.NewRequest("GET", "/", &claims.Claims{UserID: "1", LastLoginAt: &now})
+
+	if !Authority.Permit(req, "night_shift", nil) || !Authority.Permit(req, "recent_login", nil) {
+		t.Errorf("policies should be passed at current time of Auth's Clock")
+	}
+
+	Auth.Clock.Add(12 * time.Hour)
+	if Authority.Permit(req, "night_shift", nil) || Authority.Permit(req, "recent_login", nil) {
+		t.Errorf("policies should be checked with Auth's Clock")
+	}
+}
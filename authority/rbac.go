@@ -0,0 +1,286 @@
+package authority
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RBAC database backed role based access control, roles, permissions, role inheritance and assignments are saved with RBACRole, RBACPermission, RBACRoleParent, RBACAssignment
+type RBAC struct {
+	// DB database used to save roles and permissions, default is Auth's DB
+	DB *gorm.DB
+	// CacheTTL how long resolved roles and permissions of users are cached, default is 1 minute, changes made with RBAC's methods invalidate the cache immediately
+	CacheTTL time.Duration
+	// Clock current time used to expire cache, default is time.Now, Authority will set it to its Clock if blank
+	Clock func() time.Time
+
+	mutex sync.RWMutex
+	cache map[string]*rbacCacheEntry
+}
+
+type rbacCacheEntry struct {
+	roles       map[string]bool
+	permissions map[string]bool
+	expiredAt   time.Time
+}
+
+// AutoMigrate migrate RBAC tables
+func (rbac *RBAC) AutoMigrate() error {
+	return rbac.DB.AutoMigrate(&RBACRole{}, &RBACRoleParent{}, &RBACPermission{}, &RBACAssignment{}).Error
+}
+
+// AddRole save role with its parent roles, it inherits all permissions of parents
+func (rbac *RBAC) AddRole(name string, parents ...string) error {
+	err := rbac.transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(RBACRole{Name: name}).FirstOrCreate(&RBACRole{}).Error; err != nil {
+			return err
+		}
+
+		for _, parent := range parents {
+			if err := tx.Where(RBACRoleParent{Role: name, Parent: parent}).FirstOrCreate(&RBACRoleParent{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	rbac.Invalidate("")
+	return err
+}
+
+// RemoveRole remove role, its inheritance, permissions and assignments
+func (rbac *RBAC) RemoveRole(name string) error {
+	err := rbac.transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("name = ?", name).Delete(&RBACRole{}).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&RBACPermission{}, &RBACAssignment{}} {
+			if err := tx.Unscoped().Where("role = ?", name).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("role = ? OR parent = ?", name, name).Delete(&RBACRoleParent{}).Error
+	})
+
+	rbac.Invalidate("")
+	return err
+}
+
+// Grant allow role to do action on resource
+func (rbac *RBAC) Grant(role string, resource string, action string) error {
+	err := rbac.DB.Where(RBACPermission{Role: role, Resource: resource, Action: action}).FirstOrCreate(&RBACPermission{}).Error
+	rbac.Invalidate("")
+	return err
+}
+
+// Revoke disallow role to do action on resource
+func (rbac *RBAC) Revoke(role string, resource string, action string) error {
+	err := rbac.DB.Unscoped().Where(map[string]interface{}{"role": role, "resource": resource, "action": action}).Delete(&RBACPermission{}).Error
+	rbac.Invalidate("")
+	return err
+}
+
+// Assign assign role to user
+func (rbac *RBAC) Assign(userID string, role string) error {
+	err := rbac.DB.Where(RBACAssignment{UserID: userID, Role: role}).FirstOrCreate(&RBACAssignment{}).Error
+	rbac.Invalidate(userID)
+	return err
+}
+
+// Unassign remove role from user
+func (rbac *RBAC) Unassign(userID string, role string) error {
+	err := rbac.DB.Unscoped().Where(map[string]interface{}{"user_id": userID, "role": role}).Delete(&RBACAssignment{}).Error
+	rbac.Invalidate(userID)
+	return err
+}
+
+// Invalidate clear cached roles and permissions of user, clear all if userID is blank, call it if assignments are changed without RBAC's methods
+func (rbac *RBAC) Invalidate(userID string) {
+	rbac.mutex.Lock()
+	defer rbac.mutex.Unlock()
+
+	if userID == "" {
+		rbac.cache = nil
+		return
+	}
+
+	for key := range rbac.cache {
+		if key == userID || strings.HasPrefix(key, userID+",") {
+			delete(rbac.cache, key)
+		}
+	}
+}
+
+// Roles return roles of user, including assigned roles, extra roles like roles in claims, and roles inherited by them
+func (rbac *RBAC) Roles(userID string, extraRoles ...string) []string {
+	var results []string
+	for role := range rbac.load(userID, extraRoles).roles {
+		results = append(results, role)
+	}
+	return results
+}
+
+// Permissions return permissions of user as `resource:action`, they could be loaded into claims with auth.PermissionsGetter
+func (rbac *RBAC) Permissions(userID string, extraRoles ...string) []string {
+	var results []string
+	for permission := range rbac.load(userID, extraRoles).permissions {
+		results = append(results, permission)
+	}
+	return results
+}
+
+// HasRole check if user has any of roles
+func (rbac *RBAC) HasRole(userID string, roles ...string) bool {
+	return rbac.hasRole(userID, nil, roles...)
+}
+
+// hasRole check if user, or extra roles, has any of roles, including roles inherited by extra roles
+func (rbac *RBAC) hasRole(userID string, extraRoles []string, roles ...string) bool {
+	entry := rbac.load(userID, extraRoles)
+	for _, role := range roles {
+		if entry.roles[role] {
+			return true
+		}
+	}
+	return false
+}
+
+// Can check if user, or extra roles, is allowed to do action on resource
+func (rbac *RBAC) Can(userID string, resource string, action string, extraRoles ...string) bool {
+	permissions := rbac.load(userID, extraRoles).permissions
+	return permissions[resource+":"+action] || permissions[resource+":*"] || permissions["*:"+action] || permissions["*:*"]
+}
+
+func (rbac *RBAC) load(userID string, extraRoles []string) *rbacCacheEntry {
+	var cacheKey = userID
+	for _, role := range extraRoles {
+		cacheKey += "," + role
+	}
+
+	rbac.mutex.RLock()
+	entry, ok := rbac.cache[cacheKey]
+	rbac.mutex.RUnlock()
+
+	now := time.Now()
+	if rbac.Clock != nil {
+		now = rbac.Clock()
+	}
+
+	if ok && now.Before(entry.expiredAt) {
+		return entry
+	}
+
+	var (
+		ttl         = rbac.CacheTTL
+		assignments []RBACAssignment
+		pending     = append([]string{}, extraRoles...)
+	)
+
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	entry = &rbacCacheEntry{roles: map[string]bool{}, permissions: map[string]bool{}, expiredAt: now.Add(ttl)}
+
+	if userID != "" {
+		rbac.DB.Where("user_id = ?", userID).Find(&assignments)
+		for _, assignment := range assignments {
+			pending = append(pending, assignment.Role)
+		}
+	}
+
+	// resolve inherited roles
+	for len(pending) > 0 {
+		var parents []RBACRoleParent
+		var current []string
+		for _, role := range pending {
+			if !entry.roles[role] {
+				entry.roles[role] = true
+				current = append(current, role)
+			}
+		}
+
+		pending = nil
+		if len(current) > 0 {
+			rbac.DB.Where("role IN (?)", current).Find(&parents)
+		}
+
+		for _, parent := range parents {
+			pending = append(pending, parent.Parent)
+		}
+	}
+
+	if len(entry.roles) > 0 {
+		var permissions []RBACPermission
+		var roles []string
+		for role := range entry.roles {
+			roles = append(roles, role)
+		}
+
+		rbac.DB.Where("role IN (?)", roles).Find(&permissions)
+		for _, permission := range permissions {
+			entry.permissions[permission.Resource+":"+permission.Action] = true
+		}
+	}
+
+	rbac.mutex.Lock()
+	if rbac.cache == nil {
+		rbac.cache = map[string]*rbacCacheEntry{}
+	}
+	rbac.cache[cacheKey] = entry
+	rbac.mutex.Unlock()
+
+	return entry
+}
+
+func (rbac *RBAC) transaction(fc func(tx *gorm.DB) error) error {
+	tx := rbac.DB.Begin()
+	if err := fc(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Can check if current user is allowed to do action on resource, it is allowed if claims has permission `resource:action`, or current user's roles in RBAC, and roles in claims, are granted, auth identities without linked user have no roles assigned in RBAC
+func (authority *Authority) Can(req *http.Request, resource string, action string) bool {
+	claims, err := authority.getClaims(req)
+	if err != nil {
+		return false
+	}
+
+	if claims.HasPermission(resource+":"+action, resource+":*") {
+		return true
+	}
+
+	return authority.RBAC != nil && authority.RBAC.Can(claims.UserID, resource, action, claims.Roles...)
+}
+
+// AuthorizeAction authorize current user who is allowed to do action on resource to access wrapped handler, refer Can
+func (authority *Authority) AuthorizeAction(resource string, action string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if authority.Can(req, resource, action) {
+				handler.ServeHTTP(w, req)
+				return
+			}
+
+			authority.deny(w, req)
+		})
+	}
+}
+
+// rbacHasRole check if current user has any of roles in RBAC, roles inherited by roles in claims are included, like Can
+func (authority *Authority) rbacHasRole(req *http.Request, roles ...string) bool {
+	if authority.RBAC == nil {
+		return false
+	}
+
+	claims, err := authority.getClaims(req)
+	return err == nil && authority.RBAC.hasRole(claims.UserID, claims.Roles, roles...)
+}
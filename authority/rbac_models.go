@@ -0,0 +1,34 @@
+package authority
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// RBACRole role saved in database
+type RBACRole struct {
+	gorm.Model
+	Name        string `gorm:"unique_index"`
+	Description string
+}
+
+// RBACRoleParent role inherits all permissions of its parent roles
+type RBACRoleParent struct {
+	gorm.Model
+	Role   string `gorm:"index"`
+	Parent string
+}
+
+// RBACPermission permission of role to do action on resource, `*` matches any resource or action
+type RBACPermission struct {
+	gorm.Model
+	Role     string `gorm:"index"`
+	Resource string
+	Action   string
+}
+
+// RBACAssignment role assigned to user
+type RBACAssignment struct {
+	gorm.Model
+	UserID string `gorm:"index"`
+	Role   string
+}
@@ -0,0 +1,46 @@
+package authority
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fahmibaswara/auth/authtest"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestClaimsRolesInheritance(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database, got %v", err)
+	}
+	defer db.Close()
+
+	rbac := &RBAC{DB: db}
+	rbac.AutoMigrate()
+	rbac.AddRole("viewer")
+	rbac.AddRole("editor", "viewer")
+	rbac.Grant("viewer", "posts", "read")
+
+	for _, claimsRoles := range []bool{true, false} {
+		Auth := authtest.New(nil)
+		Authority := New(&Config{Auth: Auth, RBAC: rbac, ClaimsRoles: claimsRoles})
+		req, _ := Auth.NewRequest("GET", "/", &claims.Claims{UserID: "1", Roles: []string{"editor"}})
+
+		if !Authority.Can(req, "posts", "read") {
+			t.Errorf("role inherited by claims' roles should be allowed by Can")
+		}
+
+		if !Authority.Allow("viewer", req) || Authority.Allow("admin", req) {
+			t.Errorf("role inherited by claims' roles should be allowed by Allow, ClaimsRoles: %v", claimsRoles)
+		}
+
+		w := httptest.NewRecorder()
+		Authority.Authorize("viewer")(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("role inherited by claims' roles should be authorized, ClaimsRoles: %v, got %v", claimsRoles, w.Code)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package authtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/fahmibaswara/auth/providers/password"
+	"github.com/qor/mailer"
+	"github.com/qor/redirect_back"
+)
+
+// Auth Auth for tests, auth identities, tokens, users and sessions are kept in memory, sent mails and SMS are captured, time is controlled by Clock
+type Auth struct {
+	*auth.Auth
+	// Mailer captures mails sent by Auth
+	Mailer *Mailer
+	// SMS captures SMS sent by Auth, it is nil if config's SMSSender is not a *SMSSender
+	SMS *SMSSender
+	// Clock current time of Auth, it is nil if config's Clock is set
+	Clock *Clock
+	// Sessions keeps session data in memory
+	Sessions *SessionManager
+}
+
+// New initialize Auth for tests, blank fields of config are filled with in-memory implementations and fakes, then it is initialized with auth.New
+func New(config *auth.Config) *Auth {
+	if config == nil {
+		config = &auth.Config{}
+	}
+
+	var (
+		fakeMailer = &Mailer{}
+		sms, _     = config.SMSSender.(*SMSSender)
+		clock      *Clock
+		sessions   = &SessionManager{}
+		prefix     = "/auth/"
+	)
+
+	if config.URLPrefix != "" {
+		prefix = "/" + strings.Trim(config.URLPrefix, "/") + "/"
+	}
+
+	if config.Mailer == nil {
+		config.Mailer = mailer.New(&mailer.Config{Sender: fakeMailer})
+	} else if sender, ok := config.Mailer.Config.Sender.(*Mailer); ok {
+		fakeMailer = sender
+	}
+
+	if config.SMSSender == nil {
+		sms = &SMSSender{}
+		config.SMSSender = sms
+	}
+
+	if config.Clock == nil {
+		clock = NewClock(time.Now())
+		config.Clock = clock.Now
+	}
+
+	if config.IdentityStore == nil {
+		config.IdentityStore = &auth.MemoryIdentityStore{}
+	}
+
+	if config.TokenStore == nil {
+		config.TokenStore = &auth.MemoryTokenStore{}
+	}
+
+	if config.UserStorer == nil {
+		config.UserStorer = &UserStorer{}
+	}
+
+	if config.SessionRevoker == nil {
+		config.SessionRevoker = &auth.MemorySessionRevoker{}
+	}
+
+	if config.SessionStorer == nil {
+		config.SessionStorer = &auth.SessionStorer{
+			SessionName:    "_auth_session",
+			SessionManager: sessions,
+			SigningMethod:  jwt.SigningMethodHS256,
+			SignedString:   "authtest",
+			SessionRevoker: config.SessionRevoker,
+		}
+	} else if storer, ok := config.SessionStorer.(*auth.SessionStorer); ok {
+		if manager, ok := storer.SessionManager.(*SessionManager); ok {
+			sessions = manager
+		}
+	}
+
+	if config.Redirector == nil {
+		config.Redirector = &auth.Redirector{RedirectBack: redirect_back.New(&redirect_back.Config{
+			SessionManager:  sessions,
+			IgnoredPrefixes: []string{prefix},
+		})}
+	}
+
+	return &Auth{Auth: auth.New(config), Mailer: fakeMailer, SMS: sms, Clock: clock, Sessions: sessions}
+}
+
+// CreateIdentity create a confirmed auth identity of provider and uid, and its user with UserStorer, password is encrypted with password provider's Encryptor if not blank, returns claims of the auth identity
+func (a *Auth) CreateIdentity(provider string, uid string, pass string) (*claims.Claims, error) {
+	return a.CreateTenantIdentity("", provider, uid, pass)
+}
+
+// CreateTenantIdentity create auth identity in tenant like CreateIdentity, tenant is resolved from a request to `example.com` if it is blank
+func (a *Auth) CreateTenantIdentity(tenant string, provider string, uid string, pass string) (*claims.Claims, error) {
+	var (
+		req      = tenantRequest(tenant)
+		now      = a.Now()
+		authInfo = &auth_identity.Basic{Provider: provider, UID: uid, ConfirmedAt: &now}
+		context  = &auth.Context{Auth: a.Auth, Request: req}
+		err      error
+	)
+
+	if pass != "" {
+		passwordProvider, ok := a.GetProvider("password").(*password.Provider)
+		if !ok {
+			return nil, errors.New("password provider is not registered")
+		}
+
+		if authInfo.EncryptedPassword, err = passwordProvider.Encryptor.Digest(pass); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, authInfo.UserID, err = a.UserStorer.Save(&auth.Schema{Provider: provider, UID: uid, Email: uid}, context); err != nil {
+		return nil, err
+	}
+
+	if err = a.IdentityStore.Create(req, authInfo); err != nil {
+		return nil, err
+	}
+	return authInfo.ToClaims(), nil
+}
+
+// tenantRequest new request of tenant, its tenant is resolved by Auth if tenant is blank
+func tenantRequest(tenant string) *http.Request {
+	req := httptest.NewRequest("GET", "/", nil)
+	if tenant != "" {
+		req = req.WithContext(auth.WithTenant(req.Context(), tenant))
+	}
+	return req
+}
+
+// SessionCookie sign claims in like Auth.Login, the session is issued for claims' tenant, returns the session cookie, which could be added to requests or a client's cookie jar
+func (a *Auth) SessionCookie(claimer claims.ClaimerInterface) (*http.Cookie, error) {
+	var (
+		w   = httptest.NewRecorder()
+		req = tenantRequest(claimer.ToClaims().TenantID)
+	)
+
+	if err := a.Login(w, req, claimer); err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == a.Sessions.cookieName() {
+			return cookie, nil
+		}
+	}
+	return nil, errors.New("session cookie not found")
+}
+
+// Token sign claims in like Auth.Login, returns the session token, which could be used as request's `Authorization` header
+func (a *Auth) Token(claimer claims.ClaimerInterface) (string, error) {
+	cookie, err := a.SessionCookie(claimer)
+	if err != nil {
+		return "", err
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	if storer, ok := a.SessionStorer.(*auth.SessionStorer); ok {
+		return storer.GetToken(req), nil
+	}
+	return "", errors.New("token is only available with auth.SessionStorer")
+}
+
+// NewRequest new request like httptest.NewRequest, which is signed in as claims
+func (a *Auth) NewRequest(method string, target string, claimer claims.ClaimerInterface) (*http.Request, error) {
+	cookie, err := a.SessionCookie(claimer)
+	if err != nil {
+		return nil, err
+	}
+
+	req := httptest.NewRequest(method, target, nil)
+	req.AddCookie(cookie)
+	return req, nil
+}
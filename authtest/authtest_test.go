@@ -0,0 +1,336 @@
+package authtest_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/authtest"
+	"github.com/fahmibaswara/auth/providers/github"
+	"github.com/fahmibaswara/auth/providers/password"
+	"github.com/fahmibaswara/auth/providers/password/encryptor/pbkdf2_encryptor"
+	"github.com/fahmibaswara/auth/providers/phone"
+)
+
+type User struct {
+	ID    uint
+	Email string
+}
+
+// newServer start test server, which responds current user's email, or `anonymous`
+func newServer(t *testing.T, Auth *authtest.Auth) *authtest.Client {
+	server := Auth.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, ok := auth.UserFromContext(req.Context()).(*User); ok {
+			fmt.Fprint(w, user.Email)
+			return
+		}
+		fmt.Fprint(w, "anonymous")
+	}))
+	t.Cleanup(server.Close)
+	return Auth.NewClient(server)
+}
+
+func currentUser(client *authtest.Client) string {
+	resp, err := client.Get("/")
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestPasswordLogin(t *testing.T) {
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(password.New(nil))
+	client := newServer(t, Auth)
+
+	client.PasswordRegister("jinzhu@example.com", "correct horse battery staple")
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in after registered, got %v", user)
+	}
+
+	client = Auth.NewClient(client.Server)
+	client.PasswordLogin("jinzhu@example.com", "wrong password")
+	if user := currentUser(client); user != "anonymous" {
+		t.Errorf("should not sign in with wrong password, got %v", user)
+	}
+
+	client.PasswordLogin("jinzhu@example.com", "correct horse battery staple")
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with password, got %v", user)
+	}
+}
+
+func TestPasswordRehash(t *testing.T) {
+	var (
+		Auth   = authtest.New(&auth.Config{UserModel: &User{}})
+		config = &pbkdf2_encryptor.Config{Iterations: 1000}
+	)
+	Auth.RegisterProvider(password.New(&password.Config{Encryptor: pbkdf2_encryptor.New(config)}))
+	client := newServer(t, Auth)
+
+	claims, err := Auth.CreateIdentity("password", "jinzhu@example.com", "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("failed to create identity, got %v", err)
+	}
+
+	// encrypted password is upgraded to current parameters after signed in
+	config.Iterations = 2000
+	client.PasswordLogin("jinzhu@example.com", "correct horse battery staple")
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with password encrypted with old parameters, got %v", user)
+	}
+
+	authInfo, _ := Auth.IdentityStore.Find(nil, "password", claims.Id)
+	if !strings.HasPrefix(authInfo.EncryptedPassword, "$pbkdf2-sha256$i=2000$") {
+		t.Errorf("encrypted password should be upgraded, got %v", authInfo.EncryptedPassword)
+	}
+
+	client = Auth.NewClient(client.Server)
+	client.PasswordLogin("jinzhu@example.com", "correct horse battery staple")
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with upgraded password, got %v", user)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(password.New(nil))
+	client := newServer(t, Auth)
+
+	if _, err := Auth.CreateIdentity("password", "jinzhu@example.com", "correct horse battery staple"); err != nil {
+		t.Fatalf("failed to create identity, got %v", err)
+	}
+
+	resetPasswordToken := func() string {
+		client.PostForm(Auth.AuthURL("password/recover"), url.Values{"email": {"jinzhu@example.com"}})
+		link, _ := url.Parse(Auth.Mailer.LastLink("jinzhu@example.com"))
+		return link.Query().Get("token")
+	}
+
+	login := func(pass string) string {
+		client := Auth.NewClient(client.Server)
+		client.PasswordLogin("jinzhu@example.com", pass)
+		return currentUser(client)
+	}
+
+	// reset password link could be used only once
+	token := resetPasswordToken()
+	client.PostForm(Auth.AuthURL("password/update"), url.Values{"reset_password_token": {token}, "new_password": {"new password 1"}})
+	if user := login("new password 1"); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with new password, got %v", user)
+	}
+
+	client.PostForm(Auth.AuthURL("password/update"), url.Values{"reset_password_token": {token}, "new_password": {"new password 2"}})
+	if user := login("new password 2"); user != "anonymous" {
+		t.Errorf("reset password link should be used only once, got %v", user)
+	}
+
+	// reset password link is expired
+	token = resetPasswordToken()
+	Auth.Clock.Add(24 * time.Hour)
+	client.PostForm(Auth.AuthURL("password/update"), url.Values{"reset_password_token": {token}, "new_password": {"new password 3"}})
+	if user := login("new password 3"); user != "anonymous" {
+		t.Errorf("expired reset password link should be rejected, got %v", user)
+	}
+
+	// reset password link is not a session token
+	req, _ := http.NewRequest("GET", client.Server.URL, nil)
+	req.Header.Set("Authorization", token)
+	if claims, err := Auth.SessionStorer.Get(req); err == nil {
+		t.Errorf("reset password token should not be accepted as session, got %v", claims)
+	}
+}
+
+func TestPhoneLogin(t *testing.T) {
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(phone.New(nil))
+	client := newServer(t, Auth)
+
+	if _, err := Auth.CreateIdentity("phone", "+6281234567890", ""); err != nil {
+		t.Fatalf("failed to create identity, got %v", err)
+	}
+
+	client.PhoneLogin("+6281234567890")
+	if user := currentUser(client); user != "+6281234567890" {
+		t.Errorf("should sign in with phone, got %v", user)
+	}
+
+	// code is expired
+	client = Auth.NewClient(client.Server)
+	client.PostForm(Auth.AuthURL("phone/login"), url.Values{"phone_number": {"+6281234567890"}})
+	Auth.Clock.Add(4 * time.Hour)
+	client.PostForm(Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+6281234567890"}, "token": {Auth.SMS.LastCode("+6281234567890")}})
+	if user := currentUser(client); user != "anonymous" {
+		t.Errorf("should not sign in with expired code, got %v", user)
+	}
+}
+
+func TestOAuthLogin(t *testing.T) {
+	oauth := authtest.NewOAuthServer(map[string]interface{}{"id": 42, "login": "jinzhu", "email": "jinzhu@example.com"})
+	defer oauth.Close()
+
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(github.New(&github.Config{ClientID: "id", ClientSecret: "secret", AuthorizeURL: oauth.AuthorizeURL(), TokenURL: oauth.TokenURL(), APIURL: oauth.URL + "/"}))
+	client := newServer(t, Auth)
+
+	if _, err := client.OAuthLogin("github"); err != nil {
+		t.Fatalf("failed to sign in with OAuth, got %v", err)
+	}
+
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with OAuth, got %v", user)
+	}
+}
+
+func TestOAuthLink(t *testing.T) {
+	oauth := authtest.NewOAuthServer(map[string]interface{}{"id": 42, "login": "attacker"})
+	defer oauth.Close()
+
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(password.New(nil))
+	Auth.RegisterProvider(github.New(&github.Config{ClientID: "id", ClientSecret: "secret", AuthorizeURL: oauth.AuthorizeURL(), TokenURL: oauth.TokenURL(), APIURL: oauth.URL + "/"}))
+	attacker, victim := newServer(t, Auth), newServer(t, Auth)
+	victim.Server = attacker.Server
+
+	attackerClaims, _ := Auth.CreateIdentity("password", "attacker@example.com", "password of attacker")
+	victimClaims, _ := Auth.CreateIdentity("password", "victim@example.com", "password of victim")
+	attacker.SignIn(attackerClaims)
+	victim.SignIn(victimClaims)
+
+	// callbackURL start linking as client, returns the provider's callback URL without requesting it
+	callbackURL := func(client *authtest.Client) string {
+		resp, err := client.PostForm(Auth.AuthURL("github/link"), url.Values{})
+		if err != nil || !strings.HasPrefix(resp.Header.Get("Location"), oauth.URL) {
+			t.Fatalf("should redirect to OAuth server, got %v, %v", resp, err)
+		}
+
+		if resp, err = client.HTTPClient.Get(resp.Header.Get("Location")); err != nil {
+			t.Fatalf("failed to authorize, got %v", err)
+		}
+		return resp.Header.Get("Location")
+	}
+
+	linkedUserID := func() string {
+		if identity, err := Auth.IdentityStore.Find(httptest.NewRequest("GET", "/", nil), "github", "42"); err == nil {
+			return identity.UserID
+		}
+		return ""
+	}
+
+	if resp, _ := attacker.Get(Auth.AuthURL("github/link")); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("link should only be started with POST, got %v", resp.StatusCode)
+	}
+
+	if _, err := victim.HTTPClient.Get(callbackURL(attacker)); err != nil || linkedUserID() != "" {
+		t.Fatalf("state of another session should be rejected, got %v, linked to %v", err, linkedUserID())
+	}
+
+	expiredURL := callbackURL(attacker)
+	Auth.Clock.Add(auth.LinkStateTTL + time.Second)
+	if _, err := attacker.HTTPClient.Get(expiredURL); err != nil || linkedUserID() != "" {
+		t.Fatalf("expired state should be rejected, got %v, linked to %v", err, linkedUserID())
+	}
+
+	if _, err := attacker.HTTPClient.Get(callbackURL(attacker)); err != nil || linkedUserID() != attackerClaims.UserID {
+		t.Errorf("github account should be linked to current user, got %v, linked to %v", err, linkedUserID())
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	Auth := authtest.New(&auth.Config{UserModel: &User{}})
+	Auth.RegisterProvider(password.New(nil))
+	client := newServer(t, Auth)
+
+	claims, _ := Auth.CreateIdentity("password", "jinzhu@example.com", "correct horse battery staple")
+	if err := client.SignIn(claims); err != nil {
+		t.Fatalf("failed to sign in, got %v", err)
+	}
+
+	if user := currentUser(client); user != "jinzhu@example.com" {
+		t.Errorf("should sign in with session cookie, got %v", user)
+	}
+
+	req, _ := Auth.NewRequest("GET", "/", claims)
+	if user, ok := Auth.GetCurrentUser(req).(*User); !ok || user.Email != "jinzhu@example.com" {
+		t.Errorf("request should be signed in, got %v", user)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	Auth := authtest.New(&auth.Config{UserModel: &User{}, TenantResolver: &auth.HeaderTenantResolver{Tenants: []string{"a", "b"}}})
+	Auth.RegisterProvider(password.New(nil))
+	client := newServer(t, Auth)
+
+	claimsA, err := Auth.CreateTenantIdentity("a", "password", "jinzhu@example.com", "password of a")
+	if err != nil {
+		t.Fatalf("failed to create identity, got %v", err)
+	}
+
+	if _, err = Auth.CreateTenantIdentity("b", "password", "jinzhu@example.com", "password of b"); err != nil {
+		t.Fatalf("same uid should be created in another tenant, got %v", err)
+	}
+
+	request := func(tenant string, method string, path string, values url.Values) *http.Response {
+		req, _ := http.NewRequest(method, client.Server.URL+path, strings.NewReader(values.Encode()))
+		req.Header.Set("X-Tenant-ID", tenant)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.HTTPClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to request %v, got %v", path, err)
+		}
+		return resp
+	}
+
+	// password of tenant a is rejected in tenant b
+	if resp := request("b", "POST", Auth.AuthURL("password/login"), url.Values{"login": {"jinzhu@example.com"}, "password": {"password of a"}}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("password of tenant a should be rejected in tenant b, got %v", resp.StatusCode)
+	}
+
+	// session of tenant a is rejected in tenant b
+	cookie, _ := Auth.SessionCookie(claimsA)
+	for tenant, status := range map[string]int{"a": http.StatusOK, "b": http.StatusUnauthorized} {
+		req := tenantRequest(t, client.Server.URL+"/", tenant)
+		req.AddCookie(cookie)
+		if _, err := Auth.SessionStorer.Get(req); (err == nil) != (status == http.StatusOK) {
+			t.Errorf("session of tenant a in tenant %v, got %v", tenant, err)
+		}
+	}
+
+	// action token of tenant a is rejected in tenant b
+	token, err := Auth.NewActionToken(tenantRequest(t, client.Server.URL, "a"), auth.ActionResetPassword, claimsA)
+	if err != nil {
+		t.Fatalf("failed to create action token, got %v", err)
+	}
+
+	if _, _, err := Auth.ValidateActionToken(tenantRequest(t, client.Server.URL, "b"), auth.ActionResetPassword, token); err == nil {
+		t.Errorf("action token of tenant a should be rejected in tenant b")
+	}
+
+	if _, _, err := Auth.ValidateActionToken(tenantRequest(t, client.Server.URL, "a"), auth.ActionResetPassword, token); err != nil {
+		t.Errorf("action token of tenant a should be valid in tenant a, got %v", err)
+	}
+
+	// requests without tenant are rejected
+	if resp := request("", "GET", Auth.AuthURL("login"), nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("request without tenant should be rejected, got %v", resp.StatusCode)
+	}
+}
+
+func tenantRequest(t *testing.T, target string, tenant string) *http.Request {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		t.Fatalf("failed to create request, got %v", err)
+	}
+	req.Header.Set("X-Tenant-ID", tenant)
+	return req
+}
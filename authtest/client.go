@@ -0,0 +1,165 @@
+package authtest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/fahmibaswara/auth/claims"
+)
+
+// NewServer start a test server, Auth is mounted under its URLPrefix, other requests are served by handler with Auth's middleware if handler is not nil, tenants of requests are resolved with Auth's TenantMiddleware
+func (a *Auth) NewServer(handler http.Handler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(a.URLPrefix, a.NewServeMux())
+	if handler != nil {
+		mux.Handle("/", a.Middleware(handler))
+	}
+	return httptest.NewServer(a.Sessions.Middleware(a.TenantMiddleware(mux)))
+}
+
+// Client client of test server, cookies are kept between requests, redirects are not followed, so responses of Auth's handlers could be checked
+type Client struct {
+	Auth       *Auth
+	Server     *httptest.Server
+	HTTPClient *http.Client
+}
+
+// NewClient new client of test server
+func (a *Auth) NewClient(server *httptest.Server) *Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		Auth:   a,
+		Server: server,
+		HTTPClient: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Get request path of server
+func (client *Client) Get(path string) (*http.Response, error) {
+	return client.HTTPClient.Get(client.Server.URL + path)
+}
+
+// PostForm post form values to path of server
+func (client *Client) PostForm(path string, values url.Values) (*http.Response, error) {
+	return client.HTTPClient.PostForm(client.Server.URL+path, values)
+}
+
+// SignIn sign client in as claims, refer Auth.SessionCookie
+func (client *Client) SignIn(claimer claims.ClaimerInterface) error {
+	cookie, err := client.Auth.SessionCookie(claimer)
+	if err != nil {
+		return err
+	}
+
+	serverURL, _ := url.Parse(client.Server.URL)
+	client.HTTPClient.Jar.SetCookies(serverURL, []*http.Cookie{cookie})
+	return nil
+}
+
+// PasswordRegister register with password provider
+func (client *Client) PasswordRegister(login string, password string) (*http.Response, error) {
+	return client.PostForm(client.Auth.AuthURL("password/register"), url.Values{"login": {login}, "password": {password}})
+}
+
+// PasswordLogin sign in with password provider
+func (client *Client) PasswordLogin(login string, password string) (*http.Response, error) {
+	return client.PostForm(client.Auth.AuthURL("password/login"), url.Values{"login": {login}, "password": {password}})
+}
+
+// PhoneLogin sign in with phone provider, request a code for phone number, then confirm it with the code sent by SMS, response of requesting code is returned if it failed
+func (client *Client) PhoneLogin(phoneNumber string) (*http.Response, error) {
+	if client.Auth.SMS == nil {
+		return nil, errors.New("SMS are not captured")
+	}
+
+	resp, err := client.PostForm(client.Auth.AuthURL("phone/login"), url.Values{"phone_number": {phoneNumber}})
+	if err != nil || !isRedirect(resp) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	return client.PostForm(client.Auth.AuthURL("phone/confirmation/check"), url.Values{
+		"phone_number": {phoneNumber},
+		"token":        {client.Auth.SMS.LastCode(phoneNumber)},
+	})
+}
+
+// OAuthLogin sign in with OAuth provider, which should be configured to use an OAuthServer, redirects are followed until the provider's callback is requested, returns response of the callback
+func (client *Client) OAuthLogin(provider string) (*http.Response, error) {
+	resp, err := client.Get(client.Auth.AuthURL(provider + "/login"))
+
+	for err == nil && isRedirect(resp) {
+		var location *url.URL
+		if location, err = resp.Location(); err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+
+		isCallback := strings.HasPrefix(location.String(), client.Server.URL)
+		if resp, err = client.HTTPClient.Get(location.String()); isCallback {
+			break
+		}
+	}
+	return resp, err
+}
+
+func isRedirect(resp *http.Response) bool {
+	return resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != ""
+}
+
+// OAuthServer fake OAuth 2 provider, requests are authorized without prompt, User is responded as user info
+type OAuthServer struct {
+	*httptest.Server
+	// User user info responded for requests other than `/authorize`, `/token`, like `{"id": 1, "email": "jinzhu@example.com"}`
+	User map[string]interface{}
+}
+
+// NewOAuthServer start a fake OAuth 2 provider, configure providers with its AuthorizeURL, TokenURL, and user info URL like `{URL}/userinfo`
+func NewOAuthServer(user map[string]interface{}) *OAuthServer {
+	server := &OAuthServer{User: user}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/authorize":
+			redirectURL, err := url.Parse(req.URL.Query().Get("redirect_uri"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			values := redirectURL.Query()
+			values.Set("code", "authtest")
+			values.Set("state", req.URL.Query().Get("state"))
+			redirectURL.RawQuery = values.Encode()
+			http.Redirect(w, req, redirectURL.String(), http.StatusFound)
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "authtest", "token_type": "bearer", "expires_in": 3600})
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(server.User)
+		}
+	}))
+	return server
+}
+
+// AuthorizeURL return authorize URL of server
+func (server *OAuthServer) AuthorizeURL() string {
+	return server.URL + "/authorize"
+}
+
+// TokenURL return token URL of server
+func (server *OAuthServer) TokenURL() string {
+	return server.URL + "/token"
+}
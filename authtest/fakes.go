@@ -0,0 +1,174 @@
+package authtest
+
+import (
+	"html"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/qor/mailer"
+)
+
+var (
+	linkRegexp = regexp.MustCompile(`https?://[^\s"'<>]+`)
+	codeRegexp = regexp.MustCompile(`\d{4,}`)
+)
+
+// Mailer mail sender that captures sent mails
+type Mailer struct {
+	mutex sync.Mutex
+	mails []mailer.Email
+}
+
+// Send capture mail
+func (m *Mailer) Send(email mailer.Email) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.mails = append(m.mails, email)
+	return nil
+}
+
+// Mails return sent mails
+func (m *Mailer) Mails() []mailer.Email {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]mailer.Email{}, m.mails...)
+}
+
+// LastMail return last mail sent to address
+func (m *Mailer) LastMail(to string) (mailer.Email, bool) {
+	mails := m.Mails()
+	for idx := len(mails) - 1; idx >= 0; idx-- {
+		for _, address := range mails[idx].TO {
+			if address.Address == to {
+				return mails[idx], true
+			}
+		}
+	}
+	return mailer.Email{}, false
+}
+
+// LastLink return first link of last mail sent to address, like confirm or reset password link
+func (m *Mailer) LastLink(to string) string {
+	if email, ok := m.LastMail(to); ok {
+		if links := Links(email); len(links) > 0 {
+			return links[0]
+		}
+	}
+	return ""
+}
+
+// Reset remove captured mails
+func (m *Mailer) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.mails = nil
+}
+
+// Links return links in mail's plain text part, or HTML part if plain text part is blank
+func Links(email mailer.Email) []string {
+	content := email.Text
+	if content == "" {
+		content = email.HTML
+	}
+
+	var links []string
+	for _, link := range linkRegexp.FindAllString(content, -1) {
+		links = append(links, html.UnescapeString(link))
+	}
+	return links
+}
+
+// SMS SMS sent by Auth
+type SMS struct {
+	To      string
+	Content string
+}
+
+// SMSSender SMS sender that captures sent SMS
+type SMSSender struct {
+	mutex    sync.Mutex
+	messages []SMS
+}
+
+// Send capture SMS
+func (sender *SMSSender) Send(destination string, content string) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	sender.messages = append(sender.messages, SMS{To: destination, Content: content})
+	return nil
+}
+
+// Messages return sent SMS
+func (sender *SMSSender) Messages() []SMS {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	return append([]SMS{}, sender.messages...)
+}
+
+// LastMessage return last SMS sent to destination
+func (sender *SMSSender) LastMessage(to string) (SMS, bool) {
+	messages := sender.Messages()
+	for idx := len(messages) - 1; idx >= 0; idx-- {
+		if messages[idx].To == to {
+			return messages[idx], true
+		}
+	}
+	return SMS{}, false
+}
+
+// LastCode return verification code in last SMS sent to destination
+func (sender *SMSSender) LastCode(to string) string {
+	if message, ok := sender.LastMessage(to); ok {
+		return codeRegexp.FindString(message.Content)
+	}
+	return ""
+}
+
+// Reset remove captured SMS
+func (sender *SMSSender) Reset() {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	sender.messages = nil
+}
+
+// Clock fake clock, its time is frozen until changed with Add or Set, use its Now as Auth's Clock to test expiration
+type Clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewClock new clock frozen at now
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now return clock's time
+func (clock *Clock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	return clock.now
+}
+
+// Add move clock forward by duration
+func (clock *Clock) Add(duration time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.now = clock.now.Add(duration)
+}
+
+// Set set clock's time
+func (clock *Clock) Set(now time.Time) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+
+	clock.now = now
+}
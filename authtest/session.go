@@ -0,0 +1,128 @@
+package authtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/qor/session"
+)
+
+// SessionManager session manager that keeps session data in memory, sessions are identified by cookie
+type SessionManager struct {
+	// CookieName cookie used to save session ID, default value is `authtest_session`
+	CookieName string
+
+	mutex    sync.Mutex
+	sessions map[string]map[string]string
+}
+
+func (manager *SessionManager) cookieName() string {
+	if manager.CookieName == "" {
+		return "authtest_session"
+	}
+	return manager.CookieName
+}
+
+// sessionID return session ID of request, new session will be started if the request doesn't have one and w is not nil
+func (manager *SessionManager) sessionID(w http.ResponseWriter, req *http.Request) string {
+	if cookie, err := req.Cookie(manager.cookieName()); err == nil {
+		return cookie.Value
+	}
+
+	if w == nil {
+		return ""
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	cookie := &http.Cookie{Name: manager.cookieName(), Value: hex.EncodeToString(id), Path: "/", HttpOnly: true}
+	http.SetCookie(w, cookie)
+	// so the session could be read in current request
+	req.AddCookie(cookie)
+	return cookie.Value
+}
+
+// Add value to session data, if value is not string, it will be saved as JSON
+func (manager *SessionManager) Add(w http.ResponseWriter, req *http.Request, key string, value interface{}) error {
+	str, ok := value.(string)
+	if !ok {
+		result, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		str = string(result)
+	}
+
+	id := manager.sessionID(w, req)
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if manager.sessions == nil {
+		manager.sessions = map[string]map[string]string{}
+	}
+	if manager.sessions[id] == nil {
+		manager.sessions[id] = map[string]string{}
+	}
+	manager.sessions[id][key] = str
+	return nil
+}
+
+// Get value from session data
+func (manager *SessionManager) Get(req *http.Request, key string) string {
+	id := manager.sessionID(nil, req)
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	return manager.sessions[id][key]
+}
+
+// Pop value from session data
+func (manager *SessionManager) Pop(w http.ResponseWriter, req *http.Request, key string) string {
+	id := manager.sessionID(nil, req)
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	value := manager.sessions[id][key]
+	delete(manager.sessions[id], key)
+	return value
+}
+
+// Flash add flash message to session data
+func (manager *SessionManager) Flash(w http.ResponseWriter, req *http.Request, message session.Message) error {
+	var messages []session.Message
+	manager.Load(req, "_flashes", &messages)
+	return manager.Add(w, req, "_flashes", append(messages, message))
+}
+
+// Flashes returns a slice of flash messages from session data
+func (manager *SessionManager) Flashes(w http.ResponseWriter, req *http.Request) []session.Message {
+	var messages []session.Message
+	manager.PopLoad(w, req, "_flashes", &messages)
+	return messages
+}
+
+// Load get value from session data and unmarshal it into result
+func (manager *SessionManager) Load(req *http.Request, key string, result interface{}) error {
+	if value := manager.Get(req, key); value != "" {
+		return json.Unmarshal([]byte(value), result)
+	}
+	return nil
+}
+
+// PopLoad pop value from session data and unmarshal it into result
+func (manager *SessionManager) PopLoad(w http.ResponseWriter, req *http.Request, key string, result interface{}) error {
+	if value := manager.Pop(w, req, key); value != "" {
+		return json.Unmarshal([]byte(value), result)
+	}
+	return nil
+}
+
+// Middleware session data is kept in memory, so it returns handler as it
+func (manager *SessionManager) Middleware(handler http.Handler) http.Handler {
+	return handler
+}
@@ -0,0 +1,339 @@
+package authtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// TwilioInvalidNumber numbers that SMSServer responds as invalid 'To' number for Twilio requests, like Twilio's test credentials
+var TwilioInvalidNumber = "+15005550001"
+
+// SMSServer local stand-in of HTTP SMS gateways, it accepts SMS posted by sms.HTTPSender with default body, and sms.TwilioSender, sent SMS are captured with SMS
+type SMSServer struct {
+	*httptest.Server
+	SMS *SMSSender
+
+	mutex    sync.Mutex
+	failures []int
+}
+
+// NewSMSServer start a stand-in of HTTP SMS gateways, use its URL as sms.HTTPSender's URL, or sms.TwilioSender's BaseURL
+func NewSMSServer() *SMSServer {
+	server := &SMSServer{SMS: &SMSSender{}}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// Fail respond next times requests with status, like `503`, `429`
+func (server *SMSServer) Fail(status int, times int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for i := 0; i < times; i++ {
+		server.failures = append(server.failures, status)
+	}
+}
+
+func (server *SMSServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	server.mutex.Lock()
+	var status int
+	if len(server.failures) > 0 {
+		status, server.failures = server.failures[0], server.failures[1:]
+	}
+	server.mutex.Unlock()
+
+	if status != 0 {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": status, "message": http.StatusText(status), "status": status})
+		return
+	}
+
+	if req.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if strings.HasSuffix(req.URL.Path, "/Messages.json") {
+		if _, _, ok := req.BasicAuth(); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 20003, "message": "Authenticate", "status": http.StatusUnauthorized})
+			return
+		}
+
+		to := req.FormValue("To")
+		if to == TwilioInvalidNumber {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 21211, "message": fmt.Sprintf("The 'To' number %v is not a valid phone number.", to), "status": http.StatusBadRequest})
+			return
+		}
+
+		server.SMS.Send(to, req.FormValue("Body"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"sid": fmt.Sprintf("SM%032d", len(server.SMS.Messages())), "to": to, "status": "queued"})
+		return
+	}
+
+	var body struct{ To, Content string }
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.To == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": "invalid_destination", "message": "to is required"})
+		return
+	}
+
+	server.SMS.Send(body.To, body.Content)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": len(server.SMS.Messages())})
+}
+
+// SMPPServer local stand-in of SMSC, it accepts transmitters bound with SystemID, Password if they are not blank, sent SMS are captured with SMS
+type SMPPServer struct {
+	// Addr address of server, use it as sms.SMPPSender's Addr
+	Addr     string
+	SystemID string
+	Password string
+	SMS      *SMSSender
+
+	listener net.Listener
+	mutex    sync.Mutex
+	failures []uint32
+	conns    map[net.Conn]bool
+}
+
+// NewSMPPServer start a stand-in of SMSC
+func NewSMPPServer() *SMPPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("authtest: failed to listen: %v", err))
+	}
+
+	server := &SMPPServer{Addr: listener.Addr().String(), SMS: &SMSSender{}, listener: listener, conns: map[net.Conn]bool{}}
+	go server.serve()
+	return server
+}
+
+// Fail respond next times submit_sm requests with command status, like `0x58` ESME_RTHROTTLED, `0x0B` ESME_RINVDSTADR
+func (server *SMPPServer) Fail(status uint32, times int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for i := 0; i < times; i++ {
+		server.failures = append(server.failures, status)
+	}
+}
+
+// CloseConnections close connections of bound transmitters, like SMSC restarted
+func (server *SMPPServer) CloseConnections() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for conn := range server.conns {
+		conn.Close()
+		delete(server.conns, conn)
+	}
+}
+
+// Close stop the server
+func (server *SMPPServer) Close() error {
+	err := server.listener.Close()
+	server.CloseConnections()
+	return err
+}
+
+func (server *SMPPServer) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		server.mutex.Lock()
+		server.conns[conn] = true
+		server.mutex.Unlock()
+
+		go server.serveConn(conn)
+	}
+}
+
+func (server *SMPPServer) serveConn(conn net.Conn) {
+	defer func() {
+		server.mutex.Lock()
+		delete(server.conns, conn)
+		server.mutex.Unlock()
+		conn.Close()
+	}()
+
+	var (
+		reader = bufio.NewReader(conn)
+		bound  bool
+	)
+
+	for {
+		commandID, sequence, body, err := readSMPP(reader)
+		if err != nil {
+			return
+		}
+
+		var (
+			status   uint32
+			respBody []byte
+		)
+
+		switch commandID {
+		case 0x00000002: // bind_transmitter
+			fields := bytes.SplitN(body, []byte{0}, 3)
+			if len(fields) < 3 || (server.SystemID != "" && string(fields[0]) != server.SystemID) {
+				status = 0x0F
+			} else if server.Password != "" && string(fields[1]) != server.Password {
+				status = 0x0E
+			} else {
+				bound = true
+			}
+			respBody = []byte("authtest\x00")
+		case 0x00000004: // submit_sm
+			server.mutex.Lock()
+			if len(server.failures) > 0 {
+				status, server.failures = server.failures[0], server.failures[1:]
+			}
+			server.mutex.Unlock()
+
+			if !bound {
+				status = 0x04
+			} else if status == 0 {
+				destination, content, ok := parseSubmitSM(body)
+				if !ok {
+					status = 0x02
+				} else {
+					server.SMS.Send(destination, content)
+					respBody = []byte(fmt.Sprintf("%d\x00", len(server.SMS.Messages())))
+				}
+			}
+		case 0x00000015: // enquire_link
+		case 0x00000006: // unbind
+			writeSMPP(conn, commandID|0x80000000, 0, sequence, nil)
+			return
+		default:
+			writeSMPP(conn, 0x80000000, 0x03, sequence, nil)
+			continue
+		}
+
+		if err := writeSMPP(conn, commandID|0x80000000, status, sequence, respBody); err != nil {
+			return
+		}
+	}
+}
+
+// parseSubmitSM parse destination and content of submit_sm, international destinations are returned with `+`
+func parseSubmitSM(body []byte) (destination string, content string, ok bool) {
+	cstring := func() (string, bool) {
+		idx := bytes.IndexByte(body, 0)
+		if idx < 0 {
+			return "", false
+		}
+		value := string(body[:idx])
+		body = body[idx+1:]
+		return value, true
+	}
+
+	skip := func(n int) bool {
+		if len(body) < n {
+			return false
+		}
+		body = body[n:]
+		return true
+	}
+
+	// service_type, source_addr_ton, source_addr_npi, source_addr
+	if _, ok = cstring(); !ok || !skip(2) {
+		return
+	}
+	if _, ok = cstring(); !ok || len(body) < 2 {
+		return "", "", false
+	}
+
+	ton := body[0]
+	skip(2)
+	if destination, ok = cstring(); !ok {
+		return
+	}
+	if ton == 1 {
+		destination = "+" + destination
+	}
+
+	// esm_class, protocol_id, priority_flag, schedule_delivery_time, validity_period
+	if !skip(3) {
+		return "", "", false
+	}
+	if _, ok = cstring(); !ok {
+		return
+	}
+	if _, ok = cstring(); !ok || len(body) < 5 {
+		return "", "", false
+	}
+
+	dataCoding, length := body[2], int(body[4])
+	body = body[5:]
+	if len(body) < length {
+		return "", "", false
+	}
+	message := body[:length]
+
+	// message_payload
+	for tlvs := body[length:]; len(tlvs) >= 4; {
+		tag, size := binary.BigEndian.Uint16(tlvs), int(binary.BigEndian.Uint16(tlvs[2:]))
+		if len(tlvs) < 4+size {
+			break
+		}
+		if tag == 0x0424 {
+			message = tlvs[4 : 4+size]
+		}
+		tlvs = tlvs[4+size:]
+	}
+
+	if dataCoding == 0x08 {
+		codes := make([]uint16, len(message)/2)
+		for i := range codes {
+			codes[i] = binary.BigEndian.Uint16(message[i*2:])
+		}
+		return destination, string(utf16.Decode(codes)), true
+	}
+	return destination, string(message), true
+}
+
+func readSMPP(r io.Reader) (commandID uint32, sequence uint32, body []byte, err error) {
+	header := make([]byte, 16)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+
+	length := binary.BigEndian.Uint32(header)
+	if length < 16 || length > 64*1024 {
+		return 0, 0, nil, fmt.Errorf("authtest: invalid SMPP PDU length %v", length)
+	}
+
+	body = make([]byte, length-16)
+	_, err = io.ReadFull(r, body)
+	return binary.BigEndian.Uint32(header[4:]), binary.BigEndian.Uint32(header[12:]), body, err
+}
+
+func writeSMPP(w io.Writer, commandID uint32, status uint32, sequence uint32, body []byte) error {
+	buf := make([]byte, 16+len(body))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(buf)))
+	binary.BigEndian.PutUint32(buf[4:], commandID)
+	binary.BigEndian.PutUint32(buf[8:], status)
+	binary.BigEndian.PutUint32(buf[12:], sequence)
+	copy(buf[16:], body)
+
+	_, err := w.Write(buf)
+	return err
+}
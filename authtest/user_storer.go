@@ -0,0 +1,93 @@
+package authtest
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/jinzhu/copier"
+	"github.com/qor/qor/utils"
+)
+
+// UserStorer user storer that keeps users in memory, users are copied from schema into new UserModel records, their `ID` field is set to user ID if exists, nothing is saved if UserModel is blank, like auth.UserStorer
+type UserStorer struct {
+	mutex  sync.RWMutex
+	users  map[string]interface{}
+	lastID int
+}
+
+// Save save user copied from schema
+func (storer *UserStorer) Save(schema *auth.Schema, context *auth.Context) (user interface{}, userID string, err error) {
+	if context.Auth.Config.UserModel == nil {
+		return nil, "", nil
+	}
+
+	user = reflect.New(utils.ModelType(context.Auth.Config.UserModel)).Interface()
+	if err = copier.Copy(user, schema); err != nil {
+		return nil, "", err
+	}
+
+	storer.mutex.Lock()
+	defer storer.mutex.Unlock()
+
+	storer.lastID++
+	userID = strconv.Itoa(storer.lastID)
+
+	if field := reflect.Indirect(reflect.ValueOf(user)).FieldByName("ID"); field.IsValid() && field.CanSet() {
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			field.SetInt(int64(storer.lastID))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetUint(uint64(storer.lastID))
+		case reflect.String:
+			field.SetString(userID)
+		}
+	}
+
+	if storer.users == nil {
+		storer.users = map[string]interface{}{}
+	}
+	storer.users[userID] = user
+	return user, userID, nil
+}
+
+// Get get user of claims, returns claims' auth identity if it has no user
+func (storer *UserStorer) Get(claims *claims.Claims, context *auth.Context) (user interface{}, err error) {
+	userID := claims.UserID
+
+	if userID == "" {
+		authInfo, err := context.Auth.IdentityStore.Find(context.Request, claims.Provider, claims.Id)
+		if err != nil {
+			return nil, auth.ErrInvalidAccount
+		}
+
+		if authInfo.UserID == "" {
+			return authInfo, nil
+		}
+		userID = authInfo.UserID
+	}
+
+	if user = storer.User(userID); user == nil {
+		return nil, auth.ErrInvalidAccount
+	}
+	return user, nil
+}
+
+// UpdateProfile update user with profile synced from providers, implements auth.ProfileUpdater
+func (storer *UserStorer) UpdateProfile(user interface{}, schema *auth.Schema, context *auth.Context) error {
+	storer.mutex.Lock()
+	defer storer.mutex.Unlock()
+
+	auth.ApplySchema(user, schema)
+	return nil
+}
+
+// User return user with user ID, changing returned user will change the saved one
+func (storer *UserStorer) User(userID string) interface{} {
+	storer.mutex.RLock()
+	defer storer.mutex.RUnlock()
+
+	return storer.users[userID]
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+)
+
+const requestCacheKey utils.ContextKey = "auth_request_cache"
+
+// requestCache claims and current user memoized for a request
+type requestCache struct {
+	mutex        sync.Mutex
+	claims       *claims.Claims
+	claimsErr    error
+	claimsLoaded bool
+	user         interface{}
+	userLoaded   bool
+	locale       string
+	localeLoaded bool
+}
+
+// WithRequestCache return a copy of request that memoizes its claims, current user and locale, so the session is validated, and current user is loaded only once for a request, Auth's Middleware, Auth's routes and Authority's Middleware do it
+func WithRequestCache(req *http.Request) *http.Request {
+	if getRequestCache(req) != nil {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), requestCacheKey, &requestCache{}))
+}
+
+func getRequestCache(req *http.Request) *requestCache {
+	cache, _ := req.Context().Value(requestCacheKey).(*requestCache)
+	return cache
+}
+
+// Get get claims from request, it is memoized if the request has cache, refer WithRequestCache
+func (auth *Auth) Get(req *http.Request) (*claims.Claims, error) {
+	cache := getRequestCache(req)
+	if cache == nil {
+		return auth.SessionStorer.Get(req)
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.claimsLoaded {
+		cache.claims, cache.claimsErr = auth.SessionStorer.Get(req)
+		cache.claimsLoaded = true
+	}
+	return cache.claims, cache.claimsErr
+}
+
+// Update update claims of session, and claims memoized for the request
+func (auth *Auth) Update(w http.ResponseWriter, req *http.Request, claims *claims.Claims) error {
+	if cache := getRequestCache(req); cache != nil {
+		cache.mutex.Lock()
+		cache.claims, cache.claimsErr, cache.claimsLoaded = claims, nil, true
+		cache.user, cache.userLoaded = nil, false
+		cache.localeLoaded = false
+		cache.mutex.Unlock()
+	}
+	return auth.SessionStorer.Update(w, req, claims)
+}
+
+// Delete delete session, and claims memoized for the request
+func (auth *Auth) Delete(w http.ResponseWriter, req *http.Request) error {
+	if cache := getRequestCache(req); cache != nil {
+		cache.mutex.Lock()
+		cache.claims, cache.claimsErr, cache.claimsLoaded = nil, ErrUnauthorized, true
+		cache.user, cache.userLoaded = nil, true
+		cache.localeLoaded = false
+		cache.mutex.Unlock()
+	}
+	return auth.SessionStorer.Delete(w, req)
+}
+
+// loadUser load user of claims with UserStorer, loaded users are cached in UserCache if configured
+func (auth *Auth) loadUser(req *http.Request, claims *claims.Claims) (interface{}, error) {
+	var key string
+
+	if auth.Config.UserCache != nil {
+		key = SessionKey(claims)
+		if user, ok := auth.Config.UserCache.Get(key); ok {
+			return copyUser(user), nil
+		}
+	}
+
+	user, err := auth.UserStorer.Get(claims, &Context{Auth: auth, Claims: claims, Request: req})
+	if err == nil && auth.Config.UserCache != nil {
+		auth.Config.UserCache.Set(key, copyUser(user))
+	}
+	return user, err
+}
+
+// copyUser shallow copy cached user, so changing its fields won't change the cached one
+func copyUser(user interface{}) interface{} {
+	value := reflect.ValueOf(user)
+	if value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Struct {
+		copied := reflect.New(value.Elem().Type())
+		copied.Elem().Set(value.Elem())
+		return copied.Interface()
+	}
+	return user
+}
+
+// InvalidateUser remove cached user of claims from UserCache, call it after changed the user, e.g. updated user's roles
+func (auth *Auth) InvalidateUser(claims *claims.Claims) {
+	if auth.Config.UserCache != nil {
+		auth.Config.UserCache.Delete(SessionKey(claims))
+	}
+}
+
+// UserCacheInterface cache users loaded by UserStorer across requests, keyed by SessionKey of claims
+type UserCacheInterface interface {
+	Get(key string) (user interface{}, ok bool)
+	Set(key string, user interface{})
+	Delete(key string)
+}
+
+// MemoryUserCache in-memory LRU user cache
+type MemoryUserCache struct {
+	// TTL how long users are cached, default is 1 minute
+	TTL time.Duration
+	// Size max number of cached users, least recently used ones are removed when full, default is 1000
+	Size int
+	// Clock current time used to expire cached users, default is time.Now, Auth will set it to its Clock if blank
+	Clock func() time.Time
+
+	mutex sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+type memoryUserCacheItem struct {
+	key       string
+	user      interface{}
+	expiredAt time.Time
+}
+
+func (cache *MemoryUserCache) now() time.Time {
+	if cache.Clock != nil {
+		return cache.Clock()
+	}
+	return time.Now()
+}
+
+// Get get cached user
+func (cache *MemoryUserCache) Get(key string) (interface{}, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := element.Value.(*memoryUserCacheItem)
+	if cache.now().After(item.expiredAt) {
+		cache.lru.Remove(element)
+		delete(cache.items, key)
+		return nil, false
+	}
+
+	cache.lru.MoveToFront(element)
+	return item.user, true
+}
+
+// Set cache user
+func (cache *MemoryUserCache) Set(key string, user interface{}) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	var (
+		ttl  = cache.TTL
+		size = cache.Size
+	)
+
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	if size <= 0 {
+		size = 1000
+	}
+
+	if cache.items == nil {
+		cache.items, cache.lru = map[string]*list.Element{}, list.New()
+	}
+
+	item := &memoryUserCacheItem{key: key, user: user, expiredAt: cache.now().Add(ttl)}
+	if element, ok := cache.items[key]; ok {
+		element.Value = item
+		cache.lru.MoveToFront(element)
+		return
+	}
+
+	cache.items[key] = cache.lru.PushFront(item)
+	for cache.lru.Len() > size {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.items, oldest.Value.(*memoryUserCacheItem).key)
+	}
+}
+
+// Delete remove cached user
+func (cache *MemoryUserCache) Delete(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, ok := cache.items[key]; ok {
+		cache.lru.Remove(element)
+		delete(cache.items, key)
+	}
+}
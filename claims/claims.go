@@ -13,9 +13,50 @@ type Claims struct {
 	LastLoginAt                      *time.Time     `json:"last_login,omitempty"`
 	LastActiveAt                     *time.Time     `json:"last_active,omitempty"`
 	LongestDistractionSinceLastLogin *time.Duration `json:"distraction_time,omitempty"`
+	Roles                            []string       `json:"roles,omitempty"`
+	Permissions                      []string       `json:"permissions,omitempty"`
+	RolesLoadedAt                    *time.Time     `json:"roles_loaded_at,omitempty"`
+	Impersonator                     *Impersonator  `json:"impersonator,omitempty"`
+	SecondFactor                     string         `json:"second_factor,omitempty"`
+	SecondFactorAt                   *time.Time     `json:"second_factor_at,omitempty"`
+	TenantID                         string         `json:"tenant_id,omitempty"`
 	jwt.StandardClaims
 }
 
+// HasRole check if claims has any of roles
+func (claims *Claims) HasRole(roles ...string) bool {
+	return claims != nil && includesAny(claims.Roles, roles)
+}
+
+// HasPermission check if claims has any of permissions
+func (claims *Claims) HasPermission(permissions ...string) bool {
+	return claims != nil && includesAny(claims.Permissions, permissions)
+}
+
+func includesAny(values []string, targets []string) bool {
+	for _, value := range values {
+		for _, target := range targets {
+			if value == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Impersonator the original identity of an admin who is signed in as another user
+type Impersonator struct {
+	Provider  string     `json:"provider,omitempty"`
+	UID       string     `json:"uid,omitempty"`
+	UserID    string     `json:"userid,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// IsImpersonating check if claims is issued for an admin who is signed in as another user
+func (claims *Claims) IsImpersonating() bool {
+	return claims != nil && claims.Impersonator != nil
+}
+
 // ToClaims implement ClaimerInterface
 func (claims *Claims) ToClaims() *Claims {
 	return claims
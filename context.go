@@ -14,6 +14,8 @@ type Context struct {
 	Provider Provider
 	Request  *http.Request
 	Writer   http.ResponseWriter
+	// Params params of matched route, refer Param
+	Params map[string]string
 }
 
 // Flashes get flash messages
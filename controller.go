@@ -4,11 +4,9 @@ import (
 	"net/http"
 	"path"
 	"strings"
-
-	"github.com/fahmibaswara/auth/claims"
 )
 
-// NewServeMux generate http.Handler for auth
+// NewServeMux generate http.Handler for auth, it serves routes of Auth's route table, refer AddRoute
 func (auth *Auth) NewServeMux() http.Handler {
 	return &serveMux{Auth: auth}
 }
@@ -19,61 +17,74 @@ type serveMux struct {
 
 // ServeHTTP dispatches the handler registered in the matched route
 func (serveMux *serveMux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var (
-		claims  *claims.Claims
-		reqPath = strings.TrimPrefix(req.URL.Path, serveMux.URLPrefix)
-		paths   = strings.Split(reqPath, "/")
-		context = &Context{Auth: serveMux.Auth, Claims: claims, Request: req, Writer: w}
-	)
-
-	if len(paths) >= 2 {
-		// render assets
-		if paths[0] == "assets" {
-			DefaultAssetHandler(context)
-			return
-		}
-
-		// eg: /phone/login
-		if provider := serveMux.Auth.GetProvider(paths[0]); provider != nil {
-			context.Provider = provider
-
-			// serve mux
-			switch paths[1] {
-			case "login":
-				provider.Login(context)
-			case "logout":
-				provider.Logout(context)
-			case "register":
-				provider.Register(context)
-			case "callback":
-				provider.Callback(context)
-			default:
-				provider.ServeHTTP(context)
-			}
-			return
-		}
-	} else if len(paths) == 1 {
-		// eg: /login, /logout
-		switch paths[0] {
-		case "login":
-			// render login page
-			serveMux.Auth.Render.Execute("auth/login", context, req, w)
-		case "register":
-			// render register page
-			serveMux.Auth.Render.Execute("auth/register", context, req, w)
-		case "logout":
-			// destroy login context
-			serveMux.Auth.LogoutHandler(context)
-		default:
-			http.NotFound(w, req)
-		}
+	req, err := serveMux.Auth.resolveTenant(req)
+	if err != nil {
+		serveMux.Auth.WriteError(w, req, err)
 		return
 	}
 
-	http.NotFound(w, req)
+	reqPath := strings.TrimPrefix(req.URL.Path, serveMux.URLPrefix)
+
+	route, params, allowed, status := serveMux.Auth.matchRoute(req, reqPath)
+	switch status {
+	case http.StatusNotFound:
+		http.NotFound(w, req)
+	case http.StatusMethodNotAllowed:
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, http.StatusText(status), status)
+	default:
+		serveMux.Auth.serveRoute(w, req, route, params)
+	}
+}
+
+// Mount mount handler under `{Auth Prefix}/{name}`, e.g. admin pages, it is a route with path `{name}/*` that accepts any method
+func (auth *Auth) Mount(name string, handler func(*Context)) {
+	auth.AddRoute(Route{Path: path.Join(name, "*"), Handler: handler})
 }
 
 // AuthURL generate URL for auth
 func (auth *Auth) AuthURL(pth string) string {
 	return path.Join(auth.URLPrefix, pth)
 }
+
+// registerDefaultRoutes register routes of login, register, logout pages, assets, mail previews, account pages
+func (auth *Auth) registerDefaultRoutes() {
+	auth.AddRoute(Route{Method: "GET", Path: "login", Description: "render login page, `return_to` param is saved to redirect back after login", Handler: func(context *Context) {
+		// eg: /login?return_to=/orders
+		if returnTo := context.Request.URL.Query().Get("return_to"); returnTo != "" {
+			context.Auth.SetReturnTo(context.Writer, context.Request, returnTo)
+		}
+		context.Auth.Render.Execute("auth/login", context, context.Request, context.Writer)
+	}})
+
+	auth.AddRoute(Route{Method: "GET", Path: "register", Description: "render register page", Handler: func(context *Context) {
+		context.Auth.Render.Execute("auth/register", context, context.Request, context.Writer)
+	}})
+
+	auth.AddRoute(Route{Path: "logout", Description: "destroy login session", Handler: func(context *Context) {
+		context.Auth.LogoutHandler(context)
+	}})
+
+	auth.AddRoute(Route{Path: "locale", Description: "save chosen locale", Handler: func(context *Context) {
+		DefaultLocaleHandler(context)
+	}})
+
+	auth.AddRoute(Route{Method: "GET", Path: "providers", Description: "list providers with their metadata, login URL and capabilities as JSON", Handler: func(context *Context) {
+		DefaultProvidersHandler(context)
+	}})
+
+	auth.AddRoute(Route{Method: "GET", Path: "assets/*", Description: "serve asset files", Handler: func(context *Context) {
+		DefaultAssetHandler(context)
+	}})
+
+	for _, pth := range []string{"mailers/preview", "mailers/preview/:name"} {
+		auth.AddRoute(Route{Method: "GET", Path: pth, Description: "preview sample mails", Handler: func(context *Context) {
+			DefaultMailPreviewHandler(context)
+		}})
+	}
+
+	auth.AddRoute(Route{Method: "GET", Path: "account/export", Description: "download personal data as JSON", Handler: exportAccount})
+	auth.AddRoute(Route{Method: "GET", Path: "account/delete", Description: "render delete account page", Handler: deleteAccountPage})
+	auth.AddRoute(Route{Method: "POST", Path: "account/delete", Description: "request to delete account", Handler: deleteAccount})
+	auth.AddRoute(Route{Method: "POST", Path: "account/cancel_deletion", Description: "cancel requested account deletion", Handler: cancelDeletion})
+}
@@ -1,18 +1,179 @@
 package auth
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/qor/session"
+)
 
 var (
 	// ErrAlreadyRegistered registered error
-	ErrAlreadyRegistered = errors.New("Email already registered")
+	ErrAlreadyRegistered = NewError("already_registered", http.StatusConflict, "Email already registered")
 	// ErrPhoneRegistered registered error
-	ErrPhoneRegistered = errors.New("Phone already registered")
+	ErrPhoneRegistered = NewError("phone_registered", http.StatusConflict, "Phone already registered")
 	// ErrInvalidPassword invalid password error
-	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidPassword = NewError("invalid_password", http.StatusUnauthorized, "invalid password")
 	// ErrInvalidAccount invalid account error
-	ErrInvalidAccount = errors.New("invalid account")
+	ErrInvalidAccount = NewError("invalid_account", http.StatusUnauthorized, "invalid account")
 	// ErrInvalidPhoneNumber invalid format phone number
-	ErrInvalidPhoneNumber = errors.New("invalid format phone number")
+	ErrInvalidPhoneNumber = NewError("invalid_phone_number", http.StatusUnprocessableEntity, "invalid format phone number")
 	// ErrUnauthorized unauthorized error
-	ErrUnauthorized = errors.New("Unauthorized")
+	ErrUnauthorized = NewError("unauthorized", http.StatusUnauthorized, "Unauthorized")
+	// ErrSessionRevoked session revoked error
+	ErrSessionRevoked = NewError("session_revoked", http.StatusUnauthorized, "Session has been revoked")
+	// ErrInvalidActionToken invalid action token error
+	ErrInvalidActionToken = NewError("invalid_action_token", http.StatusBadRequest, "Invalid Token")
+	// ErrActionTokenExpired action token expired error
+	ErrActionTokenExpired = NewError("action_token_expired", http.StatusBadRequest, "Token Has Expired")
+	// ErrActionTokenUsed action token used error
+	ErrActionTokenUsed = NewError("action_token_used", http.StatusBadRequest, "Token Has Already Been Used")
+	// ErrIdentityNotFound auth identity not found error, returned by IdentityStore
+	ErrIdentityNotFound = NewError("identity_not_found", http.StatusNotFound, "Identity not found")
+	// ErrTokenNotFound token not found error, returned by TokenStore
+	ErrTokenNotFound = NewError("token_not_found", http.StatusNotFound, "Token not found")
+	// ErrAccountLocked account locked error
+	ErrAccountLocked = NewError("account_locked", http.StatusForbidden, "Your account has been locked")
+	// ErrImpersonating action not allowed while an admin is signed in as another user error
+	ErrImpersonating = NewError("impersonating", http.StatusForbidden, "This action is not allowed while signed in as another user")
+	// ErrSessionRevokerRequired session revoker not configured error
+	ErrSessionRevokerRequired = NewError("session_revoker_required", http.StatusInternalServerError, "SessionRevoker is required to revoke sessions")
+	// ErrUnsupportedProvider provider doesn't support the feature error
+	ErrUnsupportedProvider = NewError("unsupported_provider", http.StatusNotFound, "This sign in method doesn't support it")
+	// ErrIdentityLinked auth identity is linked to another user error
+	ErrIdentityLinked = NewError("identity_linked", http.StatusConflict, "This account is already linked to another user")
+	// ErrTenantNotFound request doesn't belong to any tenant error, returned by TenantResolver
+	ErrTenantNotFound = NewError("tenant_not_found", http.StatusNotFound, "Site not found")
+	// ErrTenantMismatch session or token is issued by another tenant error
+	ErrTenantMismatch = NewError("tenant_mismatch", http.StatusUnauthorized, "Your session doesn't belong to this site")
+	// ErrInternal internal error, unknown errors will be responded as it, and their message won't be shown to users
+	ErrInternal = NewError("internal_error", http.StatusInternalServerError, "Something went wrong, please try again later")
 )
+
+// Error auth error, it has a stable code for clients, HTTP status, a message that is safe to show to users, and the internal cause
+type Error struct {
+	// Code machine-readable code, like `invalid_account`, errors with same code are treated as same error by `errors.Is`
+	Code string
+	// Status HTTP status used when respond the error
+	Status int
+	// Message message shown to users
+	Message string
+	// MessageID message ID used to translate Message, refer Auth.TranslateError
+	MessageID string
+	// Details extra data of the error for JSON clients, like failed password policy rules
+	Details interface{}
+	// Cause internal cause of the error, it will be logged, but never shown to users
+	Cause error
+}
+
+// NewError new auth error, its message ID is `auth.errors.{code}`
+func NewError(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message, MessageID: "auth.errors." + code}
+}
+
+// Error return error's user message
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// Unwrap return error's cause
+func (err *Error) Unwrap() error {
+	return err.Cause
+}
+
+// Is check if target is an auth error with same code
+func (err *Error) Is(target error) bool {
+	if e, ok := target.(*Error); ok {
+		return e.Code == err.Code
+	}
+	return false
+}
+
+// Wrap return a copy of error with cause
+func (err *Error) Wrap(cause error) *Error {
+	e := *err
+	e.Cause = cause
+	return &e
+}
+
+// WithMessage return a copy of error with message, as the message is not the default one, it won't be translated
+func (err *Error) WithMessage(message string) *Error {
+	e := *err
+	e.Message = message
+	e.MessageID = ""
+	return &e
+}
+
+// WithDetails return a copy of error with details
+func (err *Error) WithDetails(details interface{}) *Error {
+	e := *err
+	e.Details = details
+	return &e
+}
+
+// AsError convert err to auth error, err will be wrapped as ErrInternal if it is not an auth error
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// ErrorResponse JSON body of error response
+type ErrorResponse struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// DefaultErrorLogger default error logger, log errors that have an internal cause or a server error status
+var DefaultErrorLogger = func(req *http.Request, err *Error) {
+	if err.Cause != nil || err.Status >= http.StatusInternalServerError {
+		log.Printf("auth: %v %v: %v (%v)", req.Method, req.URL.Path, err.Code, err.Cause)
+	}
+}
+
+func (auth *Auth) logError(req *http.Request, err *Error) {
+	if auth.Config.ErrorLogger != nil {
+		auth.Config.ErrorLogger(req, err)
+	}
+}
+
+// LogError log error with ErrorLogger without responding it, e.g. failures of background work that shouldn't fail the request
+func (auth *Auth) LogError(req *http.Request, err error) {
+	if e := AsError(err); e != nil {
+		auth.logError(req, e)
+	}
+}
+
+// FlashError log error, and flash its translated message
+func (auth *Auth) FlashError(w http.ResponseWriter, req *http.Request, err error) {
+	if e := AsError(err); e != nil {
+		auth.logError(req, e)
+		auth.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(auth.TranslateError(req, e)), Type: "error"})
+	}
+}
+
+// WriteError log error, and write it as JSON with its HTTP status
+func (auth *Auth) WriteError(w http.ResponseWriter, req *http.Request, err error) {
+	if e := AsError(err); e != nil {
+		auth.logError(req, e)
+
+		status := e.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Code: e.Code, Message: auth.TranslateError(req, e), Details: e.Details})
+	}
+}
@@ -3,12 +3,10 @@ package auth
 import (
 	"crypto/md5"
 	"fmt"
-	"html/template"
 	"mime"
 	"net/http"
 	"path"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/fahmibaswara/auth/claims"
@@ -17,9 +15,6 @@ import (
 )
 
 func respondAfterLogged(claims *claims.Claims, context *Context) {
-	// login user
-	context.Auth.Login(context.Writer, context.Request, claims)
-
 	responder.With("html", func() {
 		// write cookie
 		context.Auth.Redirector.Redirect(context.Writer, context.Request, "login")
@@ -37,18 +32,21 @@ var DefaultLoginHandler = func(context *Context, authorize func(*Context) (*clai
 	)
 
 	if err == nil && claims != nil {
-		context.SessionStorer.Flash(w, req, session.Message{Message: "logged"})
-		respondAfterLogged(claims, context)
-		return
+		// login user
+		if err = context.Auth.Login(w, req, claims); err == nil {
+			context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.logged", "logged")})
+			respondAfterLogged(claims, context)
+			return
+		}
 	}
 
-	context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(w, req, err)
 
 	// error handling
 	responder.With("html", func() {
 		context.Auth.Config.Render.Execute("auth/login", context, req, w)
 	}).With([]string{"json"}, func() {
-		// TODO write json error
+		context.Auth.WriteError(w, req, err)
 	}).Respond(context.Request)
 }
 
@@ -61,24 +59,27 @@ var DefaultRegisterHandler = func(context *Context, register func(*Context) (*cl
 	)
 
 	if err == nil && claims != nil {
-		respondAfterLogged(claims, context)
-		return
+		// login user
+		if err = context.Auth.Login(w, req, claims); err == nil {
+			respondAfterLogged(claims, context)
+			return
+		}
 	}
 
-	context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(w, req, err)
 
 	// error handling
 	responder.With("html", func() {
 		context.Auth.Config.Render.Execute("auth/register", context, req, w)
 	}).With([]string{"json"}, func() {
-		// TODO write json error
+		context.Auth.WriteError(w, req, err)
 	}).Respond(context.Request)
 }
 
 // DefaultLogoutHandler default logout behaviour
 var DefaultLogoutHandler = func(context *Context) {
 	// Clear auth session
-	context.SessionStorer.Delete(context.Writer, context.Request)
+	context.Auth.Delete(context.Writer, context.Request)
 	context.Auth.Redirector.Redirect(context.Writer, context.Request, "logout")
 }
 
@@ -86,7 +87,7 @@ var cacheSince = time.Now().Format(http.TimeFormat)
 
 // DefaultAssetHandler render auth asset file
 var DefaultAssetHandler = func(context *Context) {
-	asset := strings.TrimPrefix(context.Request.URL.Path, context.Auth.URLPrefix)
+	asset := path.Join("assets", context.Param("*"))
 
 	if context.Request.Header.Get("If-Modified-Since") == cacheSince {
 		context.Writer.WriteHeader(http.StatusNotModified)
@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Translator keeps translations of messages, messages are keyed by message ID, like `auth.errors.invalid_account`
+type Translator struct {
+	// DefaultLocale locale used when no supported locale matched, messages of default locale usually come from the fallback passed to Translate
+	DefaultLocale string
+	mutex         sync.RWMutex
+	messages      map[string]map[string]string
+}
+
+// New initialize Translator with built-in translations
+func New(defaultLocale string) *Translator {
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+
+	translator := &Translator{DefaultLocale: defaultLocale}
+	translator.AddMessages("id", Indonesian)
+	return translator
+}
+
+// AddMessages add translations for locale, existing translations with same ID will be overwritten
+func (translator *Translator) AddMessages(locale string, messages map[string]string) {
+	translator.mutex.Lock()
+	defer translator.mutex.Unlock()
+
+	locale = normalize(locale)
+	if translator.messages == nil {
+		translator.messages = map[string]map[string]string{}
+	}
+
+	if translator.messages[locale] == nil {
+		translator.messages[locale] = map[string]string{}
+	}
+
+	for id, message := range messages {
+		translator.messages[locale][id] = message
+	}
+}
+
+// Locales return locales that have translations, and default locale
+func (translator *Translator) Locales() []string {
+	translator.mutex.RLock()
+	defer translator.mutex.RUnlock()
+
+	locales := []string{normalize(translator.DefaultLocale)}
+	for locale := range translator.messages {
+		if locale != locales[0] {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Lookup find translation of message ID for locale, fallback to locale's base language, e.g. `id-ID` -> `id`
+func (translator *Translator) Lookup(locale string, id string) (string, bool) {
+	translator.mutex.RLock()
+	defer translator.mutex.RUnlock()
+
+	for _, candidate := range candidates(locale) {
+		if message, ok := translator.messages[candidate][id]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Translate translate message ID to locale, return fallback if no translation found
+func (translator *Translator) Translate(locale string, id string, fallback string) string {
+	if message, ok := translator.Lookup(locale, id); ok {
+		return message
+	}
+	return fallback
+}
+
+// Match return first supported locale of candidates, or default locale if none matched
+func (translator *Translator) Match(locales ...string) string {
+	supported := translator.Locales()
+	for _, locale := range locales {
+		for _, candidate := range candidates(locale) {
+			for _, s := range supported {
+				if s == candidate {
+					return s
+				}
+			}
+		}
+	}
+	return normalize(translator.DefaultLocale)
+}
+
+// ParseAcceptLanguage parse Accept-Language header, return languages sorted by quality
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, field := range fields[1:] {
+			if field = strings.TrimSpace(field); strings.HasPrefix(field, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(field, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		languages = append(languages, language{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	var tags []string
+	for _, lang := range languages {
+		tags = append(tags, lang.tag)
+	}
+	return tags
+}
+
+func normalize(locale string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(locale)), "_", "-", -1)
+}
+
+func candidates(locale string) []string {
+	locale = normalize(locale)
+	if locale == "" {
+		return nil
+	}
+
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		return []string{locale, locale[:idx]}
+	}
+	return []string{locale}
+}
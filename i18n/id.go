@@ -0,0 +1,176 @@
+package i18n
+
+// Indonesian built-in Indonesian translations
+var Indonesian = map[string]string{
+	// errors
+	"auth.errors.already_registered":   "Email sudah terdaftar",
+	"auth.errors.phone_registered":     "Nomor telepon sudah terdaftar",
+	"auth.errors.invalid_password":     "Kata sandi salah",
+	"auth.errors.invalid_account":      "Akun tidak valid",
+	"auth.errors.invalid_phone_number": "Format nomor telepon tidak valid",
+	"auth.errors.unauthorized":         "Tidak diizinkan",
+	"auth.errors.session_revoked":      "Sesi telah dicabut",
+	"auth.errors.invalid_action_token": "Token tidak valid",
+	"auth.errors.action_token_expired": "Token telah kedaluwarsa",
+	"auth.errors.action_token_used":    "Token sudah pernah digunakan",
+	"auth.errors.already_confirmed":    "Akun Anda sudah dikonfirmasi",
+	"auth.errors.unconfirmed":          "Anda harus mengonfirmasi akun Anda sebelum melanjutkan",
+	"auth.errors.impersonating":        "Tindakan ini tidak diizinkan saat masuk sebagai pengguna lain",
+	"auth.errors.identity_not_found":   "Identitas tidak ditemukan",
+	"auth.errors.token_not_found":      "Token tidak ditemukan",
+	"auth.errors.account_locked":       "Akun Anda telah dikunci",
+	"auth.errors.unsupported_provider": "Metode masuk ini tidak mendukungnya",
+	"auth.errors.identity_linked":      "Akun ini sudah ditautkan ke pengguna lain",
+	"auth.errors.tenant_not_found":     "Situs tidak ditemukan",
+	"auth.errors.tenant_mismatch":      "Sesi Anda bukan milik situs ini",
+	"auth.errors.internal_error":       "Terjadi kesalahan, silakan coba lagi nanti",
+
+	"password.errors.invalid_reset_password_token":   "Token tidak valid",
+	"password.errors.password_confirmation_mismatch": "Konfirmasi kata sandi tidak cocok",
+	"password.errors.weak_password":                  "Kata sandi terlalu lemah",
+
+	"phone.errors.invalid_token":         "Token tidak cocok",
+	"phone.errors.token_expired":         "Token telah kedaluwarsa",
+	"phone.errors.too_many_attempts":     "Terlalu banyak percobaan, silakan coba lagi nanti",
+	"phone.errors.resend_too_soon":       "Harap tunggu sebelum meminta kode baru",
+	"phone.errors.invalid_number":        "Nomor telepon tidak valid",
+	"phone.errors.phone_number_required": "Nomor telepon wajib diisi",
+	"phone.errors.phone_not_found":       "Maaf, sepertinya nomor telepon Anda belum terdaftar",
+	"phone.errors.invalid_channel":       "Kode tidak dapat dikirim dengan cara ini",
+	"phone.errors.channel_unavailable":   "Kode tidak dapat dikirim dengan cara ini, silakan pilih cara lain",
+
+	"sms.errors.invalid_destination": "SMS tidak dapat dikirim ke nomor telepon ini",
+	"sms.errors.rate_limited":        "Terlalu banyak SMS yang dikirim, silakan coba lagi nanti",
+	"sms.errors.unavailable":         "SMS tidak dapat dikirim saat ini, silakan coba lagi nanti",
+	"sms.errors.rejected":            "SMS tidak dapat dikirim, silakan coba lagi nanti",
+
+	// flash messages
+	"auth.flash.logged":                     "Berhasil masuk",
+	"auth.flash.confirmed_account":          "Akun Anda telah dikonfirmasi!",
+	"auth.flash.confirm_account":            "Silakan konfirmasi akun Anda",
+	"password.flash.send_reset_password":    "Anda akan menerima email berisi petunjuk cara mengatur ulang kata sandi dalam beberapa menit",
+	"password.flash.changed_password":       "Kata sandi Anda telah diubah!",
+	"phone.flash.resubmit_phone_number":     "Silakan kirim ulang nomor telepon Anda",
+	"authority.flash.access_denied":         "Akses ditolak!",
+	"auth.flash.account_deletion_scheduled": "Akun Anda akan dihapus, Anda dapat membatalkannya sebelum itu",
+	"auth.flash.account_deletion_canceled":  "Akun Anda tidak akan dihapus",
+	"auth.flash.account_deleted":            "Akun Anda telah dihapus",
+	"auth.flash.linked":                     "Akun Anda telah ditautkan",
+	"auth.flash.second_factor_verified":     "Identitas Anda telah diverifikasi",
+
+	// sms
+	"phone.token_message":       "kode Anda adalah {token}",
+	"phone.token_message.voice": "Kode Anda adalah {spoken_token}. Sekali lagi, kode Anda adalah {spoken_token}.",
+
+	// mails
+	"auth.mail.confirmation.subject":     "Silakan konfirmasi akun Anda",
+	"auth.mail.welcome.subject":          "Selamat datang",
+	"auth.mail.reset_password.subject":   "Atur ulang kata sandi Anda",
+	"auth.mail.password_changed.subject": "Kata sandi Anda telah diubah",
+	"auth.mail.phone_token.subject":      "Kode verifikasi Anda",
+
+	"auth.mail.confirmation.body":       "Silakan klik tautan di bawah ini untuk memvalidasi alamat email Anda:",
+	"auth.mail.welcome.body":            "Selamat datang! Akun Anda telah dibuat.",
+	"auth.mail.welcome.sign_in":         "Anda dapat masuk melalui tautan di bawah ini.",
+	"auth.mail.reset_password.body":     "Seseorang telah meminta tautan untuk mengubah kata sandi Anda. Anda dapat melakukannya melalui tautan di bawah ini.",
+	"auth.mail.reset_password.ignore":   "Jika Anda tidak memintanya, abaikan email ini.",
+	"auth.mail.reset_password.note":     "Kata sandi Anda tidak akan berubah sampai Anda membuka tautan di atas dan membuat yang baru.",
+	"auth.mail.password_changed.body":   "Kata sandi akun Anda baru saja diubah.",
+	"auth.mail.password_changed.notice": "Jika Anda tidak melakukannya, segera atur ulang kata sandi Anda melalui tautan di bawah ini.",
+	"auth.mail.phone_token.ignore":      "Jika Anda tidak memintanya, abaikan email ini.",
+
+	// admin
+	"admin.flash.confirm":                     "Identitas telah dikonfirmasi",
+	"admin.flash.unconfirm":                   "Konfirmasi identitas telah dibatalkan",
+	"admin.flash.lock":                        "Identitas telah dikunci",
+	"admin.flash.unlock":                      "Kunci identitas telah dibuka",
+	"admin.flash.reset_password":              "Kata sandi telah diatur ulang, email berisi petunjuk atur ulang kata sandi telah dikirim",
+	"admin.flash.revoke_sessions":             "Semua sesi telah dicabut",
+	"admin.flash.delete":                      "Identitas telah dihapus",
+	"admin.flash.impersonate":                 "Anda sekarang masuk sebagai pengguna ini",
+	"admin.flash.stop_impersonating":          "Anda telah kembali ke akun Anda",
+	"admin.errors.already_impersonating":      "Anda sudah masuk sebagai pengguna lain",
+	"admin.errors.not_impersonating":          "Anda tidak sedang masuk sebagai pengguna lain",
+	"admin.errors.identity_not_found":         "Identitas tidak ditemukan",
+	"admin.errors.unknown_action":             "Tindakan tidak dikenal",
+	"admin.errors.password_reset_unsupported": "Kata sandi hanya dapat diatur ulang untuk identitas kata sandi",
+
+	// views
+	"auth.login.title":                 "Masuk",
+	"auth.register.title":              "Daftar",
+	"auth.links.sign_in":               "Masuk",
+	"auth.links.sign_up":               "Daftar",
+	"auth.links.github":                "Masuk dengan Github",
+	"auth.links.google":                "Masuk dengan Google",
+	"auth.links.facebook":              "Masuk dengan Facebook",
+	"auth.links.twitter":               "Masuk dengan Twitter",
+	"auth.form.login":                  "Email",
+	"auth.form.email":                  "Email",
+	"auth.form.submit":                 "Kirim",
+	"phone.links.sign_in":              "Masuk dengan Nomor Ponsel",
+	"phone.links.sign_up":              "Daftar dengan Nomor Ponsel",
+	"phone.form.phone_number":          "Nomor Telepon",
+	"phone.form.token":                 "Kode",
+	"phone.form.channel":               "Kirim kode melalui",
+	"phone.confirmation.title":         "Masukkan kode Anda",
+	"phone.confirmation.sent_with":     "Kode Anda telah dikirim ke",
+	"phone.confirmation.submit":        "Konfirmasi",
+	"phone.confirmation.resend":        "Kirim ulang kode",
+	"phone.confirmation.send_with":     "Kirim kode melalui",
+	"phone.channels.sms":               "SMS",
+	"phone.channels.voice":             "Panggilan suara",
+	"phone.channels.email":             "Email",
+	"phone.channels.whatsapp":          "WhatsApp",
+	"password.form.password":           "Kata Sandi",
+	"password.form.new":                "Kata Sandi Baru",
+	"password.form.current":            "Kata Sandi Saat Ini",
+	"password.form.confirm_new":        "Konfirmasi Kata Sandi Baru",
+	"password.links.forgot":            "lupa kata sandi?",
+	"password.confirmation.new":        "Kirim Ulang Konfirmasi",
+	"password.edit.title":              "Ubah kata sandi Anda",
+	"password.new.title":               "Lupa kata sandi Anda?",
+	"auth.account.delete.title":        "Hapus akun Anda",
+	"auth.account.export":              "Unduh data Anda",
+	"auth.account.delete.scheduled":    "Akun Anda akan dihapus pada",
+	"auth.account.delete.cancel":       "Pertahankan akun saya",
+	"auth.account.delete.notice":       "Semua data Anda akan dihapus, tindakan ini tidak dapat dibatalkan.",
+	"auth.account.delete.submit":       "Hapus akun saya",
+	"authority.access_denied.title":    "Akses Ditolak",
+	"authority.access_denied.notice":   "Anda tidak memiliki izin untuk mengakses halaman ini.",
+	"authority.links.home":             "Kembali ke beranda",
+	"admin.identities.title":           "Pengguna",
+	"admin.identities.search":          "Cari berdasarkan login atau ID pengguna",
+	"admin.identities.all_providers":   "Semua penyedia",
+	"admin.identities.submit":          "Cari",
+	"admin.identities.total":           "Total",
+	"admin.identity.provider":          "Penyedia",
+	"admin.identity.uid":               "Login",
+	"admin.identity.user_id":           "ID Pengguna",
+	"admin.identity.created_at":        "Dibuat Pada",
+	"admin.identity.confirmed_at":      "Dikonfirmasi Pada",
+	"admin.identity.locked_at":         "Dikunci Pada",
+	"admin.identity.sign_in_count":     "Jumlah Masuk",
+	"admin.identity.sign_logs":         "Riwayat masuk",
+	"admin.identity.linked_identities": "Identitas tertaut",
+	"admin.identity.user":              "Pengguna",
+	"admin.identity.audit_logs":        "Log audit",
+	"admin.sign_log.at":                "Waktu",
+	"admin.sign_log.ip":                "IP",
+	"admin.sign_log.user_agent":        "User Agent",
+	"admin.audit_log.at":               "Waktu",
+	"admin.audit_log.action":           "Tindakan",
+	"admin.audit_log.actor":            "Oleh",
+	"admin.actions.confirm":            "Konfirmasi",
+	"admin.actions.unconfirm":          "Batalkan konfirmasi",
+	"admin.actions.lock":               "Kunci",
+	"admin.actions.unlock":             "Buka kunci",
+	"admin.actions.reset_password":     "Paksa atur ulang kata sandi",
+	"admin.actions.revoke_sessions":    "Cabut sesi",
+	"admin.actions.delete":             "Hapus identitas",
+	"admin.actions.impersonate":        "Masuk sebagai pengguna ini",
+	"admin.impersonation.banner":       "Anda masuk sebagai",
+	"admin.impersonation.stop":         "Kembali ke akun Anda",
+	"admin.links.back":                 "Kembali ke daftar pengguna",
+	"admin.links.prev_page":            "Sebelumnya",
+	"admin.links.next_page":            "Berikutnya",
+}
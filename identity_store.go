@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/qor/qor/utils"
+)
+
+// IdentityStore is an interface that defined how to find/save auth identities, all providers access auth identities with it
+type IdentityStore interface {
+	// Find find auth identity with provider and uid, returns ErrIdentityNotFound if not found
+	Find(req *http.Request, provider string, uid string) (*auth_identity.Basic, error)
+	// FindByUserID find user's auth identity of provider, returns ErrIdentityNotFound if not found or userID is blank
+	FindByUserID(req *http.Request, provider string, userID string) (*auth_identity.Basic, error)
+	// Create create auth identity if there is no auth identity with same provider and uid, auth identities are scoped to request's tenant if Auth is multi-tenant, refer TenantResolver
+	Create(req *http.Request, identity *auth_identity.Basic) error
+	// Update save auth identity's encrypted password, user ID, confirmed at and locked at, it is found by provider and uid
+	Update(req *http.Request, identity *auth_identity.Basic) error
+	// Delete delete auth identity with provider and uid
+	Delete(req *http.Request, provider string, uid string) error
+}
+
+// TokenStore is an interface that defined how to find/save tokens sent to users, like phone verification codes, Auth provides a gorm based one `GormTokenStore` (default), a database/sql based one `SQLTokenStore` and an in-memory one `MemoryTokenStore`
+type TokenStore interface {
+	// Find find token of identity, returns ErrTokenNotFound if not found
+	Find(req *http.Request, identity string) (*auth_identity.AuthToken, error)
+	// Save save token, previous token of same identity will be replaced
+	Save(req *http.Request, token *auth_identity.AuthToken) error
+	// Delete delete token of identity
+	Delete(req *http.Request, identity string) error
+}
+
+func identityColumns(identity *auth_identity.Basic) map[string]interface{} {
+	return map[string]interface{}{
+		"encrypted_password": identity.EncryptedPassword,
+		"user_id":            identity.UserID,
+		"confirmed_at":       identity.ConfirmedAt,
+		"locked_at":          identity.LockedAt,
+	}
+}
+
+// TenantConditions add request's tenant into conditions if Auth is multi-tenant, it is saved into column `tenant_id`, refer ScopeTenant
+func (auth *Auth) TenantConditions(req *http.Request, conditions map[string]interface{}) map[string]interface{} {
+	if auth.IsMultiTenant() {
+		conditions["tenant_id"] = auth.GetTenant(req)
+	}
+	return conditions
+}
+
+// GormIdentityStore identity store that saves auth identities with AuthIdentityModel into Auth's DB, auth identities are saved with column `tenant_id` if Auth is multi-tenant
+type GormIdentityStore struct {
+	Auth *Auth
+}
+
+// Find find auth identity with provider and uid
+func (store *GormIdentityStore) Find(req *http.Request, provider string, uid string) (*auth_identity.Basic, error) {
+	return store.find(req, map[string]interface{}{"provider": provider, "uid": uid})
+}
+
+// FindByUserID find user's auth identity of provider
+func (store *GormIdentityStore) FindByUserID(req *http.Request, provider string, userID string) (*auth_identity.Basic, error) {
+	// auth identities without linked user shouldn't be found by blank user ID
+	if userID == "" {
+		return nil, ErrIdentityNotFound
+	}
+	return store.find(req, map[string]interface{}{"provider": provider, "user_id": userID})
+}
+
+func (store *GormIdentityStore) find(req *http.Request, conditions map[string]interface{}) (*auth_identity.Basic, error) {
+	var identity auth_identity.Basic
+
+	scope := store.Auth.GetDB(req).Model(store.Auth.Config.AuthIdentityModel).Where(store.Auth.TenantConditions(req, conditions)).Scan(&identity)
+	if scope.RecordNotFound() {
+		return nil, ErrIdentityNotFound
+	}
+	return &identity, scope.Error
+}
+
+// Create create auth identity if there is no auth identity with same provider and uid
+func (store *GormIdentityStore) Create(req *http.Request, identity *auth_identity.Basic) error {
+	authIdentity := reflect.New(utils.ModelType(store.Auth.Config.AuthIdentityModel)).Interface()
+	identity.TenantID = store.Auth.GetTenant(req)
+
+	return store.Auth.GetDB(req).Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"provider": identity.Provider,
+		"uid":      identity.UID,
+	})).Attrs(identityColumns(identity)).FirstOrCreate(authIdentity).Error
+}
+
+// Update save auth identity's encrypted password, user ID, confirmed at and locked at
+func (store *GormIdentityStore) Update(req *http.Request, identity *auth_identity.Basic) error {
+	authIdentity := reflect.New(utils.ModelType(store.Auth.Config.AuthIdentityModel)).Interface()
+
+	return store.Auth.GetDB(req).Model(authIdentity).Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"provider": identity.Provider,
+		"uid":      identity.UID,
+	})).Updates(identityColumns(identity)).Error
+}
+
+// Delete delete auth identity with provider and uid
+func (store *GormIdentityStore) Delete(req *http.Request, provider string, uid string) error {
+	authIdentity := reflect.New(utils.ModelType(store.Auth.Config.AuthIdentityModel)).Interface()
+
+	return store.Auth.GetDB(req).Unscoped().Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"provider": provider,
+		"uid":      uid,
+	})).Delete(authIdentity).Error
+}
+
+// GormTokenStore token store that saves tokens with UserTokenModel into Auth's DB, tokens are saved with column `tenant_id` if Auth is multi-tenant
+type GormTokenStore struct {
+	Auth *Auth
+}
+
+// Find find token of identity
+func (store *GormTokenStore) Find(req *http.Request, identity string) (*auth_identity.AuthToken, error) {
+	var token auth_identity.AuthToken
+
+	scope := store.Auth.GetDB(req).Model(store.Auth.Config.UserTokenModel).Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"identity": identity,
+	})).Scan(&token)
+	if scope.RecordNotFound() {
+		return nil, ErrTokenNotFound
+	}
+	return &token, scope.Error
+}
+
+// Save save token, previous token of same identity will be replaced
+func (store *GormTokenStore) Save(req *http.Request, token *auth_identity.AuthToken) error {
+	tokenRecord := reflect.New(utils.ModelType(store.Auth.Config.UserTokenModel)).Interface()
+	token.TenantID = store.Auth.GetTenant(req)
+
+	return store.Auth.GetDB(req).Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"identity": token.Identity,
+	})).Assign(map[string]interface{}{
+		"token":       token.Token,
+		"valid_until": token.ValidUntil,
+		"channel":     token.Channel,
+		"attempts":    token.Attempts,
+		"sent_at":     token.SentAt,
+	}).FirstOrCreate(tokenRecord).Error
+}
+
+// Delete delete token of identity
+func (store *GormTokenStore) Delete(req *http.Request, identity string) error {
+	tokenRecord := reflect.New(utils.ModelType(store.Auth.Config.UserTokenModel)).Interface()
+	return store.Auth.GetDB(req).Unscoped().Where(store.Auth.TenantConditions(req, map[string]interface{}{
+		"identity": identity,
+	})).Delete(tokenRecord).Error
+}
+
+// MemoryIdentityStore identity store that keeps auth identities in memory, useful for tests
+type MemoryIdentityStore struct {
+	// Tenant return tenant of request, auth identities are scoped to it, Auth will set it to GetTenant if it is multi-tenant and Tenant is blank
+	Tenant func(req *http.Request) string
+
+	mutex      sync.RWMutex
+	identities map[[3]string]auth_identity.Basic
+}
+
+func (store *MemoryIdentityStore) key(req *http.Request, provider string, uid string) [3]string {
+	if store.Tenant != nil {
+		return [3]string{store.Tenant(req), provider, uid}
+	}
+	return [3]string{"", provider, uid}
+}
+
+// Find find auth identity with provider and uid
+func (store *MemoryIdentityStore) Find(req *http.Request, provider string, uid string) (*auth_identity.Basic, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if identity, ok := store.identities[store.key(req, provider, uid)]; ok {
+		return &identity, nil
+	}
+	return nil, ErrIdentityNotFound
+}
+
+// FindByUserID find user's auth identity of provider
+func (store *MemoryIdentityStore) FindByUserID(req *http.Request, provider string, userID string) (*auth_identity.Basic, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	tenant := store.key(req, provider, "")[0]
+	for key, identity := range store.identities {
+		if key[0] == tenant && identity.Provider == provider && identity.UserID != "" && identity.UserID == userID {
+			return &identity, nil
+		}
+	}
+	return nil, ErrIdentityNotFound
+}
+
+// Create create auth identity if there is no auth identity with same provider and uid
+func (store *MemoryIdentityStore) Create(req *http.Request, identity *auth_identity.Basic) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.identities == nil {
+		store.identities = map[[3]string]auth_identity.Basic{}
+	}
+
+	key := store.key(req, identity.Provider, identity.UID)
+	identity.TenantID = key[0]
+	if _, ok := store.identities[key]; !ok {
+		store.identities[key] = *identity
+	}
+	return nil
+}
+
+// Update save auth identity's encrypted password, user ID, confirmed at and locked at
+func (store *MemoryIdentityStore) Update(req *http.Request, identity *auth_identity.Basic) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key := store.key(req, identity.Provider, identity.UID)
+	if stored, ok := store.identities[key]; ok {
+		identity.TenantID = stored.TenantID
+		store.identities[key] = *identity
+	}
+	return nil
+}
+
+// Delete delete auth identity with provider and uid
+func (store *MemoryIdentityStore) Delete(req *http.Request, provider string, uid string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.identities, store.key(req, provider, uid))
+	return nil
+}
+
+// MemoryTokenStore token store that keeps tokens in memory, useful for tests
+type MemoryTokenStore struct {
+	// Tenant return tenant of request, tokens are scoped to it, Auth will set it to GetTenant if it is multi-tenant and Tenant is blank
+	Tenant func(req *http.Request) string
+
+	mutex  sync.RWMutex
+	tokens map[[2]string]auth_identity.AuthToken
+}
+
+func (store *MemoryTokenStore) key(req *http.Request, identity string) [2]string {
+	if store.Tenant != nil {
+		return [2]string{store.Tenant(req), identity}
+	}
+	return [2]string{"", identity}
+}
+
+// Find find token of identity
+func (store *MemoryTokenStore) Find(req *http.Request, identity string) (*auth_identity.AuthToken, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if token, ok := store.tokens[store.key(req, identity)]; ok {
+		return &token, nil
+	}
+	return nil, ErrTokenNotFound
+}
+
+// Save save token, previous token of same identity will be replaced
+func (store *MemoryTokenStore) Save(req *http.Request, token *auth_identity.AuthToken) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.tokens == nil {
+		store.tokens = map[[2]string]auth_identity.AuthToken{}
+	}
+
+	key := store.key(req, token.Identity)
+	token.TenantID = key[0]
+	store.tokens[key] = *token
+	return nil
+}
+
+// Delete delete token of identity
+func (store *MemoryTokenStore) Delete(req *http.Request, identity string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.tokens, store.key(req, identity))
+	return nil
+}
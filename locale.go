@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+
+	"github.com/fahmibaswara/auth/i18n"
+	"github.com/qor/qor/utils"
+)
+
+// LocaleGetter could be implemented by UserModel to provide user's preferred locale
+type LocaleGetter interface {
+	GetLocale() string
+}
+
+func (auth *Auth) userHasLocale() bool {
+	model := auth.Config.UserModel
+	if model == nil {
+		model = auth.Config.AuthIdentityModel
+	}
+
+	_, ok := reflect.New(utils.ModelType(model)).Interface().(LocaleGetter)
+	return ok
+}
+
+// GetLocale get locale of request, it is current user's preferred locale if UserModel implemented LocaleGetter, or locale saved in cookie, or preferred language in Accept-Language header, which is supported by I18n, it is memoized if the request has cache, refer WithRequestCache
+func (auth *Auth) GetLocale(req *http.Request) string {
+	if req == nil {
+		return auth.I18n.Match()
+	}
+
+	cache := getRequestCache(req)
+	if cache == nil {
+		return auth.resolveLocale(req)
+	}
+
+	cache.mutex.Lock()
+	locale, loaded := cache.locale, cache.localeLoaded
+	cache.mutex.Unlock()
+
+	if !loaded {
+		// resolved without holding the lock, as getting current user locks the cache too
+		locale = auth.resolveLocale(req)
+		cache.mutex.Lock()
+		cache.locale, cache.localeLoaded = locale, true
+		cache.mutex.Unlock()
+	}
+	return locale
+}
+
+func (auth *Auth) resolveLocale(req *http.Request) string {
+	var candidates []string
+
+	if auth.localeFromUser {
+		if user, ok := auth.GetCurrentUser(req).(LocaleGetter); ok && user.GetLocale() != "" {
+			candidates = append(candidates, user.GetLocale())
+		}
+	}
+
+	if cookie, err := req.Cookie(auth.Config.LocaleCookieName); err == nil && cookie.Value != "" {
+		candidates = append(candidates, cookie.Value)
+	}
+
+	candidates = append(candidates, i18n.ParseAcceptLanguage(req.Header.Get("Accept-Language"))...)
+	return auth.I18n.Match(candidates...)
+}
+
+// Translate translate message ID to request's locale, fallback is used if no translation found
+func (auth *Auth) Translate(req *http.Request, id string, fallback string) string {
+	return auth.I18n.Translate(auth.GetLocale(req), id, fallback)
+}
+
+// TranslateError translate error's message to request's locale, unknown errors will be translated as ErrInternal, refer AsError
+func (auth *Auth) TranslateError(req *http.Request, err error) string {
+	e := AsError(err)
+	if e == nil {
+		return ""
+	}
+
+	if e.MessageID == "" {
+		return e.Message
+	}
+	return auth.Translate(req, e.MessageID, e.Message)
+}
+
+// T translate message ID to current locale, could be used in templates like `{{.T "auth.login.title" "Log in"}}`
+func (context Context) T(id string, fallback string) template.HTML {
+	return template.HTML(context.Auth.Translate(context.Request, id, fallback))
+}
+
+// DefaultLocaleHandler save locale from query `locale` into cookie, then redirect back
+var DefaultLocaleHandler = func(context *Context) {
+	locale := context.Auth.I18n.Match(context.Request.URL.Query().Get("locale"))
+
+	http.SetCookie(context.Writer, &http.Cookie{
+		Name:     context.Auth.Config.LocaleCookieName,
+		Value:    locale,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+	})
+	context.Auth.Redirector.Redirect(context.Writer, context.Request, "locale")
+}
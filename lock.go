@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fahmibaswara/auth/claims"
+)
+
+// IsLocked check if claims' auth identity has been locked
+func (auth *Auth) IsLocked(req *http.Request, claims *claims.Claims) bool {
+	if claims.Provider == "" || claims.Id == "" {
+		return false
+	}
+
+	authInfo, err := auth.findAuthInfo(req, claims.Provider, claims.Id)
+	return err == nil && authInfo.LockedAt != nil
+}
+
+// Lock lock claims' auth identity, so it can't be used to sign in, its sessions will be revoked if SessionRevoker configured
+func (auth *Auth) Lock(req *http.Request, claims *claims.Claims) error {
+	now := auth.Now()
+	if err := auth.updateLockedAt(req, claims, &now); err != nil {
+		return err
+	}
+
+	if auth.Config.SessionRevoker != nil {
+		return auth.RevokeSessions(nil, req, claims)
+	}
+	return nil
+}
+
+// Unlock unlock claims' auth identity
+func (auth *Auth) Unlock(req *http.Request, claims *claims.Claims) error {
+	return auth.updateLockedAt(req, claims, nil)
+}
+
+func (auth *Auth) updateLockedAt(req *http.Request, claims *claims.Claims, lockedAt *time.Time) error {
+	auth.InvalidateUser(claims)
+
+	authInfo, err := auth.IdentityStore.Find(req, claims.Provider, claims.Id)
+	if err != nil {
+		return err
+	}
+
+	authInfo.LockedAt = lockedAt
+	return auth.IdentityStore.Update(req, authInfo)
+}
@@ -1,16 +1,10 @@
 package auth
 
 import (
-	"errors"
 	"html/template"
-	"net/mail"
-	"path"
-	"reflect"
-	"time"
+	"net/http"
 
-	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/mailer"
 	"github.com/qor/qor/utils"
 	"github.com/qor/session"
 )
@@ -19,6 +13,9 @@ var (
 	// ConfirmationMailSubject confirmation mail's subject
 	ConfirmationMailSubject = "Please confirm your account"
 
+	// WelcomeMailSubject welcome mail's subject
+	WelcomeMailSubject = "Welcome"
+
 	// ConfirmedAccountFlashMessage confirmed your account message
 	ConfirmedAccountFlashMessage = template.HTML("Confirmed your account!")
 
@@ -26,80 +23,60 @@ var (
 	ConfirmFlashMessage = template.HTML("Please confirm your account")
 
 	// ErrAlreadyConfirmed account already confirmed error
-	ErrAlreadyConfirmed = errors.New("Your account already been confirmed")
+	ErrAlreadyConfirmed = NewError("already_confirmed", http.StatusConflict, "Your account already been confirmed")
 
 	// ErrUnconfirmed unauthorized error
-	ErrUnconfirmed = errors.New("You have to confirm your account before continuing")
+	ErrUnconfirmed = NewError("unconfirmed", http.StatusForbidden, "You have to confirm your account before continuing")
 )
 
 // DefaultConfirmationMailer default confirm mailer
 var DefaultConfirmationMailer = func(email string, context *Context, claim *claims.Claims, currentUser interface{}) error {
-	claim.Subject = "confirm"
-
-	return context.Auth.Mailer.Send(
-		mailer.Email{
-			TO:      []mail.Address{{Address: email}},
-			From:    &mail.Address{Address: "admin@example.org"},
-			Subject: ConfirmationMailSubject,
-		}, mailer.Template{
-			Name:    "auth/confirmation",
-			Data:    context,
-			Request: context.Request,
-			Writer:  context.Writer,
-		}.Funcs(template.FuncMap{
-			"current_user": func() interface{} {
-				return currentUser
-			},
+	token, err := context.Auth.NewActionToken(context.Request, ActionConfirm, claim)
+	if err != nil {
+		return err
+	}
+
+	return context.Auth.SendMail(context, Mail{
+		Name:        "confirmation",
+		Template:    "auth/confirmation",
+		Subject:     ConfirmationMailSubject,
+		To:          email,
+		CurrentUser: currentUser,
+		Funcs: template.FuncMap{
 			"confirm_url": func() string {
-				confirmURL := utils.GetAbsURL(context.Request)
-				confirmURL.Path = path.Join(context.Auth.AuthURL("password/confirm"))
-				qry := confirmURL.Query()
-				qry.Set("token", context.SessionStorer.SignedToken(claim))
-				confirmURL.RawQuery = qry.Encode()
-				return confirmURL.String()
+				return context.Auth.ConfirmURL(context.Request, token)
 			},
-		}))
+		},
+	})
+}
+
+// ConfirmURL generate confirm account URL with token
+func (auth *Auth) ConfirmURL(req *http.Request, token string) string {
+	confirmURL := utils.GetAbsURL(req)
+	confirmURL.Path = auth.RequestAuthURL(req, "password/confirm")
+	qry := confirmURL.Query()
+	qry.Set("token", token)
+	confirmURL.RawQuery = qry.Encode()
+	return confirmURL.String()
 }
 
 // DefaultConfirmHandler default confirm handler
 var DefaultConfirmHandler = func(context *Context) error {
-	var (
-		authInfo auth_identity.Basic
-		tx       = context.Auth.GetDB(context.Request)
-		token    = context.Request.URL.Query().Get("token")
-	)
+	var token = context.Request.URL.Query().Get("token")
 
-	claims, err := context.SessionStorer.ValidateClaims(token)
+	authInfo, err := context.Auth.ConsumeActionToken(context.Request, ActionConfirm, token)
 
 	if err == nil {
-		if err = claims.Valid(); err == nil {
-			// To do dirubah ke Table User yg di confirm
-			authInfo.UID = claims.Id
-			authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-
-			if tx.Where(map[string]interface{}{
-				"provider": authInfo.Provider,
-				"uid":      authInfo.UID,
-			}).First(authIdentity).RecordNotFound() {
-				err = ErrInvalidAccount
-			}
-
-			if err == nil {
-				if authInfo.ConfirmedAt == nil {
-					now := time.Now()
-					authInfo.ConfirmedAt = &now
-					if err = tx.Model(authIdentity).Where(map[string]interface{}{
-						"provider": authInfo.Provider,
-						"uid":      authInfo.UID,
-					}).Update(authInfo).Error; err == nil {
-						context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: ConfirmedAccountFlashMessage, Type: "success"})
-						context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm")
-						return nil
-					}
-				}
-				err = ErrAlreadyConfirmed
+		if authInfo.ConfirmedAt == nil {
+			now := context.Auth.Now()
+			authInfo.ConfirmedAt = &now
+			if err = context.Auth.IdentityStore.Update(context.Request, authInfo); err == nil {
+				context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: context.T("auth.flash.confirmed_account", string(ConfirmedAccountFlashMessage)), Type: "success"})
+				context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm")
 			}
+			return err
 		}
+		err = ErrAlreadyConfirmed
 	}
 
 	return err
@@ -107,29 +84,50 @@ var DefaultConfirmHandler = func(context *Context) error {
 
 // DefaultWelcomeMailer default mailer for welcome message
 var DefaultWelcomeMailer = func(email string, context *Context, claim *claims.Claims, currentUser interface{}) error {
-	claim.Subject = "confirm"
-
-	return context.Auth.Mailer.Send(
-		mailer.Email{
-			TO:      []mail.Address{{Address: email}},
-			From:    &mail.Address{Address: "admin@example.org"},
-			Subject: "Welcome To Our Store",
-		}, mailer.Template{
-			Name:    "auth/confirmation",
-			Data:    context,
-			Request: context.Request,
-			Writer:  context.Writer,
-		}.Funcs(template.FuncMap{
-			"current_user": func() interface{} {
-				return currentUser
+	return context.Auth.SendMail(context, Mail{
+		Name:        "welcome",
+		Template:    "auth/welcome",
+		Subject:     WelcomeMailSubject,
+		To:          email,
+		CurrentUser: currentUser,
+		Funcs: template.FuncMap{
+			"login_url": func() string {
+				loginURL := utils.GetAbsURL(context.Request)
+				loginURL.Path = context.AuthURL("login")
+				return loginURL.String()
 			},
-			"confirm_url": func() string {
-				confirmURL := utils.GetAbsURL(context.Request)
-				confirmURL.Path = path.Join(context.Auth.AuthURL("password/confirm"))
-				qry := confirmURL.Query()
-				qry.Set("token", context.SessionStorer.SignedToken(claim))
-				confirmURL.RawQuery = qry.Encode()
-				return confirmURL.String()
+		},
+	})
+}
+
+func (auth *Auth) registerDefaultMailPreviews() {
+	auth.RegisterMailPreview("confirmation", func(context *Context) Mail {
+		return Mail{
+			Name:     "confirmation",
+			Template: "auth/confirmation",
+			Subject:  ConfirmationMailSubject,
+			To:       "user@example.org",
+			Funcs: template.FuncMap{
+				"confirm_url": func() string {
+					return context.Auth.ConfirmURL(context.Request, "sample-token")
+				},
 			},
-		}))
+		}
+	})
+
+	auth.RegisterMailPreview("welcome", func(context *Context) Mail {
+		return Mail{
+			Name:     "welcome",
+			Template: "auth/welcome",
+			Subject:  WelcomeMailSubject,
+			To:       "user@example.org",
+			Funcs: template.FuncMap{
+				"login_url": func() string {
+					loginURL := utils.GetAbsURL(context.Request)
+					loginURL.Path = context.AuthURL("login")
+					return loginURL.String()
+				},
+			},
+		}
+	})
 }
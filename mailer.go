@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/qor/mailer"
+)
+
+// MailConfig mail config
+type MailConfig struct {
+	// From sender of mails, default is admin@example.org
+	From *mail.Address
+	// ReplyTo reply-to address of mails
+	ReplyTo string
+	// SiteName could be used in subject templates with `{{.SiteName}}`
+	SiteName string
+	// Subjects subject templates with mail name as key, e.g. `"welcome": "Welcome to {{.SiteName}}"`, use `name.locale` as key for locale specific subject, e.g. `welcome.id`
+	Subjects map[string]string
+	// Locale return locale used to choose templates and subjects, by default, it is the request's locale, refer Auth.GetLocale
+	Locale func(*Context) string
+	// Preview enable mail preview pages under `{Auth Prefix}/mailers/preview`, which render each mail with sample data, should only be enabled in development
+	Preview bool
+}
+
+// Mail a mail sent by Auth, it will be rendered with `{Template}.html.tmpl` and `{Template}.text.tmpl` as HTML and plain text parts, locale specific templates like `{Template}.{locale}.html.tmpl` are preferred if exist, messages could be translated to mail's locale with `{{t "message id" "fallback"}}` in templates
+type Mail struct {
+	Name        string
+	Template    string
+	Subject     string
+	To          string
+	CurrentUser interface{}
+	Funcs       template.FuncMap
+}
+
+// MailSubjectData data used to render subject templates
+type MailSubjectData struct {
+	To          string
+	SiteName    string
+	Locale      string
+	CurrentUser interface{}
+}
+
+// DefaultMailLocale use request's locale as mail's locale, refer Auth.GetLocale
+var DefaultMailLocale = func(context *Context) string {
+	return context.Auth.GetLocale(context.Request)
+}
+
+// localeCandidates return locale and its base language, e.g. `id-id`, `id`
+func localeCandidates(locale string) (candidates []string) {
+	if locale == "" {
+		return nil
+	}
+
+	candidates = append(candidates, locale)
+	if idx := strings.IndexAny(locale, "-_"); idx > 0 {
+		candidates = append(candidates, locale[:idx])
+	}
+	return
+}
+
+func (auth *Auth) mailTemplateExists(name string) bool {
+	if auth.Mailer.Config == nil || auth.Mailer.Config.Render == nil {
+		return false
+	}
+
+	for _, ext := range []string{".html.tmpl", ".text.tmpl"} {
+		if _, err := auth.Mailer.Config.Render.Asset(name + ext); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// MailTemplate return template name of mail for locale
+func (auth *Auth) MailTemplate(m Mail, locale string) string {
+	for _, candidate := range localeCandidates(locale) {
+		if name := m.Template + "." + candidate; auth.mailTemplateExists(name) {
+			return name
+		}
+	}
+	return m.Template
+}
+
+// MailSubject render subject of mail for locale, configured Subjects take precedence over translation `auth.mail.{name}.subject`
+func (auth *Auth) MailSubject(m Mail, locale string) (string, error) {
+	var (
+		config  = auth.Config.Mail
+		subject = m.Subject
+	)
+
+	if s, ok := config.Subjects[m.Name]; ok {
+		subject = s
+	} else if s, ok := auth.I18n.Lookup(locale, "auth.mail."+m.Name+".subject"); ok {
+		subject = s
+	}
+
+	candidates := localeCandidates(locale)
+	for idx := len(candidates) - 1; idx >= 0; idx-- {
+		if s, ok := config.Subjects[m.Name+"."+candidates[idx]]; ok {
+			subject = s
+		}
+	}
+
+	tmpl, err := texttemplate.New(m.Name).Parse(subject)
+	if err != nil {
+		return "", err
+	}
+
+	var result bytes.Buffer
+	err = tmpl.Execute(&result, MailSubjectData{To: m.To, SiteName: config.SiteName, Locale: locale, CurrentUser: m.CurrentUser})
+	return result.String(), err
+}
+
+func (auth *Auth) buildMail(context *Context, m Mail) (mailer.Email, mailer.Template, error) {
+	var (
+		config = auth.Config.Mail
+		locale = config.Locale(context)
+	)
+
+	subject, err := auth.MailSubject(m, locale)
+	if err != nil {
+		return mailer.Email{}, mailer.Template{}, err
+	}
+
+	funcs := template.FuncMap{
+		"current_user": func() interface{} {
+			return m.CurrentUser
+		},
+		"mail_locale": func() string {
+			return locale
+		},
+		"t": func(id string, fallback string) template.HTML {
+			return template.HTML(auth.I18n.Translate(locale, id, fallback))
+		},
+	}
+	for key, fc := range m.Funcs {
+		funcs[key] = fc
+	}
+
+	email := mailer.Email{
+		TO:      []mail.Address{{Address: m.To}},
+		From:    config.From,
+		ReplyTo: config.ReplyTo,
+		Subject: subject,
+	}
+
+	tmpl := mailer.Template{
+		Name:    auth.MailTemplate(m, locale),
+		Data:    context,
+		Request: context.Request,
+		Writer:  context.Writer,
+	}.Funcs(funcs)
+
+	return email, tmpl, nil
+}
+
+// SendMail send mail with configured sender, subject and locale specific templates
+func (auth *Auth) SendMail(context *Context, m Mail) error {
+	email, tmpl, err := auth.buildMail(context, m)
+	if err != nil {
+		return err
+	}
+	return auth.Mailer.Send(email, tmpl)
+}
+
+// RenderMail render mail without sending it
+func (auth *Auth) RenderMail(context *Context, m Mail) (mailer.Email, error) {
+	email, tmpl, err := auth.buildMail(context, m)
+	if err != nil {
+		return email, err
+	}
+
+	rendered := auth.Mailer.Render(tmpl)
+	email.HTML, email.Text = rendered.HTML, rendered.Text
+	return email, nil
+}
+
+// RegisterMailPreview register sample mail used in mail preview pages
+func (auth *Auth) RegisterMailPreview(name string, sample func(*Context) Mail) {
+	if auth.mailPreviews == nil {
+		auth.mailPreviews = map[string]func(*Context) Mail{}
+	}
+	auth.mailPreviews[name] = sample
+}
+
+// DefaultMailPreviewHandler render registered sample mails, `{Auth Prefix}/mailers/preview` lists all mails, `{Auth Prefix}/mailers/preview/{name}` renders a mail, use `?format=text` to render plain text part
+var DefaultMailPreviewHandler = func(context *Context) {
+	var (
+		w    = context.Writer
+		req  = context.Request
+		name = context.Param("name")
+	)
+
+	if !context.Auth.Config.Mail.Preview {
+		http.NotFound(w, req)
+		return
+	}
+
+	if name == "" {
+		var names []string
+		for name := range context.Auth.mailPreviews {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<ul>")
+		for _, name := range names {
+			fmt.Fprintf(w, `<li><a href="%v">%v</a> (<a href="%v?format=text">text</a>)</li>`, context.AuthURL("mailers/preview/"+name), template.HTMLEscapeString(name), context.AuthURL("mailers/preview/"+name))
+		}
+		fmt.Fprint(w, "</ul>")
+		return
+	}
+
+	sample, ok := context.Auth.mailPreviews[name]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	email, err := context.Auth.RenderMail(context, sample(context))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if req.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "Subject: %v\nFrom: %v\nReply-To: %v\n\n%v", email.Subject, email.From, email.ReplyTo, email.Text)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<p><b>Subject:</b> %v<br><b>From:</b> %v<br><b>Reply-To:</b> %v</p><hr>%v",
+		template.HTMLEscapeString(email.Subject), template.HTMLEscapeString(fmt.Sprint(email.From)), template.HTMLEscapeString(email.ReplyTo), email.HTML)
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/fahmibaswara/auth/i18n"
+)
+
+func TestMailSubject(t *testing.T) {
+	cases := []struct {
+		subjects map[string]string
+		locale   string
+		subject  string
+	}{
+		{locale: "en", subject: "Welcome"},
+		{locale: "id", subject: "Selamat datang"},
+		{subjects: map[string]string{"welcome": "Welcome to {{.SiteName}}"}, locale: "en", subject: "Welcome to Qor"},
+		{subjects: map[string]string{"welcome": "Welcome to {{.SiteName}}"}, locale: "id", subject: "Welcome to Qor"},
+		{subjects: map[string]string{"welcome": "Welcome to {{.SiteName}}", "welcome.id": "Selamat datang di {{.SiteName}}"}, locale: "id-ID", subject: "Selamat datang di Qor"},
+		{subjects: map[string]string{"welcome.id": "Selamat datang di {{.SiteName}}"}, locale: "en", subject: "Welcome"},
+	}
+
+	for _, c := range cases {
+		auth := &Auth{Config: &Config{I18n: i18n.New("en"), Mail: &MailConfig{SiteName: "Qor", Subjects: c.subjects}}}
+
+		if subject, err := auth.MailSubject(Mail{Name: "welcome", Subject: "Welcome"}, c.locale); err != nil || subject != c.subject {
+			t.Errorf("subject of %v with %v should be %v, got %v, %v", c.locale, c.subjects, c.subject, subject, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+	"github.com/qor/responder"
+)
+
+// CurrentClaims context key to get current session's claims from Request
+const CurrentClaims utils.ContextKey = "current_claims"
+
+// MiddlewareConfig auth middleware config
+type MiddlewareConfig struct {
+	// SkipUser only save claims into request's context, current user won't be loaded
+	SkipUser bool
+	// Required requests that not signed in will be handled by UnauthenticatedHandler
+	Required bool
+	// UnauthenticatedHandler handle requests that not signed in if Required, default is Auth's DefaultUnauthenticatedHandler
+	UnauthenticatedHandler func(w http.ResponseWriter, req *http.Request)
+}
+
+// ClaimsFromContext get claims saved by auth middleware from context
+func ClaimsFromContext(ctx context.Context) *claims.Claims {
+	claims, _ := ctx.Value(CurrentClaims).(*claims.Claims)
+	return claims
+}
+
+// UserFromContext get current user saved by auth middleware from context
+func UserFromContext(ctx context.Context) interface{} {
+	return ctx.Value(CurrentUser)
+}
+
+// WithClaims return a copy of context with claims
+func WithClaims(ctx context.Context, claims *claims.Claims) context.Context {
+	return context.WithValue(ctx, CurrentClaims, claims)
+}
+
+// WithUser return a copy of context with current user
+func WithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, CurrentUser, user)
+}
+
+// Middleware auth middleware, validates session once, saves claims and current user into request's context, get them with ClaimsFromContext, UserFromContext or GetCurrentUser
+func (auth *Auth) Middleware(handler http.Handler) http.Handler {
+	return auth.NewMiddleware(nil)(handler)
+}
+
+// NewMiddleware new auth middleware with config, e.g. require requests signed in
+func (auth *Auth) NewMiddleware(config *MiddlewareConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &MiddlewareConfig{}
+	}
+
+	if config.UnauthenticatedHandler == nil {
+		config.UnauthenticatedHandler = auth.DefaultUnauthenticatedHandler
+	}
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req = WithRequestCache(req)
+			ctx := req.Context()
+
+			claims, err := auth.Get(req)
+			if err == nil {
+				ctx = WithClaims(ctx, claims)
+
+				if !config.SkipUser {
+					if currentUser := auth.GetCurrentUser(req); currentUser != nil {
+						ctx = WithUser(ctx, currentUser)
+					} else {
+						err = ErrInvalidAccount
+					}
+				}
+			}
+
+			if err != nil && config.Required {
+				config.UnauthenticatedHandler(w, req)
+				return
+			}
+
+			handler.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// DefaultUnauthenticatedHandler default handler for requests that not signed in, responds 401 with `WWW-Authenticate` header for JSON requests, and redirects others to login page with current URL as `return_to`
+func (auth *Auth) DefaultUnauthenticatedHandler(w http.ResponseWriter, req *http.Request) {
+	responder.With("html", func() {
+		returnTo := req.URL.RequestURI()
+		if req.RequestURI != "" {
+			// requests' paths might be stripped by routers or TenantMiddleware, redirect back to the original one
+			returnTo = req.RequestURI
+		}
+
+		values := url.Values{"return_to": {returnTo}}
+		http.Redirect(w, req, auth.RequestAuthURL(req, "login")+"?"+values.Encode(), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="auth"`)
+		auth.WriteError(w, req, ErrUnauthorized)
+	}).Respond(req)
+}
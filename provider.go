@@ -2,19 +2,35 @@ package auth
 
 import "fmt"
 
-// Provider define Provider interface
+// Provider define Provider interface, providers implement optional interfaces for features they support, like Registerer, Logouter, CallbackHandler, HTTPHandler, Linker, TokenRefresher, SecondFactor, ProfileSyncer, RouteRegistrar, MetadataProvider
 type Provider interface {
 	GetName() string
 
 	ConfigAuth(*Auth)
 	Login(*Context)
-	Logout(*Context)
+}
+
+// Registerer providers support registration, route `{name}/register` is added for them
+type Registerer interface {
 	Register(*Context)
+}
+
+// Logouter providers need to do their own logout, other providers logout with Auth's LogoutHandler
+type Logouter interface {
+	Logout(*Context)
+}
+
+// CallbackHandler providers handle callbacks, like OAuth providers, route `{name}/callback` is added for them
+type CallbackHandler interface {
 	Callback(*Context)
+}
+
+// HTTPHandler providers serve paths under `{name}/` that are not matched by other routes, prefer RouteRegistrar
+type HTTPHandler interface {
 	ServeHTTP(*Context)
 }
 
-// RegisterProvider register auth provider
+// RegisterProvider register auth provider, routes `{name}/login`, `{name}/logout` are added for the provider, and routes of optional interfaces it implemented, like `{name}/register` for Registerer, routes of RouteRegistrar
 func (auth *Auth) RegisterProvider(provider Provider) {
 	name := provider.GetName()
 	for _, p := range auth.providers {
@@ -24,7 +40,11 @@ func (auth *Auth) RegisterProvider(provider Provider) {
 		}
 	}
 
-	provider.ConfigAuth(auth)
+	auth.addProviderRoutes(provider)
+	auth.configProvider(provider)
+	if registrar, ok := provider.(RouteRegistrar); ok {
+		registrar.RegisterRoutes(auth)
+	}
 	auth.providers = append(auth.providers, provider)
 }
 
@@ -45,3 +65,15 @@ func (auth *Auth) GetProviders() (providers []Provider) {
 	}
 	return
 }
+
+// configProvider run provider's ConfigAuth once for providers with same name, like providers of tenants, as it registers view paths, func maps, account handlers into Auth
+func (auth *Auth) configProvider(provider Provider) {
+	if auth.configured == nil {
+		auth.configured = map[string]bool{}
+	}
+
+	if name := provider.GetName(); !auth.configured[name] {
+		auth.configured[name] = true
+		provider.ConfigAuth(auth)
+	}
+}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/responder"
+	"github.com/qor/session"
+)
+
+var (
+	// LinkedFlashMessage identity linked flash message
+	LinkedFlashMessage = template.HTML("Your account has been linked")
+	// SecondFactorVerifiedFlashMessage second factor verified flash message
+	SecondFactorVerifiedFlashMessage = template.HTML("Your identity has been verified")
+	// LinkStateTTL how long a state issued by LinkState is valid
+	LinkStateTTL = 10 * time.Minute
+)
+
+// Linker providers could link their identities to current user, like connecting GitHub account to user logged in with password, route `POST {name}/link` is added for them, it is only accessible for logged users, refer LinkState, LinkIdentity, RespondLink
+type Linker interface {
+	Link(*Context)
+}
+
+// TokenRefresher providers could refresh access tokens they issued, like OAuth providers, refer Auth's RefreshProviderToken
+type TokenRefresher interface {
+	RefreshToken(context *Context, token *ProviderToken) (*ProviderToken, error)
+}
+
+// SecondFactor providers could verify logged users as second factor, like sending code to user's phone, routes `{name}/second_factor` (send challenge) and `{name}/second_factor/verify` are added for them, refer Auth's ChallengeSecondFactor, VerifySecondFactor
+type SecondFactor interface {
+	// ChallengeSecondFactor start verification of user, like send code to user's phone
+	ChallengeSecondFactor(context *Context, claims *claims.Claims) error
+	// VerifySecondFactor verify user's answer of the challenge, like submitted code
+	VerifySecondFactor(context *Context, claims *claims.Claims) error
+}
+
+// ProfileSyncer providers could fetch latest profile of user with access tokens, refer Auth's SyncProfile
+type ProfileSyncer interface {
+	FetchProfile(context *Context, token *ProviderToken) (*Schema, error)
+}
+
+// RouteRegistrar providers register their own routes, it is called after ConfigAuth when register provider
+type RouteRegistrar interface {
+	RegisterRoutes(*Auth)
+}
+
+// MetadataProvider providers describe themselves for login pages, docs, refer Auth's GetProviderMetadata
+type MetadataProvider interface {
+	Metadata() ProviderMetadata
+}
+
+// ProviderMetadata description of provider
+type ProviderMetadata struct {
+	// DisplayName name shown to users, like `GitHub`, default is provider's name with first letter in upper case
+	DisplayName string `json:"display_name"`
+	// Kind kind of provider, like `oauth`, `password`, `phone`
+	Kind string `json:"kind,omitempty"`
+	// Icon URL of provider's icon
+	Icon string `json:"icon,omitempty"`
+}
+
+// ProviderToken access token issued by provider, like OAuth tokens, refer Config's ProviderTokenHandler
+type ProviderToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// ProfileUpdater UserStorer could implement it to update user's profile synced from providers, refer Auth's SyncProfile
+type ProfileUpdater interface {
+	UpdateProfile(user interface{}, schema *Schema, context *Context) error
+}
+
+// addProviderRoutes add routes of provider's optional interfaces
+func (auth *Auth) addProviderRoutes(provider Provider) {
+	name := provider.GetName()
+
+	auth.AddRoute(Route{Path: name + "/login", Provider: name, Description: "login with " + name, Handler: func(context *Context) { context.Provider.Login(context) }})
+	auth.AddRoute(Route{Path: name + "/logout", Provider: name, Description: "logout from " + name, Handler: func(context *Context) {
+		if logouter, ok := context.Provider.(Logouter); ok {
+			logouter.Logout(context)
+			return
+		}
+		context.Auth.LogoutHandler(context)
+	}})
+
+	if _, ok := provider.(Registerer); ok {
+		auth.AddRoute(Route{Path: name + "/register", Provider: name, Description: "register with " + name, Handler: func(context *Context) { context.Provider.(Registerer).Register(context) }})
+	}
+
+	if _, ok := provider.(CallbackHandler); ok {
+		auth.AddRoute(Route{Path: name + "/callback", Provider: name, Description: "callback of " + name, Handler: func(context *Context) { context.Provider.(CallbackHandler).Callback(context) }})
+	}
+
+	if _, ok := provider.(HTTPHandler); ok {
+		auth.AddRoute(Route{Path: name + "/*", Provider: name, Handler: func(context *Context) { context.Provider.(HTTPHandler).ServeHTTP(context) }})
+	}
+
+	if _, ok := provider.(Linker); ok {
+		auth.AddRoute(Route{Method: "POST", Path: name + "/link", Provider: name, Description: "link " + name + " account to current user", Handler: func(context *Context) {
+			if !loadClaims(context) {
+				context.Auth.RespondLink(context, ErrUnauthorized)
+				return
+			}
+			context.Provider.(Linker).Link(context)
+		}})
+	}
+
+	if _, ok := provider.(SecondFactor); ok {
+		auth.AddRoute(Route{Method: "POST", Path: name + "/second_factor", Provider: name, Description: "send second factor challenge with " + name, Handler: func(context *Context) {
+			var err error = ErrUnauthorized
+			if loadClaims(context) {
+				err = context.Auth.ChallengeSecondFactor(context, name, context.Claims)
+			}
+			respondSecondFactor(context, "second_factor_challenge", err)
+		}})
+
+		auth.AddRoute(Route{Method: "POST", Path: name + "/second_factor/verify", Provider: name, Description: "verify second factor with " + name, Handler: func(context *Context) {
+			var err error = ErrUnauthorized
+			if loadClaims(context) {
+				err = context.Auth.VerifySecondFactor(context, name, context.Claims)
+			}
+			respondSecondFactor(context, "second_factor", err)
+		}})
+	}
+}
+
+// loadClaims load claims of current session into context, returns false if not logged
+func loadClaims(context *Context) bool {
+	if context.Claims == nil {
+		claims, err := context.SessionStorer.Get(context.Request)
+		if err != nil {
+			return false
+		}
+		context.Claims = claims
+	}
+	return context.Claims.UserID != ""
+}
+
+// GetProviderMetadata return provider's metadata, DisplayName is filled if blank
+func (auth *Auth) GetProviderMetadata(provider Provider) ProviderMetadata {
+	var metadata ProviderMetadata
+	if metadataProvider, ok := provider.(MetadataProvider); ok {
+		metadata = metadataProvider.Metadata()
+	}
+
+	if metadata.DisplayName == "" {
+		name := provider.GetName()
+		metadata.DisplayName = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return metadata
+}
+
+// GetProviderCapabilities return optional features supported by provider, like `register`, `link`, `refresh_token`, `second_factor`, `sync_profile`
+func (auth *Auth) GetProviderCapabilities(provider Provider) (capabilities []string) {
+	if _, ok := provider.(Registerer); ok {
+		capabilities = append(capabilities, "register")
+	}
+	if _, ok := provider.(Linker); ok {
+		capabilities = append(capabilities, "link")
+	}
+	if _, ok := provider.(TokenRefresher); ok {
+		capabilities = append(capabilities, "refresh_token")
+	}
+	if _, ok := provider.(SecondFactor); ok {
+		capabilities = append(capabilities, "second_factor")
+	}
+	if _, ok := provider.(ProfileSyncer); ok {
+		capabilities = append(capabilities, "sync_profile")
+	}
+	return
+}
+
+// DefaultProvidersHandler respond registered providers with their metadata, login URL and capabilities as JSON, served at `{Auth Prefix}/providers`, used to render login pages of SPA
+var DefaultProvidersHandler = func(context *Context) {
+	type providerInfo struct {
+		Name string `json:"name"`
+		ProviderMetadata
+		LoginURL     string   `json:"login_url"`
+		Capabilities []string `json:"capabilities"`
+	}
+
+	infos := []providerInfo{}
+	for _, provider := range context.Auth.GetTenantProviders(context.Request) {
+		infos = append(infos, providerInfo{
+			Name:             provider.GetName(),
+			ProviderMetadata: context.Auth.GetProviderMetadata(provider),
+			LoginURL:         context.AuthURL(provider.GetName() + "/login"),
+			Capabilities:     context.Auth.GetProviderCapabilities(provider),
+		})
+	}
+
+	context.Writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(context.Writer).Encode(infos)
+}
+
+// LinkIdentity link identity to current user of context, identity's UserID will be set, returns ErrUnauthorized if not logged, ErrIdentityLinked if the identity is linked to another user
+func (auth *Auth) LinkIdentity(context *Context, identity *auth_identity.Basic) error {
+	if !loadClaims(context) {
+		return ErrUnauthorized
+	}
+
+	existing, err := auth.IdentityStore.Find(context.Request, identity.Provider, identity.UID)
+	if err == nil {
+		if existing.UserID != context.Claims.UserID {
+			return ErrIdentityLinked
+		}
+		*identity = *existing
+		return nil
+	} else if err != ErrIdentityNotFound {
+		return err
+	}
+
+	identity.UserID = context.Claims.UserID
+	return auth.IdentityStore.Create(context.Request, identity)
+}
+
+// LinkState return signed OAuth state to start linking identity to current user, it is bound to current session and expires after LinkStateTTL, returns ErrUnauthorized if not logged
+func (auth *Auth) LinkState(context *Context) (string, error) {
+	if !loadClaims(context) {
+		return "", ErrUnauthorized
+	}
+
+	now := auth.Now()
+	state := claims.Claims{UserID: context.Claims.UserID, TenantID: context.Claims.TenantID}
+	state.Subject = "link"
+	state.Audience = SessionKey(context.Claims)
+	state.ExpiresAt = now.Add(LinkStateTTL).Unix()
+	return auth.SessionStorer.SignedToken(&state), nil
+}
+
+// ValidateLinkState validate state returned by LinkState, returns ErrUnauthorized if it is expired or not issued for current session
+func (auth *Auth) ValidateLinkState(context *Context, state string) error {
+	stateClaims, err := auth.SessionStorer.ValidateClaims(state)
+	if err != nil || stateClaims.Subject != "link" || !stateClaims.VerifyExpiresAt(auth.Now().Unix(), true) {
+		return ErrUnauthorized
+	}
+
+	if !loadClaims(context) || stateClaims.UserID != context.Claims.UserID || stateClaims.Audience != SessionKey(context.Claims) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// RespondLink respond result of linking identity, for html requests, flash the error or success message, and redirect with Redirector's `link` action
+func (auth *Auth) RespondLink(context *Context, err error) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	responder.With("html", func() {
+		if errors.Is(err, ErrUnauthorized) {
+			auth.FlashError(w, req, err)
+			http.Redirect(w, req, context.AuthURL("login"), http.StatusSeeOther)
+			return
+		} else if err != nil {
+			auth.FlashError(w, req, err)
+		} else {
+			auth.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.linked", string(LinkedFlashMessage)), Type: "success"})
+		}
+		auth.Redirector.Redirect(w, req, "link")
+	}).With([]string{"json"}, func() {
+		if err != nil {
+			auth.WriteError(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Respond(req)
+}
+
+// SaveProviderToken pass access token issued by provider to Config's ProviderTokenHandler, it is called by providers after logged or linked
+func (auth *Auth) SaveProviderToken(context *Context, claims *claims.Claims, token *ProviderToken) error {
+	if auth.Config.ProviderTokenHandler == nil || token == nil {
+		return nil
+	}
+	return auth.Config.ProviderTokenHandler(context, claims, token)
+}
+
+// RefreshProviderToken refresh access token of claims' provider, the refreshed token is saved with SaveProviderToken, returns ErrUnsupportedProvider if the provider isn't a TokenRefresher
+func (auth *Auth) RefreshProviderToken(context *Context, claims *claims.Claims, token *ProviderToken) (*ProviderToken, error) {
+	refresher, ok := auth.GetTenantProvider(context.Request, claims.Provider).(TokenRefresher)
+	if !ok {
+		return nil, ErrUnsupportedProvider
+	}
+
+	refreshed, err := refresher.RefreshToken(context, token)
+	if err != nil {
+		return nil, err
+	}
+	return refreshed, auth.SaveProviderToken(context, claims, refreshed)
+}
+
+// SyncProfile fetch latest profile from claims' provider with access token, and update user with UserStorer if it is a ProfileUpdater, returns ErrUnsupportedProvider if the provider isn't a ProfileSyncer
+func (auth *Auth) SyncProfile(context *Context, claims *claims.Claims, token *ProviderToken) (*Schema, error) {
+	syncer, ok := auth.GetTenantProvider(context.Request, claims.Provider).(ProfileSyncer)
+	if !ok {
+		return nil, ErrUnsupportedProvider
+	}
+
+	schema, err := syncer.FetchProfile(context, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if updater, ok := auth.UserStorer.(ProfileUpdater); ok && claims.UserID != "" {
+		user, err := auth.UserStorer.Get(claims, context)
+		if err != nil {
+			return schema, err
+		}
+		return schema, updater.UpdateProfile(user, schema, context)
+	}
+	return schema, nil
+}
+
+// ApplySchema copy schema's non-blank Name, Email, FirstName, LastName, Location, Image, Phone, URL to user's string fields with same names
+func ApplySchema(user interface{}, schema *Schema) {
+	var (
+		userValue   = reflect.Indirect(reflect.ValueOf(user))
+		schemaValue = reflect.ValueOf(schema).Elem()
+	)
+
+	if userValue.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, name := range []string{"Name", "Email", "FirstName", "LastName", "Location", "Image", "Phone", "URL"} {
+		value := schemaValue.FieldByName(name).String()
+		if field := userValue.FieldByName(name); value != "" && field.Kind() == reflect.String && field.CanSet() {
+			field.SetString(value)
+		}
+	}
+}
+
+// ChallengeSecondFactor send second factor challenge with provider to user of claims, returns ErrUnsupportedProvider if the provider isn't a SecondFactor
+func (auth *Auth) ChallengeSecondFactor(context *Context, provider string, claims *claims.Claims) error {
+	secondFactor, ok := auth.GetTenantProvider(context.Request, provider).(SecondFactor)
+	if !ok {
+		return ErrUnsupportedProvider
+	}
+	return secondFactor.ChallengeSecondFactor(context, claims)
+}
+
+// VerifySecondFactor verify user of claims with provider, SecondFactor, SecondFactorAt of current session's claims will be updated if verified, returns ErrUnsupportedProvider if the provider isn't a SecondFactor
+func (auth *Auth) VerifySecondFactor(context *Context, provider string, claims *claims.Claims) error {
+	secondFactor, ok := auth.GetTenantProvider(context.Request, provider).(SecondFactor)
+	if !ok {
+		return ErrUnsupportedProvider
+	}
+
+	if err := secondFactor.VerifySecondFactor(context, claims); err != nil {
+		return err
+	}
+
+	now := auth.Now()
+	claims.SecondFactor = provider
+	claims.SecondFactorAt = &now
+	return auth.Update(context.Writer, context.Request, claims)
+}
+
+func respondSecondFactor(context *Context, action string, err error) {
+	var (
+		w   = context.Writer
+		req = context.Request
+	)
+
+	responder.With("html", func() {
+		if errors.Is(err, ErrUnauthorized) {
+			context.Auth.FlashError(w, req, err)
+			http.Redirect(w, req, context.AuthURL("login"), http.StatusSeeOther)
+			return
+		} else if err != nil {
+			context.Auth.FlashError(w, req, err)
+		} else if action == "second_factor" {
+			context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.second_factor_verified", string(SecondFactorVerifiedFlashMessage)), Type: "success"})
+		}
+		context.Auth.Redirector.Redirect(w, req, action)
+	}).With([]string{"json"}, func() {
+		if err != nil {
+			context.Auth.WriteError(w, req, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Respond(req)
+}
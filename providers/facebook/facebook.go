@@ -5,12 +5,10 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
-	"reflect"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/qor/utils"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/facebook"
 )
@@ -63,11 +61,9 @@ func New(config *Config) *FacebookProvider {
 	if config.AuthorizeHandler == nil {
 		config.AuthorizeHandler = func(context *auth.Context) (*claims.Claims, error) {
 			var (
-				req          = context.Request
-				schema       auth.Schema
-				authInfo     auth_identity.Basic
-				tx           = context.Auth.GetDB(req)
-				authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
+				req      = context.Request
+				schema   auth.Schema
+				authInfo auth_identity.Basic
 			)
 
 			state := req.URL.Query().Get("state")
@@ -86,7 +82,7 @@ func New(config *Config) *FacebookProvider {
 				}
 
 				{
-					resp, err := http.Get("https://graph.facebook.com/me?access_token=" + tkn.AccessToken)
+					resp, err := http.Get(UserInfoURL + tkn.AccessToken)
 					if err != nil {
 						return nil, err
 					}
@@ -108,11 +104,10 @@ func New(config *Config) *FacebookProvider {
 				authInfo.Provider = provider.GetName()
 				authInfo.UID = schema.UID
 
-				if !tx.Model(authIdentity).Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).Scan(&authInfo).RecordNotFound() {
-					return authInfo.ToClaims(), nil
+				if identity, err := context.Auth.IdentityStore.Find(req, authInfo.Provider, authInfo.UID); err == nil {
+					return identity.ToClaims(), nil
+				} else if err != auth.ErrIdentityNotFound {
+					return nil, err
 				}
 
 				if _, userID, err := context.Auth.UserStorer.Save(&schema, context); err == nil {
@@ -123,10 +118,7 @@ func New(config *Config) *FacebookProvider {
 					return nil, err
 				}
 
-				if err = tx.Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).FirstOrCreate(authIdentity).Error; err == nil {
+				if err = context.Auth.IdentityStore.Create(req, &authInfo); err == nil {
 					return authInfo.ToClaims(), nil
 				}
 			}
@@ -165,7 +157,7 @@ func (provider FacebookProvider) OAuthConfig(context *auth.Context) *oauth2.Conf
 			AuthURL:  config.AuthorizeURL,
 			TokenURL: config.TokenURL,
 		},
-		RedirectURL: scheme + context.Request.Host + context.Auth.AuthURL("facebook/callback"),
+		RedirectURL: scheme + context.Request.Host + context.AuthURL("facebook/callback"),
 		Scopes:      config.Scopes,
 	}
 }
@@ -180,10 +172,6 @@ func (provider FacebookProvider) Login(context *auth.Context) {
 	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
 }
 
-// Logout implemented logout with facebook provider
-func (FacebookProvider) Logout(context *auth.Context) {
-}
-
 // Register implemented register with facebook provider
 func (provider FacebookProvider) Register(context *auth.Context) {
 	provider.Login(context)
@@ -194,8 +182,9 @@ func (provider FacebookProvider) Callback(context *auth.Context) {
 	context.Auth.LoginHandler(context, provider.AuthorizeHandler)
 }
 
-// ServeHTTP implement ServeHTTP with facebook provider
-func (FacebookProvider) ServeHTTP(*auth.Context) {
+// Metadata return metadata of facebook provider
+func (FacebookProvider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "Facebook", Kind: "oauth"}
 }
 
 // UserInfo facebook user info structure
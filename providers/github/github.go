@@ -4,19 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"reflect"
+	"net/url"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
 	"github.com/google/go-github/github"
-	"github.com/qor/qor/utils"
 	"golang.org/x/oauth2"
 )
 
 var (
 	AuthorizeURL = "https://github.com/login/oauth/authorize"
 	TokenURL     = "https://github.com/login/oauth/access_token"
+	APIURL       = "https://api.github.com/"
 )
 
 // GithubProvider provide login with github method
@@ -30,6 +30,7 @@ type Config struct {
 	ClientSecret     string
 	AuthorizeURL     string
 	TokenURL         string
+	APIURL           string
 	RedirectURL      string
 	Scopes           []string
 	AuthorizeHandler func(*auth.Context) (*claims.Claims, error)
@@ -58,73 +59,48 @@ func New(config *Config) *GithubProvider {
 		config.TokenURL = TokenURL
 	}
 
+	if config.APIURL == "" {
+		config.APIURL = APIURL
+	}
+
 	if config.AuthorizeHandler == nil {
 		config.AuthorizeHandler = func(context *auth.Context) (*claims.Claims, error) {
 			var (
-				schema       auth.Schema
-				authInfo     auth_identity.Basic
-				authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-				req          = context.Request
-				tx           = context.Auth.GetDB(req)
+				authInfo auth_identity.Basic
+				req      = context.Request
 			)
 
-			state := req.URL.Query().Get("state")
-			claims, err := context.Auth.SessionStorer.ValidateClaims(state)
-
-			if err != nil || claims.Valid() != nil || claims.Subject != "state" {
-				return nil, auth.ErrUnauthorized
+			token, err := provider.exchange(context, "state")
+			if err != nil {
+				return nil, err
 			}
 
-			if err == nil {
-				oauthCfg := provider.OAuthConfig(context)
-				tkn, err := oauthCfg.Exchange(oauth2.NoContext, req.URL.Query().Get("code"))
-
-				if err != nil {
-					return nil, err
-				}
-
-				client := github.NewClient(oauthCfg.Client(oauth2.NoContext, tkn))
-				user, _, err := client.Users.Get(oauth2.NoContext, "")
-				if err != nil {
-					return nil, err
-				}
+			schema, err := provider.FetchProfile(context, token)
+			if err != nil {
+				return nil, err
+			}
 
-				authInfo.Provider = provider.GetName()
-				authInfo.UID = fmt.Sprint(*user.ID)
+			authInfo.Provider = provider.GetName()
+			authInfo.UID = schema.UID
 
-				if !tx.Model(authIdentity).Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).Scan(&authInfo).RecordNotFound() {
-					return authInfo.ToClaims(), nil
-				}
-
-				{
-					schema.Provider = provider.GetName()
-					schema.UID = fmt.Sprint(*user.ID)
-					schema.Name = user.GetName()
-					schema.Email = user.GetEmail()
-					schema.Image = user.GetAvatarURL()
-					schema.RawInfo = user
-				}
-				if _, userID, err := context.Auth.UserStorer.Save(&schema, context); err == nil {
-					if userID != "" {
-						authInfo.UserID = userID
-					}
-				} else {
-					return nil, err
-				}
+			if identity, err := context.Auth.IdentityStore.Find(req, authInfo.Provider, authInfo.UID); err == nil {
+				return identity.ToClaims(), context.Auth.SaveProviderToken(context, identity.ToClaims(), token)
+			} else if err != auth.ErrIdentityNotFound {
+				return nil, err
+			}
 
-				if err = tx.Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).FirstOrCreate(authIdentity).Error; err == nil {
-					return authInfo.ToClaims(), nil
+			if _, userID, err := context.Auth.UserStorer.Save(schema, context); err == nil {
+				if userID != "" {
+					authInfo.UserID = userID
 				}
+			} else {
 				return nil, err
 			}
 
-			return nil, err
+			if err = context.Auth.IdentityStore.Create(req, &authInfo); err != nil {
+				return nil, err
+			}
+			return authInfo.ToClaims(), context.Auth.SaveProviderToken(context, authInfo.ToClaims(), token)
 		}
 	}
 	return provider
@@ -158,7 +134,7 @@ func (provider GithubProvider) OAuthConfig(context *auth.Context) *oauth2.Config
 			AuthURL:  config.AuthorizeURL,
 			TokenURL: config.TokenURL,
 		},
-		RedirectURL: scheme + req.Host + context.Auth.AuthURL("github/callback"),
+		RedirectURL: scheme + req.Host + context.AuthURL("github/callback"),
 		Scopes:      config.Scopes,
 	}
 }
@@ -173,20 +149,107 @@ func (provider GithubProvider) Login(context *auth.Context) {
 	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
 }
 
-// Logout implemented logout with github provider
-func (GithubProvider) Logout(context *auth.Context) {
-}
-
 // Register implemented register with github provider
 func (provider GithubProvider) Register(context *auth.Context) {
 	provider.Login(context)
 }
 
-// Callback implement Callback with github provider
+// Callback implement Callback with github provider, it links github account to current user if started with Link
 func (provider GithubProvider) Callback(context *auth.Context) {
+	if state, err := context.Auth.SessionStorer.ValidateClaims(context.Request.URL.Query().Get("state")); err == nil && state.Subject == "link" {
+		provider.linkCallback(context)
+		return
+	}
 	context.Auth.LoginHandler(context, provider.AuthorizeHandler)
 }
 
-// ServeHTTP implement ServeHTTP with github provider
-func (GithubProvider) ServeHTTP(*auth.Context) {
+// Link link github account to current user, implements auth.Linker
+func (provider GithubProvider) Link(context *auth.Context) {
+	signedToken, err := context.Auth.LinkState(context)
+	if err != nil {
+		context.Auth.RespondLink(context, err)
+		return
+	}
+
+	url := provider.OAuthConfig(context).AuthCodeURL(signedToken)
+	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
+}
+
+func (provider GithubProvider) linkCallback(context *auth.Context) {
+	var (
+		token  *auth.ProviderToken
+		schema *auth.Schema
+		err    = context.Auth.ValidateLinkState(context, context.Request.URL.Query().Get("state"))
+	)
+
+	if err == nil {
+		token, err = provider.exchange(context, "link")
+	}
+	if err == nil {
+		schema, err = provider.FetchProfile(context, token)
+	}
+
+	if err == nil {
+		identity := &auth_identity.Basic{Provider: provider.GetName(), UID: schema.UID}
+		if err = context.Auth.LinkIdentity(context, identity); err == nil {
+			err = context.Auth.SaveProviderToken(context, identity.ToClaims(), token)
+		}
+	}
+
+	context.Auth.RespondLink(context, err)
+}
+
+// exchange validate state of callback, and exchange code to access token
+func (provider GithubProvider) exchange(context *auth.Context, subject string) (*auth.ProviderToken, error) {
+	req := context.Request
+	claims, err := context.Auth.SessionStorer.ValidateClaims(req.URL.Query().Get("state"))
+	if err != nil || claims.Valid() != nil || claims.Subject != subject {
+		return nil, auth.ErrUnauthorized
+	}
+
+	tkn, err := provider.OAuthConfig(context).Exchange(oauth2.NoContext, req.URL.Query().Get("code"))
+	if err != nil {
+		return nil, err
+	}
+	return &auth.ProviderToken{AccessToken: tkn.AccessToken, RefreshToken: tkn.RefreshToken, TokenType: tkn.TokenType, Expiry: tkn.Expiry}, nil
+}
+
+// FetchProfile fetch github user's profile with access token, implements auth.ProfileSyncer
+func (provider GithubProvider) FetchProfile(context *auth.Context, token *auth.ProviderToken) (*auth.Schema, error) {
+	tkn := &oauth2.Token{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken, TokenType: token.TokenType, Expiry: token.Expiry}
+
+	client := github.NewClient(provider.OAuthConfig(context).Client(oauth2.NoContext, tkn))
+	baseURL, err := url.Parse(provider.Config.APIURL)
+	if err != nil {
+		return nil, err
+	}
+	client.BaseURL = baseURL
+
+	user, _, err := client.Users.Get(oauth2.NoContext, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Schema{
+		Provider: provider.GetName(),
+		UID:      fmt.Sprint(*user.ID),
+		Name:     user.GetName(),
+		Email:    user.GetEmail(),
+		Image:    user.GetAvatarURL(),
+		RawInfo:  user,
+	}, nil
+}
+
+// RefreshToken refresh access token with its refresh token, github only issues refresh tokens for GitHub Apps with expiring tokens enabled, implements auth.TokenRefresher
+func (provider GithubProvider) RefreshToken(context *auth.Context, token *auth.ProviderToken) (*auth.ProviderToken, error) {
+	tkn, err := provider.OAuthConfig(context).TokenSource(oauth2.NoContext, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		return nil, err
+	}
+	return &auth.ProviderToken{AccessToken: tkn.AccessToken, RefreshToken: tkn.RefreshToken, TokenType: tkn.TokenType, Expiry: tkn.Expiry}, nil
+}
+
+// Metadata return metadata of github provider
+func (GithubProvider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "GitHub", Kind: "oauth"}
 }
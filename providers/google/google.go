@@ -3,14 +3,11 @@ package google
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"net/http"
-	"reflect"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/qor/utils"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
@@ -63,76 +60,41 @@ func New(config *Config) *GoogleProvider {
 	if config.AuthorizeHandler == nil {
 		config.AuthorizeHandler = func(context *auth.Context) (*claims.Claims, error) {
 			var (
-				req          = context.Request
-				schema       auth.Schema
-				authInfo     auth_identity.Basic
-				tx           = context.Auth.GetDB(req)
-				authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
+				req      = context.Request
+				authInfo auth_identity.Basic
 			)
 
-			state := req.URL.Query().Get("state")
-			claims, err := context.Auth.SessionStorer.ValidateClaims(state)
-
-			if err != nil || claims.Valid() != nil || claims.Subject != "state" {
-				return nil, auth.ErrUnauthorized
+			token, err := provider.exchange(context, "state")
+			if err != nil {
+				return nil, err
 			}
 
-			if err == nil {
-				oauthCfg := provider.OAuthConfig(context)
-				tkn, err := oauthCfg.Exchange(oauth2.NoContext, req.URL.Query().Get("code"))
-
-				if err != nil {
-					return nil, err
-				}
-
-				{
-					client := oauthCfg.Client(oauth2.NoContext, tkn)
-					resp, err := client.Get(UserInfoURL)
-					if err != nil {
-						return nil, err
-					}
-
-					defer resp.Body.Close()
-					body, _ := ioutil.ReadAll(resp.Body)
-					userInfo := UserInfo{}
-					json.Unmarshal(body, &userInfo)
-					schema.Provider = provider.GetName()
-					schema.UID = userInfo.Email
-					schema.Email = userInfo.Email
-					schema.FirstName = userInfo.GivenName
-					schema.LastName = userInfo.FamilyName
-					schema.Image = userInfo.Picture
-					schema.Name = userInfo.Name
-					schema.RawInfo = userInfo
-				}
-
-				authInfo.Provider = provider.GetName()
-				authInfo.UID = schema.UID
+			schema, err := provider.FetchProfile(context, token)
+			if err != nil {
+				return nil, err
+			}
 
-				if !tx.Model(authIdentity).Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).Scan(&authInfo).RecordNotFound() {
-					return authInfo.ToClaims(), nil
-				}
+			authInfo.Provider = provider.GetName()
+			authInfo.UID = schema.UID
 
-				if _, userID, err := context.Auth.UserStorer.Save(&schema, context); err == nil {
-					if userID != "" {
-						authInfo.UserID = userID
-					}
-				} else {
-					return nil, err
-				}
+			if identity, err := context.Auth.IdentityStore.Find(req, authInfo.Provider, authInfo.UID); err == nil {
+				return identity.ToClaims(), context.Auth.SaveProviderToken(context, identity.ToClaims(), token)
+			} else if err != auth.ErrIdentityNotFound {
+				return nil, err
+			}
 
-				if err = tx.Where(map[string]interface{}{
-					"provider": authInfo.Provider,
-					"uid":      authInfo.UID,
-				}).FirstOrCreate(authIdentity).Error; err == nil {
-					return authInfo.ToClaims(), nil
+			if _, userID, err := context.Auth.UserStorer.Save(schema, context); err == nil {
+				if userID != "" {
+					authInfo.UserID = userID
 				}
+			} else {
+				return nil, err
 			}
 
-			return nil, err
+			if err = context.Auth.IdentityStore.Create(req, &authInfo); err != nil {
+				return nil, err
+			}
+			return authInfo.ToClaims(), context.Auth.SaveProviderToken(context, authInfo.ToClaims(), token)
 		}
 	}
 	return provider
@@ -165,7 +127,7 @@ func (provider GoogleProvider) OAuthConfig(context *auth.Context) *oauth2.Config
 			AuthURL:  config.AuthorizeURL,
 			TokenURL: config.TokenURL,
 		},
-		RedirectURL: scheme + context.Request.Host + context.Auth.AuthURL("google/callback"),
+		RedirectURL: scheme + context.Request.Host + context.AuthURL("google/callback"),
 		Scopes:      config.Scopes,
 	}
 }
@@ -180,22 +142,110 @@ func (provider GoogleProvider) Login(context *auth.Context) {
 	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
 }
 
-// Logout implemented logout with google provider
-func (GoogleProvider) Logout(context *auth.Context) {
-}
-
 // Register implemented register with google provider
 func (provider GoogleProvider) Register(context *auth.Context) {
 	provider.Login(context)
 }
 
-// Callback implement Callback with google provider
+// Callback implement Callback with google provider, it links google account to current user if started with Link
 func (provider GoogleProvider) Callback(context *auth.Context) {
+	if state, err := context.Auth.SessionStorer.ValidateClaims(context.Request.URL.Query().Get("state")); err == nil && state.Subject == "link" {
+		provider.linkCallback(context)
+		return
+	}
 	context.Auth.LoginHandler(context, provider.AuthorizeHandler)
 }
 
-// ServeHTTP implement ServeHTTP with google provider
-func (GoogleProvider) ServeHTTP(*auth.Context) {
+// Link link google account to current user, implements auth.Linker
+func (provider GoogleProvider) Link(context *auth.Context) {
+	signedToken, err := context.Auth.LinkState(context)
+	if err != nil {
+		context.Auth.RespondLink(context, err)
+		return
+	}
+
+	url := provider.OAuthConfig(context).AuthCodeURL(signedToken)
+	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
+}
+
+func (provider GoogleProvider) linkCallback(context *auth.Context) {
+	var (
+		token  *auth.ProviderToken
+		schema *auth.Schema
+		err    = context.Auth.ValidateLinkState(context, context.Request.URL.Query().Get("state"))
+	)
+
+	if err == nil {
+		token, err = provider.exchange(context, "link")
+	}
+	if err == nil {
+		schema, err = provider.FetchProfile(context, token)
+	}
+
+	if err == nil {
+		identity := &auth_identity.Basic{Provider: provider.GetName(), UID: schema.UID}
+		if err = context.Auth.LinkIdentity(context, identity); err == nil {
+			err = context.Auth.SaveProviderToken(context, identity.ToClaims(), token)
+		}
+	}
+
+	context.Auth.RespondLink(context, err)
+}
+
+// exchange validate state of callback, and exchange code to access token
+func (provider GoogleProvider) exchange(context *auth.Context, subject string) (*auth.ProviderToken, error) {
+	req := context.Request
+	claims, err := context.Auth.SessionStorer.ValidateClaims(req.URL.Query().Get("state"))
+	if err != nil || claims.Valid() != nil || claims.Subject != subject {
+		return nil, auth.ErrUnauthorized
+	}
+
+	tkn, err := provider.OAuthConfig(context).Exchange(oauth2.NoContext, req.URL.Query().Get("code"))
+	if err != nil {
+		return nil, err
+	}
+	return &auth.ProviderToken{AccessToken: tkn.AccessToken, RefreshToken: tkn.RefreshToken, TokenType: tkn.TokenType, Expiry: tkn.Expiry}, nil
+}
+
+// FetchProfile fetch google user's profile with access token, implements auth.ProfileSyncer
+func (provider GoogleProvider) FetchProfile(context *auth.Context, token *auth.ProviderToken) (*auth.Schema, error) {
+	tkn := &oauth2.Token{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken, TokenType: token.TokenType, Expiry: token.Expiry}
+
+	resp, err := provider.OAuthConfig(context).Client(oauth2.NoContext, tkn).Get(UserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	userInfo := UserInfo{}
+	if err = json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		return nil, err
+	}
+
+	return &auth.Schema{
+		Provider:  provider.GetName(),
+		UID:       userInfo.Email,
+		Email:     userInfo.Email,
+		FirstName: userInfo.GivenName,
+		LastName:  userInfo.FamilyName,
+		Image:     userInfo.Picture,
+		Name:      userInfo.Name,
+		RawInfo:   userInfo,
+	}, nil
+}
+
+// RefreshToken refresh access token with its refresh token, google issues refresh tokens if `access_type=offline` is requested, implements auth.TokenRefresher
+func (provider GoogleProvider) RefreshToken(context *auth.Context, token *auth.ProviderToken) (*auth.ProviderToken, error) {
+	tkn, err := provider.OAuthConfig(context).TokenSource(oauth2.NoContext, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		return nil, err
+	}
+	return &auth.ProviderToken{AccessToken: tkn.AccessToken, RefreshToken: tkn.RefreshToken, TokenType: tkn.TokenType, Expiry: tkn.Expiry}, nil
+}
+
+// Metadata return metadata of google provider
+func (GoogleProvider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "Google", Kind: "oauth"}
 }
 
 // UserInfo google user info structure
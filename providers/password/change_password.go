@@ -0,0 +1,138 @@
+package password
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+	"github.com/qor/responder"
+	"github.com/qor/session"
+)
+
+var (
+	// PasswordChangedMailSubject password changed notification mail's subject
+	PasswordChangedMailSubject = "Your password has been changed"
+
+	// ErrPasswordConfirmationMismatch password confirmation doesn't match error
+	ErrPasswordConfirmationMismatch = &auth.Error{Code: "password_confirmation_mismatch", Status: http.StatusUnprocessableEntity, Message: "Password confirmation doesn't match", MessageID: "password.errors.password_confirmation_mismatch"}
+)
+
+// DefaultPasswordChangedMailer default password changed notification mailer
+var DefaultPasswordChangedMailer = func(email string, context *auth.Context, claims *claims.Claims, currentUser interface{}) error {
+	return context.Auth.SendMail(context, auth.Mail{
+		Name:        "password_changed",
+		Template:    "auth/password_changed",
+		Subject:     PasswordChangedMailSubject,
+		To:          email,
+		CurrentUser: currentUser,
+		Funcs: template.FuncMap{
+			"reset_password_url": func() string {
+				return newPasswordURL(context)
+			},
+		},
+	})
+}
+
+func newPasswordURL(context *auth.Context) string {
+	newPasswordURL := utils.GetAbsURL(context.Request)
+	newPasswordURL.Path = context.AuthURL("password/new")
+	return newPasswordURL.String()
+}
+
+// DefaultChangePasswordHandler default change password handler, used by logged user to change password with current password
+var DefaultChangePasswordHandler = func(context *auth.Context) error {
+	context.Request.ParseForm()
+
+	var (
+		authInfo    *auth_identity.Basic
+		req         = context.Request
+		provider, _ = context.Provider.(*Provider)
+		newPassword = strings.TrimSpace(req.Form.Get("new_password"))
+	)
+
+	claims, err := context.SessionStorer.Get(req)
+	if err != nil {
+		return auth.ErrUnauthorized
+	}
+
+	if claims.IsImpersonating() {
+		return auth.ErrImpersonating
+	}
+
+	// Find password auth identity of current user, current user might logged in with other providers
+	if claims.Provider == provider.GetName() {
+		authInfo, err = context.Auth.IdentityStore.Find(req, provider.GetName(), claims.Id)
+	} else if claims.UserID != "" {
+		authInfo, err = context.Auth.IdentityStore.FindByUserID(req, provider.GetName(), claims.UserID)
+	} else {
+		return auth.ErrInvalidAccount
+	}
+
+	if err == auth.ErrIdentityNotFound {
+		return auth.ErrInvalidAccount
+	} else if err != nil {
+		return err
+	}
+
+	if err = provider.Encryptor.Compare(authInfo.EncryptedPassword, strings.TrimSpace(req.Form.Get("current_password"))); err != nil {
+		return auth.ErrInvalidPassword
+	}
+
+	if confirmation, ok := req.Form["password_confirmation"]; ok && strings.TrimSpace(confirmation[0]) != newPassword {
+		return ErrPasswordConfirmationMismatch
+	}
+
+	if err = provider.ValidatePassword(newPassword, *authInfo, "", context); err != nil {
+		return err
+	}
+
+	if authInfo.EncryptedPassword, err = provider.Encryptor.Digest(newPassword); err != nil {
+		return err
+	}
+
+	if err = context.Auth.IdentityStore.Update(req, authInfo); err != nil {
+		return err
+	}
+	provider.SavePasswordHistory(*authInfo, context)
+
+	if provider.RevokeOtherSessions {
+		if err = context.Auth.RevokeSessions(context.Writer, req, authInfo.ToClaims()); err != nil {
+			return err
+		}
+	}
+
+	if provider.PasswordChangedMailer != nil {
+		currentUser, _ := context.Auth.UserStorer.Get(authInfo.ToClaims(), context)
+		if err = provider.PasswordChangedMailer(authInfo.UID, context, authInfo.ToClaims(), currentUser); err != nil {
+			return err
+		}
+	}
+
+	responder.With("html", func() {
+		context.SessionStorer.Flash(context.Writer, req, session.Message{Message: context.T("password.flash.changed_password", string(ChangedPasswordFlashMessage)), Type: "success"})
+		context.Auth.Redirector.Redirect(context.Writer, req, "change_password")
+	}).With([]string{"json"}, func() {
+		context.Writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(context.Writer).Encode(map[string]string{"message": string(context.T("password.flash.changed_password", string(ChangedPasswordFlashMessage)))})
+	}).Respond(req)
+	return nil
+}
+
+func respondChangePasswordError(err error, context *auth.Context) {
+	var (
+		req = context.Request
+		w   = context.Writer
+	)
+
+	responder.With("html", func() {
+		context.Auth.FlashError(w, req, err)
+		http.Redirect(w, req, context.AuthURL("password/change"), http.StatusSeeOther)
+	}).With([]string{"json"}, func() {
+		context.Auth.WriteError(w, req, err)
+	}).Respond(req)
+}
@@ -1,18 +1,10 @@
 package password
 
 import (
-	"errors"
 	"html/template"
-	"net/mail"
-	"path"
-	"reflect"
-	"time"
 
 	"github.com/fahmibaswara/auth"
-	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/mailer"
-	"github.com/qor/qor/utils"
 	"github.com/qor/session"
 )
 
@@ -27,81 +19,50 @@ var (
 	ConfirmFlashMessage = template.HTML("Please confirm your account")
 
 	// ErrAlreadyConfirmed account already confirmed error
-	ErrAlreadyConfirmed = errors.New("Your account already been confirmed")
+	ErrAlreadyConfirmed = auth.ErrAlreadyConfirmed
 
 	// ErrUnconfirmed unauthorized error
-	ErrUnconfirmed = errors.New("You have to confirm your account before continuing")
+	ErrUnconfirmed = auth.ErrUnconfirmed
 )
 
 // DefaultConfirmationMailer default confirm mailer
 var DefaultConfirmationMailer = func(email string, context *auth.Context, claims *claims.Claims, currentUser interface{}) error {
-	claims.Subject = "confirm"
+	token, err := context.Auth.NewActionToken(context.Request, auth.ActionConfirm, claims)
+	if err != nil {
+		return err
+	}
 
-	return context.Auth.Mailer.Send(
-		mailer.Email{
-			TO:      []mail.Address{{Address: email}},
-			From:    &mail.Address{Address: "admin@example.org"},
-			Subject: ConfirmationMailSubject,
-		}, mailer.Template{
-			Name:    "auth/confirmation",
-			Data:    context,
-			Request: context.Request,
-			Writer:  context.Writer,
-		}.Funcs(template.FuncMap{
-			"current_user": func() interface{} {
-				return currentUser
-			},
+	return context.Auth.SendMail(context, auth.Mail{
+		Name:        "confirmation",
+		Template:    "auth/confirmation",
+		Subject:     ConfirmationMailSubject,
+		To:          email,
+		CurrentUser: currentUser,
+		Funcs: template.FuncMap{
 			"confirm_url": func() string {
-				confirmURL := utils.GetAbsURL(context.Request)
-				confirmURL.Path = path.Join(context.Auth.AuthURL("password/confirm"))
-				qry := confirmURL.Query()
-				qry.Set("token", context.SessionStorer.SignedToken(claims))
-				confirmURL.RawQuery = qry.Encode()
-				return confirmURL.String()
+				return context.Auth.ConfirmURL(context.Request, token)
 			},
-		}))
+		},
+	})
 }
 
 // DefaultConfirmHandler default confirm handler
 var DefaultConfirmHandler = func(context *auth.Context) error {
-	var (
-		authInfo    auth_identity.Basic
-		provider, _ = context.Provider.(*Provider)
-		tx          = context.Auth.GetDB(context.Request)
-		token       = context.Request.URL.Query().Get("token")
-	)
+	var token = context.Request.URL.Query().Get("token")
 
-	claims, err := context.SessionStorer.ValidateClaims(token)
+	authInfo, err := context.Auth.ConsumeActionToken(context.Request, auth.ActionConfirm, token)
 
 	if err == nil {
-		if err = claims.Valid(); err == nil {
-			authInfo.Provider = provider.GetName()
-			authInfo.UID = claims.Id
-			authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-
-			if tx.Where(map[string]interface{}{
-				"provider": authInfo.Provider,
-				"uid":      authInfo.UID,
-			}).First(authIdentity).RecordNotFound() {
-				err = auth.ErrInvalidAccount
-			}
-
-			if err == nil {
-				if authInfo.ConfirmedAt == nil {
-					now := time.Now()
-					authInfo.ConfirmedAt = &now
-					if err = tx.Model(authIdentity).Where(map[string]interface{}{
-						"provider": authInfo.Provider,
-						"uid":      authInfo.UID,
-					}).Update(authInfo).Error; err == nil {
-						context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: ConfirmedAccountFlashMessage, Type: "success"})
-						context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm")
-						return nil
-					}
-				}
-				err = ErrAlreadyConfirmed
+		if authInfo.ConfirmedAt == nil {
+			now := context.Auth.Now()
+			authInfo.ConfirmedAt = &now
+			if err = context.Auth.IdentityStore.Update(context.Request, authInfo); err == nil {
+				context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: context.T("auth.flash.confirmed_account", string(ConfirmedAccountFlashMessage)), Type: "success"})
+				context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm")
 			}
+			return err
 		}
+		err = ErrAlreadyConfirmed
 	}
 
 	return err
@@ -0,0 +1,133 @@
+package argon2_encryptor
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+	"golang.org/x/crypto/argon2"
+)
+
+// Config Argon2Encryptor config, memory is in KiB
+type Config struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	SaltLength int
+	KeyLength  uint32
+}
+
+// Argon2Encryptor argon2id encryptor, hashes are encoded as `$argon2id$v=19$m=65536,t=3,p=2$salt$key`
+type Argon2Encryptor struct {
+	Config *Config
+}
+
+// New initalize Argon2Encryptor
+func New(config *Config) *Argon2Encryptor {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.Time == 0 {
+		config.Time = 3
+	}
+
+	if config.Memory == 0 {
+		config.Memory = 64 * 1024
+	}
+
+	if config.Threads == 0 {
+		config.Threads = 2
+	}
+
+	if config.SaltLength == 0 {
+		config.SaltLength = 16
+	}
+
+	if config.KeyLength == 0 {
+		config.KeyLength = 32
+	}
+
+	return &Argon2Encryptor{
+		Config: config,
+	}
+}
+
+type hash struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func decode(hashedPassword string) (*hash, error) {
+	var (
+		h     hash
+		parts = strings.Split(hashedPassword, "$")
+	)
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &h.version); err != nil {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	// argon2 panics with zero time or threads
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil || h.memory == 0 || h.time == 0 || h.threads == 0 {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	var err error
+	if h.salt, h.key, err = encryptor.DecodeSaltAndKey(parts[4], parts[5]); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Digest generate encrypted password
+func (argon2Encryptor *Argon2Encryptor) Digest(password string) (string, error) {
+	config := argon2Encryptor.Config
+	salt, err := encryptor.GenerateSalt(config.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, config.Time, config.Memory, config.Threads, config.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, config.Memory, config.Time, config.Threads, encryptor.EncodeBase64(salt), encryptor.EncodeBase64(key)), nil
+}
+
+// Compare check hashed password
+func (argon2Encryptor *Argon2Encryptor) Compare(hashedPassword string, password string) error {
+	h, err := decode(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	if h.version != argon2.Version {
+		return encryptor.ErrUnknownHashFormat
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+	if subtle.ConstantTimeCompare(key, h.key) == 1 {
+		return nil
+	}
+	return encryptor.ErrMismatchedHashAndPassword
+}
+
+// Identify check if hashed password is an argon2id hash
+func (argon2Encryptor *Argon2Encryptor) Identify(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$argon2id$")
+}
+
+// NeedsRehash check if hashed password was generated with different parameters
+func (argon2Encryptor *Argon2Encryptor) NeedsRehash(hashedPassword string) bool {
+	config := argon2Encryptor.Config
+	h, err := decode(hashedPassword)
+	return err != nil || h.version != argon2.Version || h.memory != config.Memory || h.time != config.Time ||
+		h.threads != config.Threads || len(h.salt) != config.SaltLength || uint32(len(h.key)) != config.KeyLength
+}
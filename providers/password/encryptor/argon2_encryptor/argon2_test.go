@@ -0,0 +1,45 @@
+package argon2_encryptor
+
+import (
+	"testing"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+func TestCompare(t *testing.T) {
+	e := New(&Config{Time: 1, Memory: 64, Threads: 1})
+	hashedPassword, err := e.Digest("secret")
+	if err != nil {
+		t.Fatalf("failed to digest password, got %v", err)
+	}
+
+	var (
+		salt = encryptor.EncodeBase64(make([]byte, 16))
+		key  = encryptor.EncodeBase64(make([]byte, 32))
+	)
+
+	cases := []struct {
+		name           string
+		hashedPassword string
+		password       string
+		err            error
+	}{
+		{name: "valid password", hashedPassword: hashedPassword, password: "secret"},
+		{name: "invalid password", hashedPassword: hashedPassword, password: "secret1", err: encryptor.ErrMismatchedHashAndPassword},
+		{name: "other format", hashedPassword: "$scrypt$ln=1,r=8,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "other version", hashedPassword: "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty salt", hashedPassword: "$argon2id$v=19$m=64,t=1,p=1$$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "short salt", hashedPassword: "$argon2id$v=19$m=64,t=1,p=1$AAAA$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty key", hashedPassword: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$", err: encryptor.ErrUnknownHashFormat},
+		{name: "short key", hashedPassword: "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$AAAA", err: encryptor.ErrUnknownHashFormat},
+		{name: "zero memory", hashedPassword: "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "zero time", hashedPassword: "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "zero threads", hashedPassword: "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+	}
+
+	for _, c := range cases {
+		if err := e.Compare(c.hashedPassword, c.password); err != c.err {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+	}
+}
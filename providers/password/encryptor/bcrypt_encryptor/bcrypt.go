@@ -1,6 +1,10 @@
 package bcrypt_encryptor
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 // Config BcryptEncryptor config
 type Config struct {
@@ -29,7 +33,7 @@ func New(config *Config) *BcryptEncryptor {
 
 // Digest generate encrypted password
 func (bcryptEncryptor *BcryptEncryptor) Digest(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptEncryptor.Config.Cost)
 	return string(hashedPassword), err
 }
 
@@ -37,3 +41,14 @@ func (bcryptEncryptor *BcryptEncryptor) Digest(password string) (string, error)
 func (bcryptEncryptor *BcryptEncryptor) Compare(hashedPassword string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// Identify check if hashed password is a bcrypt hash
+func (bcryptEncryptor *BcryptEncryptor) Identify(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") || strings.HasPrefix(hashedPassword, "$2b$") || strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+// NeedsRehash check if hashed password was generated with a different cost
+func (bcryptEncryptor *BcryptEncryptor) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != bcryptEncryptor.Config.Cost
+}
@@ -1,7 +1,73 @@
 package encryptor
 
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrMismatchedHashAndPassword returned when password doesn't match hashed password
+var ErrMismatchedHashAndPassword = errors.New("hashed password is not the hash of the given password")
+
+// ErrUnknownHashFormat returned when hashed password's format is not recognized
+var ErrUnknownHashFormat = errors.New("unknown hashed password format")
+
+const (
+	// MinSaltLength minimum length of salts accepted in hashed passwords
+	MinSaltLength = 8
+	// MinKeyLength minimum length of derived keys accepted in hashed passwords, blank or short keys would match any password
+	MinKeyLength = 16
+)
+
 // Interface encryptor interface
 type Interface interface {
 	Digest(password string) (string, error)
 	Compare(hashedPassword string, password string) error
 }
+
+// Identifier could be implemented by encryptors that recognize hashes generated by themselves
+type Identifier interface {
+	Identify(hashedPassword string) bool
+}
+
+// Rehasher could be implemented by encryptors that know whether a hashed password was generated with outdated parameters
+type Rehasher interface {
+	NeedsRehash(hashedPassword string) bool
+}
+
+// NeedsRehash check if hashed password should be digested again with encryptor's current parameters
+func NeedsRehash(encryptor Interface, hashedPassword string) bool {
+	if rehasher, ok := encryptor.(Rehasher); ok {
+		return rehasher.NeedsRehash(hashedPassword)
+	}
+	return false
+}
+
+// GenerateSalt generate random salt with length
+func GenerateSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+// EncodeBase64 encode bytes used in hash formats
+func EncodeBase64(src []byte) string {
+	return base64.RawStdEncoding.EncodeToString(src)
+}
+
+// DecodeBase64 decode bytes used in hash formats
+func DecodeBase64(src string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(src)
+}
+
+// DecodeSaltAndKey decode salt and key of hash formats, returns ErrUnknownHashFormat if they are malformed or shorter than MinSaltLength, MinKeyLength
+func DecodeSaltAndKey(encodedSalt string, encodedKey string) (salt []byte, key []byte, err error) {
+	if salt, err = DecodeBase64(encodedSalt); err != nil || len(salt) < MinSaltLength {
+		return nil, nil, ErrUnknownHashFormat
+	}
+
+	if key, err = DecodeBase64(encodedKey); err != nil || len(key) < MinKeyLength {
+		return nil, nil, ErrUnknownHashFormat
+	}
+	return salt, key, nil
+}
@@ -0,0 +1,77 @@
+package multi_encryptor
+
+import (
+	"errors"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+// Config MultiEncryptor config
+type Config struct {
+	// Current encryptor used to digest new passwords
+	Current encryptor.Interface
+	// Legacy encryptors only used to compare hashes generated by them, they need to implement encryptor.Identifier
+	Legacy []encryptor.Interface
+}
+
+// MultiEncryptor digest passwords with current encryptor, and compare hashes with the encryptor that generated them, used to migrate hashes between algorithms
+type MultiEncryptor struct {
+	Config *Config
+}
+
+// New initalize MultiEncryptor
+func New(config *Config) *MultiEncryptor {
+	if config == nil || config.Current == nil {
+		panic(errors.New("MultiEncryptor's Current encryptor can't be blank"))
+	}
+
+	return &MultiEncryptor{
+		Config: config,
+	}
+}
+
+func identify(e encryptor.Interface, hashedPassword string) bool {
+	if identifier, ok := e.(encryptor.Identifier); ok {
+		return identifier.Identify(hashedPassword)
+	}
+	return false
+}
+
+func (multiEncryptor *MultiEncryptor) find(hashedPassword string) encryptor.Interface {
+	if _, ok := multiEncryptor.Config.Current.(encryptor.Identifier); !ok || identify(multiEncryptor.Config.Current, hashedPassword) {
+		return multiEncryptor.Config.Current
+	}
+
+	for _, legacy := range multiEncryptor.Config.Legacy {
+		if identify(legacy, hashedPassword) {
+			return legacy
+		}
+	}
+	return nil
+}
+
+// Digest generate encrypted password with current encryptor
+func (multiEncryptor *MultiEncryptor) Digest(password string) (string, error) {
+	return multiEncryptor.Config.Current.Digest(password)
+}
+
+// Compare check hashed password with the encryptor that generated it
+func (multiEncryptor *MultiEncryptor) Compare(hashedPassword string, password string) error {
+	if e := multiEncryptor.find(hashedPassword); e != nil {
+		return e.Compare(hashedPassword, password)
+	}
+	return encryptor.ErrUnknownHashFormat
+}
+
+// Identify check if hashed password is generated by any configured encryptor
+func (multiEncryptor *MultiEncryptor) Identify(hashedPassword string) bool {
+	return multiEncryptor.find(hashedPassword) != nil
+}
+
+// NeedsRehash check if hashed password is generated by a legacy encryptor or with outdated parameters
+func (multiEncryptor *MultiEncryptor) NeedsRehash(hashedPassword string) bool {
+	if e := multiEncryptor.find(hashedPassword); e == multiEncryptor.Config.Current {
+		return encryptor.NeedsRehash(e, hashedPassword)
+	}
+	return true
+}
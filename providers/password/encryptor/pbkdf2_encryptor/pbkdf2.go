@@ -0,0 +1,111 @@
+package pbkdf2_encryptor
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Config PBKDF2Encryptor config
+type Config struct {
+	Iterations int
+	SaltLength int
+	KeyLength  int
+}
+
+// PBKDF2Encryptor PBKDF2-SHA256 encryptor, could be used in FIPS environments, hashes are encoded as `$pbkdf2-sha256$i=600000$salt$key`
+type PBKDF2Encryptor struct {
+	Config *Config
+}
+
+// New initalize PBKDF2Encryptor
+func New(config *Config) *PBKDF2Encryptor {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.Iterations == 0 {
+		config.Iterations = 600000
+	}
+
+	if config.SaltLength == 0 {
+		config.SaltLength = 16
+	}
+
+	if config.KeyLength == 0 {
+		config.KeyLength = 32
+	}
+
+	return &PBKDF2Encryptor{
+		Config: config,
+	}
+}
+
+type hash struct {
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+func decode(hashedPassword string) (*hash, error) {
+	var (
+		h     hash
+		parts = strings.Split(hashedPassword, "$")
+	)
+
+	if len(parts) != 5 || parts[1] != "pbkdf2-sha256" {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "i=%d", &h.iterations); err != nil || h.iterations <= 0 {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	var err error
+	if h.salt, h.key, err = encryptor.DecodeSaltAndKey(parts[3], parts[4]); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Digest generate encrypted password
+func (pbkdf2Encryptor *PBKDF2Encryptor) Digest(password string) (string, error) {
+	config := pbkdf2Encryptor.Config
+	salt, err := encryptor.GenerateSalt(config.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, config.Iterations, config.KeyLength, sha256.New)
+	return fmt.Sprintf("$pbkdf2-sha256$i=%d$%s$%s", config.Iterations, encryptor.EncodeBase64(salt), encryptor.EncodeBase64(key)), nil
+}
+
+// Compare check hashed password
+func (pbkdf2Encryptor *PBKDF2Encryptor) Compare(hashedPassword string, password string) error {
+	h, err := decode(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	key := pbkdf2.Key([]byte(password), h.salt, h.iterations, len(h.key), sha256.New)
+	if subtle.ConstantTimeCompare(key, h.key) == 1 {
+		return nil
+	}
+	return encryptor.ErrMismatchedHashAndPassword
+}
+
+// Identify check if hashed password is a PBKDF2-SHA256 hash
+func (pbkdf2Encryptor *PBKDF2Encryptor) Identify(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$pbkdf2-sha256$")
+}
+
+// NeedsRehash check if hashed password was generated with different parameters
+func (pbkdf2Encryptor *PBKDF2Encryptor) NeedsRehash(hashedPassword string) bool {
+	config := pbkdf2Encryptor.Config
+	h, err := decode(hashedPassword)
+	return err != nil || h.iterations != config.Iterations || len(h.salt) != config.SaltLength || len(h.key) != config.KeyLength
+}
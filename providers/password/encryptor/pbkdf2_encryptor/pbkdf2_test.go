@@ -0,0 +1,43 @@
+package pbkdf2_encryptor
+
+import (
+	"testing"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+func TestCompare(t *testing.T) {
+	e := New(&Config{Iterations: 1000})
+	hashedPassword, err := e.Digest("secret")
+	if err != nil {
+		t.Fatalf("failed to digest password, got %v", err)
+	}
+
+	var (
+		salt = encryptor.EncodeBase64(make([]byte, 16))
+		key  = encryptor.EncodeBase64(make([]byte, 32))
+	)
+
+	cases := []struct {
+		name           string
+		hashedPassword string
+		password       string
+		err            error
+	}{
+		{name: "valid password", hashedPassword: hashedPassword, password: "secret"},
+		{name: "invalid password", hashedPassword: hashedPassword, password: "secret1", err: encryptor.ErrMismatchedHashAndPassword},
+		{name: "other format", hashedPassword: "$scrypt$ln=1,r=8,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty salt", hashedPassword: "$pbkdf2-sha256$i=1000$$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "short salt", hashedPassword: "$pbkdf2-sha256$i=1000$AAAA$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty key", hashedPassword: "$pbkdf2-sha256$i=1000$" + salt + "$", err: encryptor.ErrUnknownHashFormat},
+		{name: "short key", hashedPassword: "$pbkdf2-sha256$i=1000$" + salt + "$AAAA", err: encryptor.ErrUnknownHashFormat},
+		{name: "invalid base64", hashedPassword: "$pbkdf2-sha256$i=1000$" + salt + "$!" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "zero iterations", hashedPassword: "$pbkdf2-sha256$i=0$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+	}
+
+	for _, c := range cases {
+		if err := e.Compare(c.hashedPassword, c.password); err != c.err {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package pepper_encryptor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"strings"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+const prefix = "$pepper$id="
+
+// Config PepperEncryptor config
+type Config struct {
+	// Encryptor used to digest peppered passwords, its hashes need to start with `$`
+	Encryptor encryptor.Interface
+	// Peppers server side secrets with their IDs, keep old peppers here until all hashes using them have been rehashed
+	Peppers map[string]string
+	// CurrentPepper ID of pepper used to digest new passwords
+	CurrentPepper string
+	// AllowUnpeppered allow to compare hashes generated before pepper was introduced
+	AllowUnpeppered bool
+}
+
+// PepperEncryptor mix a server side pepper into passwords with HMAC-SHA256 before digest them, hashes are encoded as `$pepper$id=ID` followed by wrapped encryptor's hash
+type PepperEncryptor struct {
+	Config *Config
+}
+
+// New initalize PepperEncryptor
+func New(config *Config) *PepperEncryptor {
+	if config == nil || config.Encryptor == nil {
+		panic(errors.New("PepperEncryptor's Encryptor can't be blank"))
+	}
+
+	if _, ok := config.Peppers[config.CurrentPepper]; !ok {
+		panic(errors.New("PepperEncryptor's CurrentPepper is not found in Peppers"))
+	}
+
+	return &PepperEncryptor{
+		Config: config,
+	}
+}
+
+func (pepperEncryptor *PepperEncryptor) pepper(id string, password string) (string, error) {
+	pepper, ok := pepperEncryptor.Config.Peppers[id]
+	if !ok {
+		return "", encryptor.ErrUnknownHashFormat
+	}
+
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(password))
+	return encryptor.EncodeBase64(mac.Sum(nil)), nil
+}
+
+func decode(hashedPassword string) (id string, hash string, ok bool) {
+	if !strings.HasPrefix(hashedPassword, prefix) {
+		return "", "", false
+	}
+
+	rest := strings.TrimPrefix(hashedPassword, prefix)
+	if idx := strings.Index(rest, "$"); idx > 0 {
+		return rest[:idx], rest[idx:], true
+	}
+	return "", "", false
+}
+
+// Digest generate encrypted password with current pepper
+func (pepperEncryptor *PepperEncryptor) Digest(password string) (string, error) {
+	peppered, err := pepperEncryptor.pepper(pepperEncryptor.Config.CurrentPepper, password)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := pepperEncryptor.Config.Encryptor.Digest(peppered)
+	if err != nil {
+		return "", err
+	}
+	return prefix + pepperEncryptor.Config.CurrentPepper + hash, nil
+}
+
+// Compare check hashed password with the pepper it was generated with
+func (pepperEncryptor *PepperEncryptor) Compare(hashedPassword string, password string) error {
+	id, hash, ok := decode(hashedPassword)
+	if !ok {
+		if pepperEncryptor.Config.AllowUnpeppered {
+			return pepperEncryptor.Config.Encryptor.Compare(hashedPassword, password)
+		}
+		return encryptor.ErrUnknownHashFormat
+	}
+
+	peppered, err := pepperEncryptor.pepper(id, password)
+	if err != nil {
+		return err
+	}
+	return pepperEncryptor.Config.Encryptor.Compare(hash, peppered)
+}
+
+// Identify check if hashed password is a peppered hash
+func (pepperEncryptor *PepperEncryptor) Identify(hashedPassword string) bool {
+	_, _, ok := decode(hashedPassword)
+	return ok
+}
+
+// NeedsRehash check if hashed password is unpeppered, uses an old pepper, or wrapped hash needs rehash
+func (pepperEncryptor *PepperEncryptor) NeedsRehash(hashedPassword string) bool {
+	id, hash, ok := decode(hashedPassword)
+	if !ok || id != pepperEncryptor.Config.CurrentPepper {
+		return true
+	}
+	return encryptor.NeedsRehash(pepperEncryptor.Config.Encryptor, hash)
+}
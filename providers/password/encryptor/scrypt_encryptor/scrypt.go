@@ -0,0 +1,130 @@
+package scrypt_encryptor
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Config ScryptEncryptor config, LogN is the base-2 logarithm of scrypt's CPU/memory cost parameter N
+type Config struct {
+	LogN       uint8
+	R          int
+	P          int
+	SaltLength int
+	KeyLength  int
+}
+
+// ScryptEncryptor scrypt encryptor, hashes are encoded as `$scrypt$ln=15,r=8,p=1$salt$key`
+type ScryptEncryptor struct {
+	Config *Config
+}
+
+// New initalize ScryptEncryptor
+func New(config *Config) *ScryptEncryptor {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.LogN == 0 {
+		config.LogN = 15
+	}
+
+	if config.R == 0 {
+		config.R = 8
+	}
+
+	if config.P == 0 {
+		config.P = 1
+	}
+
+	if config.SaltLength == 0 {
+		config.SaltLength = 16
+	}
+
+	if config.KeyLength == 0 {
+		config.KeyLength = 32
+	}
+
+	return &ScryptEncryptor{
+		Config: config,
+	}
+}
+
+type hash struct {
+	logN uint8
+	r    int
+	p    int
+	salt []byte
+	key  []byte
+}
+
+func decode(hashedPassword string) (*hash, error) {
+	var (
+		h     hash
+		parts = strings.Split(hashedPassword, "$")
+	)
+
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &h.logN, &h.r, &h.p); err != nil || h.logN == 0 || h.logN > 30 || h.r <= 0 || h.p <= 0 {
+		return nil, encryptor.ErrUnknownHashFormat
+	}
+
+	var err error
+	if h.salt, h.key, err = encryptor.DecodeSaltAndKey(parts[3], parts[4]); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Digest generate encrypted password
+func (scryptEncryptor *ScryptEncryptor) Digest(password string) (string, error) {
+	config := scryptEncryptor.Config
+	salt, err := encryptor.GenerateSalt(config.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<config.LogN, config.R, config.P, config.KeyLength)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", config.LogN, config.R, config.P, encryptor.EncodeBase64(salt), encryptor.EncodeBase64(key)), nil
+}
+
+// Compare check hashed password
+func (scryptEncryptor *ScryptEncryptor) Compare(hashedPassword string, password string) error {
+	h, err := decode(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	key, err := scrypt.Key([]byte(password), h.salt, 1<<h.logN, h.r, h.p, len(h.key))
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(key, h.key) == 1 {
+		return nil
+	}
+	return encryptor.ErrMismatchedHashAndPassword
+}
+
+// Identify check if hashed password is a scrypt hash
+func (scryptEncryptor *ScryptEncryptor) Identify(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$scrypt$")
+}
+
+// NeedsRehash check if hashed password was generated with different parameters
+func (scryptEncryptor *ScryptEncryptor) NeedsRehash(hashedPassword string) bool {
+	config := scryptEncryptor.Config
+	h, err := decode(hashedPassword)
+	return err != nil || h.logN != config.LogN || h.r != config.R || h.p != config.P ||
+		len(h.salt) != config.SaltLength || len(h.key) != config.KeyLength
+}
@@ -0,0 +1,44 @@
+package scrypt_encryptor
+
+import (
+	"testing"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+func TestCompare(t *testing.T) {
+	e := New(&Config{LogN: 4})
+	hashedPassword, err := e.Digest("secret")
+	if err != nil {
+		t.Fatalf("failed to digest password, got %v", err)
+	}
+
+	var (
+		salt = encryptor.EncodeBase64(make([]byte, 16))
+		key  = encryptor.EncodeBase64(make([]byte, 32))
+	)
+
+	cases := []struct {
+		name           string
+		hashedPassword string
+		password       string
+		err            error
+	}{
+		{name: "valid password", hashedPassword: hashedPassword, password: "secret"},
+		{name: "invalid password", hashedPassword: hashedPassword, password: "secret1", err: encryptor.ErrMismatchedHashAndPassword},
+		{name: "other format", hashedPassword: "$pbkdf2-sha256$i=1000$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty salt", hashedPassword: "$scrypt$ln=4,r=8,p=1$$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "short salt", hashedPassword: "$scrypt$ln=4,r=8,p=1$AAAA$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "empty key", hashedPassword: "$scrypt$ln=4,r=8,p=1$" + salt + "$", err: encryptor.ErrUnknownHashFormat},
+		{name: "short key", hashedPassword: "$scrypt$ln=4,r=8,p=1$" + salt + "$AAAA", err: encryptor.ErrUnknownHashFormat},
+		{name: "zero cost", hashedPassword: "$scrypt$ln=0,r=8,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "zero block size", hashedPassword: "$scrypt$ln=4,r=0,p=1$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+		{name: "zero parallelism", hashedPassword: "$scrypt$ln=4,r=8,p=0$" + salt + "$" + key, err: encryptor.ErrUnknownHashFormat},
+	}
+
+	for _, c := range cases {
+		if err := e.Compare(c.hashedPassword, c.password); err != c.err {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+	}
+}
@@ -1,8 +1,14 @@
 package password
 
-import "errors"
+import (
+	"net/http"
+
+	"github.com/fahmibaswara/auth"
+)
 
 var (
 	// ErrInvalidResetPasswordToken invalid reset password token
-	ErrInvalidResetPasswordToken = errors.New("Invalid Token")
+	ErrInvalidResetPasswordToken = &auth.Error{Code: "invalid_reset_password_token", Status: http.StatusBadRequest, Message: "Invalid Token", MessageID: "password.errors.invalid_reset_password_token"}
+	// ErrWeakPassword password doesn't meet password policy, its message is policy's failures, refer Provider.ValidatePassword
+	ErrWeakPassword = &auth.Error{Code: "weak_password", Status: http.StatusUnprocessableEntity, Message: "Password is too weak", MessageID: "password.errors.weak_password"}
 )
@@ -1,35 +1,28 @@
 package password
 
 import (
-	"reflect"
 	"strings"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/qor/utils"
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
 	"github.com/qor/session"
 )
 
 // DefaultAuthorizeHandler default authorize handler
 var DefaultAuthorizeHandler = func(context *auth.Context) (*claims.Claims, error) {
 	var (
-		authInfo    auth_identity.Basic
 		req         = context.Request
-		tx          = context.Auth.GetDB(req)
 		provider, _ = context.Provider.(*Provider)
 	)
 
 	req.ParseForm()
-	authInfo.Provider = provider.GetName()
-	authInfo.UID = strings.TrimSpace(req.Form.Get("login"))
-
-	if tx.Model(context.Auth.AuthIdentityModel).Where(
-		map[string]interface{}{
-			"provider": authInfo.Provider,
-			"uid":      authInfo.UID,
-		}).Scan(&authInfo).RecordNotFound() {
+	authInfo, err := context.Auth.IdentityStore.Find(req, provider.GetName(), strings.TrimSpace(req.Form.Get("login")))
+	if err == auth.ErrIdentityNotFound {
 		return nil, auth.ErrInvalidAccount
+	} else if err != nil {
+		return nil, err
 	}
 
 	if context.Auth.Config.Confirmable && authInfo.ConfirmedAt == nil {
@@ -39,11 +32,23 @@ var DefaultAuthorizeHandler = func(context *auth.Context) (*claims.Claims, error
 		return nil, ErrUnconfirmed
 	}
 
-	if err := provider.Encryptor.Compare(authInfo.EncryptedPassword, strings.TrimSpace(req.Form.Get("password"))); err == nil {
-		return authInfo.ToClaims(), err
+	password := strings.TrimSpace(req.Form.Get("password"))
+	if err = provider.Encryptor.Compare(authInfo.EncryptedPassword, password); err != nil {
+		return nil, auth.ErrInvalidPassword
 	}
 
-	return nil, auth.ErrInvalidPassword
+	// Upgrade encrypted password to current encryptor's parameters, user could still sign in with the old one if it failed
+	if encryptor.NeedsRehash(provider.Encryptor, authInfo.EncryptedPassword) {
+		if encryptedPassword, err := provider.Encryptor.Digest(password); err != nil {
+			context.Auth.LogError(req, err)
+		} else {
+			authInfo.EncryptedPassword = encryptedPassword
+			if err = context.Auth.IdentityStore.Update(req, authInfo); err != nil {
+				context.Auth.LogError(req, err)
+			}
+		}
+	}
+	return authInfo.ToClaims(), nil
 }
 
 // DefaultRegisterHandler default register handler
@@ -54,7 +59,6 @@ var DefaultRegisterHandler = func(context *auth.Context) (*claims.Claims, error)
 		schema      auth.Schema
 		authInfo    auth_identity.Basic
 		req         = context.Request
-		tx          = context.Auth.GetDB(req)
 		provider, _ = context.Provider.(*Provider)
 	)
 
@@ -70,14 +74,18 @@ var DefaultRegisterHandler = func(context *auth.Context) (*claims.Claims, error)
 	authInfo.Provider = provider.GetName()
 	authInfo.UID = strings.TrimSpace(req.Form.Get("login"))
 
-	if !tx.Model(context.Auth.AuthIdentityModel).Where(map[string]interface{}{
-		"provider": authInfo.Provider,
-		"uid":      authInfo.UID,
-	}).Scan(&authInfo).RecordNotFound() {
+	if _, err = context.Auth.IdentityStore.Find(req, authInfo.Provider, authInfo.UID); err == nil {
 		return nil, auth.ErrAlreadyRegistered
+	} else if err != auth.ErrIdentityNotFound {
+		return nil, err
 	}
 
-	if authInfo.EncryptedPassword, err = provider.Encryptor.Digest(strings.TrimSpace(req.Form.Get("password"))); err == nil {
+	password := strings.TrimSpace(req.Form.Get("password"))
+	if err = provider.ValidatePassword(password, authInfo, strings.TrimSpace(req.Form.Get("name")), context); err != nil {
+		return nil, err
+	}
+
+	if authInfo.EncryptedPassword, err = provider.Encryptor.Digest(password); err == nil {
 		schema.Provider = authInfo.Provider
 		schema.UID = authInfo.UID
 		schema.Email = authInfo.UID
@@ -89,15 +97,11 @@ var DefaultRegisterHandler = func(context *auth.Context) (*claims.Claims, error)
 		}
 
 		// create auth identity
-		authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-		if err = tx.Where(map[string]interface{}{
-			"provider":           authInfo.Provider,
-			"uid":                authInfo.UID,
-			"encrypted_password": authInfo.EncryptedPassword,
-			"user_id":            authInfo.UserID,
-		}).FirstOrCreate(authIdentity).Error; err == nil {
+		if err = context.Auth.IdentityStore.Create(req, &authInfo); err == nil {
+			provider.SavePasswordHistory(authInfo, context)
+
 			if context.Auth.Config.Confirmable {
-				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: ConfirmFlashMessage, Type: "success"})
+				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: context.T("auth.flash.confirm_account", string(ConfirmFlashMessage)), Type: "success"})
 				err = context.Auth.Config.ConfirmMailer(schema.Email, context, authInfo.ToClaims(), currentUser)
 			}
 
@@ -0,0 +1,37 @@
+package password
+
+import (
+	"html/template"
+
+	"github.com/fahmibaswara/auth"
+)
+
+func registerMailPreviews(Auth *auth.Auth) {
+	Auth.RegisterMailPreview("reset_password", func(context *auth.Context) auth.Mail {
+		return auth.Mail{
+			Name:     "reset_password",
+			Template: "auth/reset_password",
+			Subject:  ResetPasswordMailSubject,
+			To:       "user@example.org",
+			Funcs: template.FuncMap{
+				"reset_password_url": func() string {
+					return resetPasswordURL(context, "sample-token")
+				},
+			},
+		}
+	})
+
+	Auth.RegisterMailPreview("password_changed", func(context *auth.Context) auth.Mail {
+		return auth.Mail{
+			Name:     "password_changed",
+			Template: "auth/password_changed",
+			Subject:  PasswordChangedMailSubject,
+			To:       "user@example.org",
+			Funcs: template.FuncMap{
+				"reset_password_url": func() string {
+					return newPasswordURL(context)
+				},
+			},
+		}
+	})
+}
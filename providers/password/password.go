@@ -1,16 +1,12 @@
 package password
 
 import (
-	"html/template"
-	"net/http"
-	"strings"
-
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
 	"github.com/fahmibaswara/auth/providers/password/encryptor"
 	"github.com/fahmibaswara/auth/providers/password/encryptor/bcrypt_encryptor"
-	"github.com/qor/session"
+	"github.com/fahmibaswara/auth/providers/password/policy"
 )
 
 // Config password config
@@ -22,6 +18,19 @@ type Config struct {
 	Encryptor        encryptor.Interface
 	AuthorizeHandler func(*auth.Context) (*claims.Claims, error)
 	RegisterHandler  func(*auth.Context) (*claims.Claims, error)
+
+	// Policy password policy used when register or reset password, by default, any non-blank password is accepted
+	Policy *policy.Policy
+	// PasswordHistoryModel a model used to save previously used passwords with gorm, only used when Policy's HistorySize is set, https://github.com/fahmibaswara/auth/blob/master/auth_identity/password_history.go is the default implemention
+	PasswordHistoryModel interface{}
+	// ChangePasswordHandler defined behaviour when POST `{Auth Prefix}/password/change`, used by logged user to change password with current password
+	ChangePasswordHandler func(*auth.Context) error
+	// PasswordChangedMailer send notification mail after password changed
+	PasswordChangedMailer func(email string, context *auth.Context, claims *claims.Claims, currentUser interface{}) error
+	// RevokeOtherSessions sign out user's other sessions after password changed, requires Auth's SessionRevoker
+	RevokeOtherSessions bool
+	// StrengthCheckHandler defined behaviour when request `{Auth Prefix}/password/strength`, respond password policy check result as JSON
+	StrengthCheckHandler func(*auth.Context)
 }
 
 // New initialize password provider
@@ -34,6 +43,14 @@ func New(config *Config) *Provider {
 		config.Encryptor = bcrypt_encryptor.New(&bcrypt_encryptor.Config{})
 	}
 
+	if config.Policy == nil {
+		config.Policy = policy.New(nil)
+	}
+
+	if config.PasswordHistoryModel == nil && config.Policy.Config.HistorySize > 0 {
+		config.PasswordHistoryModel = &auth_identity.PasswordHistory{}
+	}
+
 	provider := &Provider{Config: config}
 
 	if config.ResetPasswordMailer == nil {
@@ -56,6 +73,18 @@ func New(config *Config) *Provider {
 		config.RegisterHandler = DefaultRegisterHandler
 	}
 
+	if config.ChangePasswordHandler == nil {
+		config.ChangePasswordHandler = DefaultChangePasswordHandler
+	}
+
+	if config.PasswordChangedMailer == nil {
+		config.PasswordChangedMailer = DefaultPasswordChangedMailer
+	}
+
+	if config.StrengthCheckHandler == nil {
+		config.StrengthCheckHandler = DefaultStrengthCheckHandler
+	}
+
 	return provider
 }
 
@@ -76,6 +105,9 @@ func (provider Provider) ConfigAuth(auth *auth.Auth) {
 	if auth.Mailer != nil {
 		auth.Mailer.RegisterViewPath("github.com/fahmibaswara/auth/providers/password/views/mailers")
 	}
+
+	registerMailPreviews(auth)
+	provider.registerAccountHandlers(auth)
 }
 
 // Login implemented login with password provider
@@ -88,106 +120,7 @@ func (provider Provider) Register(context *auth.Context) {
 	context.Auth.RegisterHandler(context, provider.RegisterHandler)
 }
 
-// Logout implemented logout with password provider
-func (provider Provider) Logout(context *auth.Context) {
-	context.Auth.LogoutHandler(context)
-}
-
-// Callback implement Callback with password provider
-func (provider Provider) Callback(context *auth.Context) {
-}
-
-// ServeHTTP implement ServeHTTP with password provider
-func (provider Provider) ServeHTTP(context *auth.Context) {
-	var (
-		req     = context.Request
-		reqPath = strings.TrimPrefix(req.URL.Path, context.Auth.URLPrefix)
-		paths   = strings.Split(reqPath, "/")
-	)
-
-	if len(paths) >= 2 {
-		switch paths[1] {
-		case "confirmation":
-			var err error
-
-			if len(paths) >= 3 {
-				switch paths[2] {
-				case "new":
-					// render new confirmation page
-					context.Auth.Config.Render.Execute("auth/confirmation/new", context, context.Request, context.Writer)
-				case "send":
-					var (
-						currentUser interface{}
-						authInfo    auth_identity.Basic
-						tx          = context.Auth.GetDB(req)
-					)
-
-					authInfo.Provider = provider.GetName()
-					authInfo.UID = strings.TrimSpace(req.Form.Get("email"))
-					if tx.Model(context.Auth.AuthIdentityModel).Where(map[string]interface{}{
-						"provider": authInfo.Provider,
-						"uid":      authInfo.UID,
-					}).Scan(&authInfo).RecordNotFound() {
-						err = auth.ErrInvalidAccount
-					}
-
-					if err == nil {
-						if currentUser, err = context.Auth.UserStorer.Get(authInfo.ToClaims(), context); err == nil {
-							err = context.Auth.Config.ConfirmMailer(authInfo.UID, context, authInfo.ToClaims(), currentUser)
-						}
-					}
-
-					if err == nil {
-						context.SessionStorer.Flash(context.Writer, req, session.Message{Message: ConfirmFlashMessage, Type: "success"})
-						context.Auth.Redirector.Redirect(context.Writer, context.Request, "send_confirmation")
-					}
-				}
-			}
-
-			if err != nil {
-				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
-			}
-			// render new confirmation page
-			context.Auth.Config.Render.Execute("auth/confirmation/new", context, context.Request, context.Writer)
-		case "confirm":
-			// confirm user
-			err := context.Auth.ConfirmHandler(context)
-			if err != nil {
-				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
-				context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm_failed")
-				return
-			}
-		case "new":
-			// render change password page
-			context.Auth.Config.Render.Execute("auth/password/new", context, context.Request, context.Writer)
-		case "recover":
-			// send recover password mail
-			err := provider.RecoverPasswordHandler(context)
-			if err != nil {
-				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
-				http.Redirect(context.Writer, context.Request, context.Auth.AuthURL("password/new"), http.StatusSeeOther)
-				return
-			}
-		case "edit":
-			// render edit password page
-			if token := context.Request.URL.Query().Get("token"); token != "" {
-				context.Auth.Config.Render.Funcs(template.FuncMap{
-					"reset_password_token": func() string { return token },
-				}).Execute("auth/password/edit", context, context.Request, context.Writer)
-				return
-			}
-			context.SessionStorer.Flash(context.Writer, req, session.Message{Message: template.HTML(ErrInvalidResetPasswordToken.Error()), Type: "error"})
-			http.Redirect(context.Writer, context.Request, context.Auth.AuthURL("password/new"), http.StatusSeeOther)
-		case "update":
-			// update password
-			err := provider.ResetPasswordHandler(context)
-			if err != nil {
-				context.SessionStorer.Flash(context.Writer, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
-				http.Redirect(context.Writer, context.Request, context.Auth.AuthURL("password/new"), http.StatusSeeOther)
-				return
-			}
-		}
-	}
-
-	return
+// Metadata return metadata of password provider
+func (provider Provider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "Email", Kind: "password"}
 }
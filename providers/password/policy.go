@@ -0,0 +1,117 @@
+package password
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/fahmibaswara/auth/providers/password/policy"
+	"github.com/qor/qor/utils"
+)
+
+// ValidatePassword check password with provider's password policy, returns ErrWeakPassword with failed rules as details if password is rejected
+func (provider Provider) ValidatePassword(password string, authInfo auth_identity.Basic, name string, context *auth.Context) error {
+	subject := &policy.Subject{
+		Email:     authInfo.UID,
+		Name:      name,
+		Encryptor: provider.Encryptor,
+	}
+
+	if provider.Policy.Config.HistorySize > 0 && provider.PasswordHistoryModel != nil {
+		var (
+			tx        = context.Auth.GetDB(context.Request)
+			histories []auth_identity.PasswordHistory
+		)
+
+		tx.Model(provider.PasswordHistoryModel).Where(context.Auth.TenantConditions(context.Request, map[string]interface{}{
+			"provider": authInfo.Provider,
+			"uid":      authInfo.UID,
+		})).Order("id desc").Limit(provider.Policy.Config.HistorySize).Scan(&histories)
+
+		for _, history := range histories {
+			subject.PasswordHashes = append(subject.PasswordHashes, history.EncryptedPassword)
+		}
+	}
+
+	if err := provider.Policy.Validate(password, subject); err != nil {
+		if failures, ok := err.(policy.Error); ok {
+			return ErrWeakPassword.WithMessage(failures.Error()).WithDetails(failures)
+		}
+		return err
+	}
+	return nil
+}
+
+// SavePasswordHistory save auth identity's current encrypted password into password history
+func (provider Provider) SavePasswordHistory(authInfo auth_identity.Basic, context *auth.Context) error {
+	if provider.Policy.Config.HistorySize <= 0 || provider.PasswordHistoryModel == nil {
+		return nil
+	}
+
+	var (
+		tx      = context.Auth.GetDB(context.Request)
+		history = reflect.New(utils.ModelType(provider.PasswordHistoryModel)).Interface()
+	)
+
+	return tx.Where(context.Auth.TenantConditions(context.Request, map[string]interface{}{
+		"provider":           authInfo.Provider,
+		"uid":                authInfo.UID,
+		"encrypted_password": authInfo.EncryptedPassword,
+	})).FirstOrCreate(history).Error
+}
+
+// DefaultStrengthCheckHandler default password strength check handler, respond policy check result of posted password as JSON, used for live form feedback, passwords in query strings are ignored, so they never end up in access logs
+var DefaultStrengthCheckHandler = func(context *auth.Context) {
+	var (
+		req         = context.Request
+		provider, _ = context.Provider.(*Provider)
+	)
+
+	req.ParseForm()
+
+	result := provider.Policy.Check(req.PostForm.Get("password"), &policy.Subject{
+		Email: strings.TrimSpace(req.PostForm.Get("login")),
+		Name:  strings.TrimSpace(req.PostForm.Get("name")),
+	})
+
+	context.Writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(context.Writer).Encode(result)
+}
+
+func (provider Provider) registerAccountHandlers(Auth *auth.Auth) {
+	if provider.PasswordHistoryModel == nil {
+		return
+	}
+
+	providerUIDs := func(account *auth.Account) (uids []string) {
+		for _, identity := range account.Identities {
+			if identity.Provider == provider.GetName() {
+				uids = append(uids, identity.UID)
+			}
+		}
+		return
+	}
+
+	// export when passwords were changed, hashes are not included
+	Auth.RegisterAccountExporter("password_changes", func(req *http.Request, account *auth.Account) (interface{}, error) {
+		var (
+			histories []auth_identity.PasswordHistory
+			changedAt []time.Time
+		)
+
+		err := Auth.ScopeTenant(req, Auth.GetDB(req)).Model(provider.PasswordHistoryModel).Where("provider = ? AND uid IN (?)", provider.GetName(), providerUIDs(account)).Order("id").Scan(&histories).Error
+		for _, history := range histories {
+			changedAt = append(changedAt, history.CreatedAt)
+		}
+		return changedAt, err
+	})
+
+	// password histories are deleted even if anonymize accounts, as they are secrets
+	Auth.RegisterAccountEraser(func(req *http.Request, account *auth.Account, anonymize bool) error {
+		return Auth.ScopeTenant(req, Auth.GetDB(req)).Unscoped().Where("provider = ? AND uid IN (?)", provider.GetName(), providerUIDs(account)).Delete(reflect.New(utils.ModelType(provider.PasswordHistoryModel)).Interface()).Error
+	})
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker check if password appeared in data breaches
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PrefixFileChecker check passwords against a local copy of breached SHA-1 hashes
+//
+// Dir should contain one file per 5 characters hash prefix, named as the upper case prefix (e.g. `21BD1` or `21BD1.txt`),
+// each line of the file is `SUFFIX:COUNT`, which is the same format as the Pwned Passwords range API
+type PrefixFileChecker struct {
+	Dir string
+	// MinCount only treat hashes that appeared at least MinCount times as breached
+	MinCount int
+}
+
+// NewPrefixFileChecker initialize PrefixFileChecker
+func NewPrefixFileChecker(dir string) *PrefixFileChecker {
+	return &PrefixFileChecker{Dir: dir, MinCount: 1}
+}
+
+// IsBreached check if password's SHA-1 hash is listed in prefix file
+func (checker *PrefixFileChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(checker.Dir, prefix))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(checker.Dir, prefix+".txt"))
+	}
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		parts := strings.SplitN(line, ":", 2)
+
+		if !strings.EqualFold(parts[0], suffix) {
+			continue
+		}
+
+		if len(parts) == 2 {
+			if count, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil && count < checker.MinCount {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,56 @@
+package policy
+
+import "math"
+
+// Entropy estimate password entropy in bits, based on the size of used character pools and password length, repeated characters are only counted once per run
+func Entropy(password string) float64 {
+	var (
+		pool   int
+		length int
+		last   rune
+		c      = characterClasses(password)
+	)
+
+	if c.lower {
+		pool += 26
+	}
+
+	if c.upper {
+		pool += 26
+	}
+
+	if c.digit {
+		pool += 10
+	}
+
+	if c.symbol {
+		pool += 33
+	}
+
+	for idx, r := range []rune(password) {
+		if idx == 0 || r != last {
+			length++
+		}
+		last = r
+	}
+
+	if pool == 0 {
+		return 0
+	}
+	return float64(length) * math.Log2(float64(pool))
+}
+
+func score(entropy float64) int {
+	switch {
+	case entropy < 28:
+		return 0
+	case entropy < 36:
+		return 1
+	case entropy < 60:
+		return 2
+	case entropy < 128:
+		return 3
+	default:
+		return 4
+	}
+}
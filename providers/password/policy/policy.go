@@ -0,0 +1,268 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor"
+)
+
+var (
+	// RequiredMessage message used when password is blank
+	RequiredMessage = "Password is required"
+	// MinLengthMessage message used when password is too short, %d will be replaced with min length
+	MinLengthMessage = "Password must be at least %d characters"
+	// MaxLengthMessage message used when password is too long, %d will be replaced with max length
+	MaxLengthMessage = "Password must be at most %d characters"
+	// UpperMessage message used when password doesn't contain upper case letter
+	UpperMessage = "Password must contain an upper case letter"
+	// LowerMessage message used when password doesn't contain lower case letter
+	LowerMessage = "Password must contain a lower case letter"
+	// DigitMessage message used when password doesn't contain digit
+	DigitMessage = "Password must contain a digit"
+	// SymbolMessage message used when password doesn't contain symbol
+	SymbolMessage = "Password must contain a symbol"
+	// EntropyMessage message used when password is too easy to guess
+	EntropyMessage = "Password is too easy to guess"
+	// IdentityMessage message used when password contains user's email or name
+	IdentityMessage = "Password must not contain your email or name"
+	// HistoryMessage message used when password was used recently
+	HistoryMessage = "Password was used recently, please choose a different one"
+	// BreachedMessage message used when password appears in breached password list
+	BreachedMessage = "Password has appeared in a data breach, please choose a different one"
+	// BreachCheckMessage message used when BreachChecker failed to check the password
+	BreachCheckMessage = "Couldn't check if password has appeared in a data breach, please try again later"
+)
+
+// Rule names used in Failure
+const (
+	RuleRequired = "required"
+	RuleLength   = "length"
+	RuleUpper    = "upper"
+	RuleLower    = "lower"
+	RuleDigit    = "digit"
+	RuleSymbol   = "symbol"
+	RuleEntropy  = "entropy"
+	RuleIdentity = "identity"
+	RuleHistory  = "history"
+	RuleBreached = "breached"
+	// RuleBreachCheck BreachChecker returned an error, refer Config.BreachCheckFailOpen
+	RuleBreachCheck = "breach_check"
+)
+
+// Config password policy config
+type Config struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinEntropy minimum estimated entropy in bits, refer Entropy for how it is estimated
+	MinEntropy float64
+	// DisallowIdentity reject passwords that contain user's email (or its local part) or name
+	DisallowIdentity bool
+	// HistorySize reject passwords that match one of the last N encrypted passwords of the user
+	HistorySize int
+	// BreachChecker used to reject passwords that appeared in data breaches, refer NewPrefixFileChecker
+	BreachChecker BreachChecker
+	// BreachCheckFailOpen accept passwords if BreachChecker returned an error, by default, they are rejected with rule `breach_check`
+	BreachCheckFailOpen bool
+}
+
+// Policy password policy
+type Policy struct {
+	Config *Config
+}
+
+// New initialize password policy
+func New(config *Config) *Policy {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.MinLength == 0 {
+		config.MinLength = 1
+	}
+
+	return &Policy{Config: config}
+}
+
+// Subject information about the user who is choosing the password
+type Subject struct {
+	Email string
+	Name  string
+	// PasswordHashes recently used encrypted passwords, newest first
+	PasswordHashes []string
+	// Encryptor used to compare password with PasswordHashes
+	Encryptor encryptor.Interface
+}
+
+// Failure a failed policy rule
+type Failure struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Result password check result
+type Result struct {
+	// Score password strength from 0 (very weak) to 4 (very strong)
+	Score    int       `json:"score"`
+	Entropy  float64   `json:"entropy"`
+	Valid    bool      `json:"valid"`
+	Failures []Failure `json:"failures"`
+}
+
+// Err return policy error if any rule failed
+func (result Result) Err() error {
+	if len(result.Failures) == 0 {
+		return nil
+	}
+	return Error(result.Failures)
+}
+
+// Error password policy error
+type Error []Failure
+
+// Error return failure messages
+func (err Error) Error() string {
+	var messages []string
+	for _, failure := range err {
+		messages = append(messages, failure.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate validate password, returns Error if any rule failed
+func (policy *Policy) Validate(password string, subject *Subject) error {
+	return policy.Check(password, subject).Err()
+}
+
+// Check check password against all rules
+func (policy *Policy) Check(password string, subject *Subject) Result {
+	var (
+		config = policy.Config
+		result = Result{Entropy: Entropy(password), Failures: []Failure{}}
+	)
+
+	if subject == nil {
+		subject = &Subject{}
+	}
+
+	fail := func(rule, message string) {
+		result.Failures = append(result.Failures, Failure{Rule: rule, Message: message})
+	}
+
+	if password == "" {
+		fail(RuleRequired, RequiredMessage)
+		return result
+	}
+
+	length := len([]rune(password))
+	if length < config.MinLength {
+		fail(RuleLength, fmt.Sprintf(MinLengthMessage, config.MinLength))
+	}
+
+	if config.MaxLength > 0 && length > config.MaxLength {
+		fail(RuleLength, fmt.Sprintf(MaxLengthMessage, config.MaxLength))
+	}
+
+	classes := characterClasses(password)
+	if config.RequireUpper && !classes.upper {
+		fail(RuleUpper, UpperMessage)
+	}
+
+	if config.RequireLower && !classes.lower {
+		fail(RuleLower, LowerMessage)
+	}
+
+	if config.RequireDigit && !classes.digit {
+		fail(RuleDigit, DigitMessage)
+	}
+
+	if config.RequireSymbol && !classes.symbol {
+		fail(RuleSymbol, SymbolMessage)
+	}
+
+	if config.MinEntropy > 0 && result.Entropy < config.MinEntropy {
+		fail(RuleEntropy, EntropyMessage)
+	}
+
+	if config.DisallowIdentity && containsIdentity(password, subject) {
+		fail(RuleIdentity, IdentityMessage)
+	}
+
+	if config.HistorySize > 0 && subject.Encryptor != nil {
+		for idx, hashedPassword := range subject.PasswordHashes {
+			if idx >= config.HistorySize {
+				break
+			}
+
+			if subject.Encryptor.Compare(hashedPassword, password) == nil {
+				fail(RuleHistory, HistoryMessage)
+				break
+			}
+		}
+	}
+
+	if config.BreachChecker != nil {
+		if breached, err := config.BreachChecker.IsBreached(password); err != nil {
+			if !config.BreachCheckFailOpen {
+				fail(RuleBreachCheck, BreachCheckMessage)
+			}
+		} else if breached {
+			fail(RuleBreached, BreachedMessage)
+		}
+	}
+
+	result.Score = score(result.Entropy)
+	result.Valid = len(result.Failures) == 0
+	return result
+}
+
+type classes struct {
+	upper, lower, digit, symbol bool
+}
+
+func characterClasses(password string) (c classes) {
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			c.upper = true
+		case unicode.IsLower(r):
+			c.lower = true
+		case unicode.IsDigit(r):
+			c.digit = true
+		default:
+			c.symbol = true
+		}
+	}
+	return
+}
+
+func containsIdentity(password string, subject *Subject) bool {
+	var (
+		lowerPassword = strings.ToLower(password)
+		identities    []string
+	)
+
+	if email := strings.ToLower(strings.TrimSpace(subject.Email)); email != "" {
+		identities = append(identities, email)
+		if idx := strings.Index(email, "@"); idx > 0 {
+			identities = append(identities, email[:idx])
+		}
+	}
+
+	for _, name := range strings.Fields(strings.ToLower(subject.Name)) {
+		identities = append(identities, name)
+	}
+
+	for _, identity := range identities {
+		// ignore very short identities like initials, they would reject too many passwords
+		if len(identity) >= 3 && strings.Contains(lowerPassword, identity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fahmibaswara/auth/providers/password/encryptor/pbkdf2_encryptor"
+)
+
+type breachChecker struct {
+	breached bool
+	err      error
+}
+
+func (checker breachChecker) IsBreached(password string) (bool, error) {
+	return checker.breached, checker.err
+}
+
+func rules(result Result) (rules []string) {
+	for _, failure := range result.Failures {
+		rules = append(rules, failure.Rule)
+	}
+	return
+}
+
+func TestBreachChecker(t *testing.T) {
+	cases := []struct {
+		name     string
+		checker  breachChecker
+		failOpen bool
+		valid    bool
+		rule     string
+	}{
+		{name: "not breached", checker: breachChecker{}, valid: true},
+		{name: "breached", checker: breachChecker{breached: true}, rule: RuleBreached},
+		{name: "checker error", checker: breachChecker{err: errors.New("timeout")}, rule: RuleBreachCheck},
+		{name: "checker error with fail open", checker: breachChecker{err: errors.New("timeout")}, failOpen: true, valid: true},
+	}
+
+	for _, c := range cases {
+		policy := New(&Config{BreachChecker: c.checker, BreachCheckFailOpen: c.failOpen})
+		result := policy.Check("correct horse battery staple", nil)
+
+		if result.Valid != c.valid {
+			t.Errorf("%v: valid should be %v, got failures %v", c.name, c.valid, rules(result))
+		}
+
+		if c.rule != "" && (len(result.Failures) != 1 || result.Failures[0].Rule != c.rule) {
+			t.Errorf("%v: should fail with rule %v, got %v", c.name, c.rule, rules(result))
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   Config
+		password string
+		subject  *Subject
+		failures []Failure
+	}{
+		{name: "blank", password: "", failures: []Failure{{Rule: RuleRequired, Message: RequiredMessage}}},
+		{name: "default config", password: "a", failures: []Failure{}},
+		{name: "too short", config: Config{MinLength: 8}, password: "abc", failures: []Failure{{Rule: RuleLength, Message: "Password must be at least 8 characters"}}},
+		{name: "min length of runes", config: Config{MinLength: 4}, password: "пароль", failures: []Failure{}},
+		{name: "too long", config: Config{MaxLength: 4}, password: "abcdef", failures: []Failure{{Rule: RuleLength, Message: "Password must be at most 4 characters"}}},
+		{name: "missing upper", config: Config{RequireUpper: true}, password: "abc1!", failures: []Failure{{Rule: RuleUpper, Message: UpperMessage}}},
+		{name: "missing lower", config: Config{RequireLower: true}, password: "ABC1!", failures: []Failure{{Rule: RuleLower, Message: LowerMessage}}},
+		{name: "missing digit", config: Config{RequireDigit: true}, password: "Abc!", failures: []Failure{{Rule: RuleDigit, Message: DigitMessage}}},
+		{name: "missing symbol", config: Config{RequireSymbol: true}, password: "Abc1", failures: []Failure{{Rule: RuleSymbol, Message: SymbolMessage}}},
+		{name: "all classes", config: Config{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}, password: "Abc1!", failures: []Failure{}},
+		{name: "missing classes", config: Config{RequireUpper: true, RequireDigit: true}, password: "abc", failures: []Failure{{Rule: RuleUpper, Message: UpperMessage}, {Rule: RuleDigit, Message: DigitMessage}}},
+		{name: "low entropy", config: Config{MinEntropy: 40}, password: "aaaaaaaaaaaa", failures: []Failure{{Rule: RuleEntropy, Message: EntropyMessage}}},
+		{name: "high entropy", config: Config{MinEntropy: 40}, password: "correct horse battery staple", failures: []Failure{}},
+		{name: "contains email", config: Config{DisallowIdentity: true}, password: "Jinzhu@Example.com1", subject: &Subject{Email: "jinzhu@example.com"}, failures: []Failure{{Rule: RuleIdentity, Message: IdentityMessage}}},
+		{name: "contains local part of email", config: Config{DisallowIdentity: true}, password: "ilovejinzhu", subject: &Subject{Email: "jinzhu@example.com"}, failures: []Failure{{Rule: RuleIdentity, Message: IdentityMessage}}},
+		{name: "contains name", config: Config{DisallowIdentity: true}, password: "zhang2020!", subject: &Subject{Name: "Jinzhu Zhang"}, failures: []Failure{{Rule: RuleIdentity, Message: IdentityMessage}}},
+		{name: "short name is ignored", config: Config{DisallowIdentity: true}, password: "jz2020!", subject: &Subject{Name: "J Z"}, failures: []Failure{}},
+		{name: "identity without subject", config: Config{DisallowIdentity: true}, password: "jinzhu", failures: []Failure{}},
+	}
+
+	for _, c := range cases {
+		config := c.config
+		result := New(&config).Check(c.password, c.subject)
+
+		if !reflect.DeepEqual(result.Failures, c.failures) {
+			t.Errorf("%v: failures should be %v, got %v", c.name, c.failures, result.Failures)
+		}
+
+		if result.Valid != (len(c.failures) == 0) {
+			t.Errorf("%v: valid should be %v, got %v", c.name, len(c.failures) == 0, result.Valid)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var (
+		encryptor = pbkdf2_encryptor.New(&pbkdf2_encryptor.Config{Iterations: 1000})
+		hashes    []string
+	)
+
+	// newest first
+	for _, password := range []string{"third password", "second password", "first password"} {
+		hash, _ := encryptor.Digest(password)
+		hashes = append(hashes, hash)
+	}
+
+	cases := []struct {
+		name        string
+		historySize int
+		password    string
+		reused      bool
+	}{
+		{name: "new password", historySize: 3, password: "fourth password"},
+		{name: "newest password", historySize: 3, password: "third password", reused: true},
+		{name: "oldest password in history", historySize: 3, password: "first password", reused: true},
+		{name: "password older than history size", historySize: 2, password: "first password"},
+		{name: "history disabled", password: "third password"},
+	}
+
+	for _, c := range cases {
+		result := New(&Config{HistorySize: c.historySize}).Check(c.password, &Subject{PasswordHashes: hashes, Encryptor: encryptor})
+		reused := len(result.Failures) == 1 && result.Failures[0] == Failure{Rule: RuleHistory, Message: HistoryMessage}
+
+		if reused != c.reused || result.Valid == c.reused {
+			t.Errorf("%v: should be rejected as reused %v, got %v", c.name, c.reused, result.Failures)
+		}
+	}
+
+	err := New(&Config{HistorySize: 3}).Validate("second password", &Subject{PasswordHashes: hashes, Encryptor: encryptor})
+	if failures, ok := err.(Error); !ok || err.Error() != HistoryMessage || len(failures) != 1 {
+		t.Errorf("Validate should return policy Error with history failure, got %v", err)
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	cases := []struct {
+		password string
+		score    int
+	}{
+		{password: "", score: 0},
+		{password: "aaaaaaaaaaaaaaaa", score: 0},
+		{password: "abcdef", score: 1},
+		{password: "abcdefghij", score: 2},
+		{password: "Tr0ub4dor&3", score: 3},
+		{password: "correct horse battery staple", score: 4},
+	}
+
+	for _, c := range cases {
+		if result := New(nil).Check(c.password, nil); result.Score != c.score {
+			t.Errorf("score of %q should be %v, got %v (entropy %v)", c.password, c.score, result.Score, result.Entropy)
+		}
+	}
+}
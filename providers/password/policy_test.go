@@ -0,0 +1,55 @@
+package password_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/fahmibaswara/auth/authtest"
+	"github.com/fahmibaswara/auth/providers/password"
+	"github.com/fahmibaswara/auth/providers/password/policy"
+)
+
+func TestStrengthCheckHandler(t *testing.T) {
+	Auth := authtest.New(nil)
+	Auth.RegisterProvider(password.New(&password.Config{Policy: policy.New(&policy.Config{MinLength: 8, DisallowIdentity: true})}))
+	server := Auth.NewServer(nil)
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		query  url.Values
+		values url.Values
+		rules  []string
+	}{
+		{name: "strong password", values: url.Values{"password": {"correct horse battery staple"}}},
+		{name: "short password", values: url.Values{"password": {"abc"}}, rules: []string{policy.RuleLength}},
+		{name: "password contains login", values: url.Values{"password": {"jinzhu2020!"}, "login": {"jinzhu@example.com"}}, rules: []string{policy.RuleIdentity}},
+		{name: "password contains name", values: url.Values{"password": {"zhang2020!"}, "name": {"Jinzhu Zhang"}}, rules: []string{policy.RuleIdentity}},
+		{name: "password in query string is ignored", query: url.Values{"password": {"correct horse battery staple"}}, rules: []string{policy.RuleRequired}},
+	}
+
+	for _, c := range cases {
+		resp, err := http.PostForm(server.URL+Auth.AuthURL("password/strength")+"?"+c.query.Encode(), c.values)
+		if err != nil {
+			t.Fatalf("%v: failed to check strength, got %v", c.name, err)
+		}
+
+		var (
+			result policy.Result
+			rules  []string
+		)
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		for _, failure := range result.Failures {
+			rules = append(rules, failure.Rule)
+		}
+
+		if !reflect.DeepEqual(rules, c.rules) || result.Valid != (len(c.rules) == 0) {
+			t.Errorf("%v: failed rules should be %v, got %v", c.name, c.rules, rules)
+		}
+	}
+}
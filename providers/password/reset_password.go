@@ -1,18 +1,13 @@
 package password
 
 import (
-	"net/mail"
-	"path"
-	"reflect"
 	"strings"
-	"time"
 
 	"html/template"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/mailer"
 	"github.com/qor/qor/utils"
 	"github.com/qor/session"
 )
@@ -30,32 +25,32 @@ var (
 
 // DefaultResetPasswordMailer default reset password mailer
 var DefaultResetPasswordMailer = func(email string, context *auth.Context, claims *claims.Claims, currentUser interface{}) error {
-	claims.Subject = "reset_password"
-
-	return context.Auth.Mailer.Send(
-		mailer.Email{
-			TO:      []mail.Address{{Address: email}},
-			From:    &mail.Address{Address: "admin@example.org"},
-			Subject: ResetPasswordMailSubject,
-		}, mailer.Template{
-			Name:    "auth/reset_password",
-			Data:    context,
-			Request: context.Request,
-			Writer:  context.Writer,
-		}.Funcs(template.FuncMap{
-			"current_user": func() interface{} {
-				return currentUser
-			},
+	token, err := context.Auth.NewActionToken(context.Request, auth.ActionResetPassword, claims)
+	if err != nil {
+		return err
+	}
+
+	return context.Auth.SendMail(context, auth.Mail{
+		Name:        "reset_password",
+		Template:    "auth/reset_password",
+		Subject:     ResetPasswordMailSubject,
+		To:          email,
+		CurrentUser: currentUser,
+		Funcs: template.FuncMap{
 			"reset_password_url": func() string {
-				resetPasswordURL := utils.GetAbsURL(context.Request)
-				resetPasswordURL.Path = path.Join(context.Auth.AuthURL("password/edit"))
-				qry := resetPasswordURL.Query()
-				qry.Set("token", context.SessionStorer.SignedToken(claims))
-				resetPasswordURL.RawQuery = qry.Encode()
-				return resetPasswordURL.String()
+				return resetPasswordURL(context, token)
 			},
-		}),
-	)
+		},
+	})
+}
+
+func resetPasswordURL(context *auth.Context, token string) string {
+	resetPasswordURL := utils.GetAbsURL(context.Request)
+	resetPasswordURL.Path = context.AuthURL("password/edit")
+	qry := resetPasswordURL.Query()
+	qry.Set("token", token)
+	resetPasswordURL.RawQuery = qry.Encode()
+	return resetPasswordURL.String()
 }
 
 // DefaultRecoverPasswordHandler default reset password handler
@@ -80,7 +75,7 @@ var DefaultRecoverPasswordHandler = func(context *auth.Context) error {
 	err = provider.ResetPasswordMailer(email, context, authInfo.ToClaims(), currentUser)
 
 	if err == nil {
-		context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: SendChangePasswordMailFlashMessage, Type: "success"})
+		context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: context.T("password.flash.send_reset_password", string(SendChangePasswordMailFlashMessage)), Type: "success"})
 		context.Auth.Redirector.Redirect(context.Writer, context.Request, "send_recover_password_mail")
 	}
 	return err
@@ -91,40 +86,42 @@ var DefaultResetPasswordHandler = func(context *auth.Context) error {
 	context.Request.ParseForm()
 
 	var (
-		authInfo    auth_identity.Basic
 		token       = context.Request.Form.Get("reset_password_token")
+		newPassword = strings.TrimSpace(context.Request.Form.Get("new_password"))
 		provider, _ = context.Provider.(*Provider)
-		tx          = context.Auth.GetDB(context.Request)
 	)
 
-	claims, err := context.SessionStorer.ValidateClaims(token)
+	authInfo, _, err := context.Auth.ValidateActionToken(context.Request, auth.ActionResetPassword, token)
+
+	if err == nil && authInfo.Provider != provider.GetName() {
+		err = ErrInvalidResetPasswordToken
+	}
 
 	if err == nil {
-		if err = claims.Valid(); err == nil {
-			authInfo.Provider = provider.GetName()
-			authInfo.UID = claims.Id
-			authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-
-			if tx.Where(map[string]interface{}{
-				"provider": authInfo.Provider,
-				"uid":      authInfo.UID,
-			}).First(authIdentity).RecordNotFound() {
-				return auth.ErrInvalidAccount
+		if err = provider.ValidatePassword(newPassword, *authInfo, "", context); err != nil {
+			return err
+		}
+
+		// mark token as used after password is accepted, so user could retry with the same link
+		if _, err = context.Auth.ConsumeActionToken(context.Request, auth.ActionResetPassword, token); err != nil {
+			return err
+		}
+
+		if authInfo.EncryptedPassword, err = provider.Encryptor.Digest(newPassword); err == nil {
+			// Confirm account after reset password, as user already click a link from email
+			if context.Auth.Config.Confirmable && authInfo.ConfirmedAt == nil {
+				now := context.Auth.Now()
+				authInfo.ConfirmedAt = &now
 			}
 
-			if authInfo.EncryptedPassword, err = provider.Encryptor.Digest(strings.TrimSpace(context.Request.Form.Get("new_password"))); err == nil {
-				// Confirm account after reset password, as user already click a link from email
-				if context.Auth.Config.Confirmable && authInfo.ConfirmedAt == nil {
-					now := time.Now()
-					authInfo.ConfirmedAt = &now
-				}
-				err = tx.Model(authIdentity).Update(authInfo).Error
+			if err = context.Auth.IdentityStore.Update(context.Request, authInfo); err == nil {
+				provider.SavePasswordHistory(*authInfo, context)
 			}
 		}
 	}
 
 	if err == nil {
-		context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: ChangedPasswordFlashMessage, Type: "success"})
+		context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: context.T("password.flash.changed_password", string(ChangedPasswordFlashMessage)), Type: "success"})
 		context.Auth.Redirector.Redirect(context.Writer, context.Request, "reset_password")
 	}
 	return err
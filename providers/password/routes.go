@@ -0,0 +1,120 @@
+package password
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/auth_identity"
+	"github.com/qor/session"
+)
+
+// RegisterRoutes register routes of password provider
+func (provider Provider) RegisterRoutes(Auth *auth.Auth) {
+	name := provider.GetName()
+	for _, route := range []auth.Route{
+		{Method: "GET", Path: "confirmation", Description: "render resend confirmation page", Handler: provider.confirmationPage},
+		{Method: "GET", Path: "confirmation/new", Description: "render resend confirmation page", Handler: provider.confirmationPage},
+		{Method: "POST", Path: "confirmation/send", Description: "resend confirmation mail", Handler: provider.sendConfirmation},
+		{Method: "GET", Path: "confirm", Description: "confirm account with token of confirmation mail", Handler: provider.confirm},
+		{Method: "GET", Path: "new", Description: "render forgot password page", Handler: provider.newPasswordPage},
+		{Method: "POST", Path: "recover", Description: "send reset password mail", Handler: provider.recoverPassword},
+		{Method: "GET", Path: "edit", Description: "render reset password page with token of reset password mail", Handler: provider.editPasswordPage},
+		{Method: "POST", Path: "update", Description: "reset password with token of reset password mail", Handler: provider.updatePassword},
+		{Method: "GET", Path: "change", Description: "render change password page", Handler: provider.changePasswordPage},
+		{Method: "POST", Path: "change", Description: "change password with current password", Handler: provider.changePassword},
+		{Method: "POST", Path: "strength", Description: "check password strength", Handler: provider.StrengthCheckHandler},
+	} {
+		route.Path = name + "/" + route.Path
+		route.Provider = name
+		Auth.AddRoute(route)
+	}
+}
+
+func (provider Provider) confirmationPage(context *auth.Context) {
+	// render new confirmation page
+	context.Auth.Config.Render.Execute("auth/confirmation/new", context, context.Request, context.Writer)
+}
+
+func (provider Provider) sendConfirmation(context *auth.Context) {
+	var (
+		req         = context.Request
+		currentUser interface{}
+		authInfo    *auth_identity.Basic
+		err         error
+	)
+
+	if authInfo, err = context.Auth.IdentityStore.Find(req, provider.GetName(), strings.TrimSpace(req.FormValue("email"))); err == auth.ErrIdentityNotFound {
+		err = auth.ErrInvalidAccount
+	}
+
+	if err == nil {
+		if currentUser, err = context.Auth.UserStorer.Get(authInfo.ToClaims(), context); err == nil {
+			err = context.Auth.Config.ConfirmMailer(authInfo.UID, context, authInfo.ToClaims(), currentUser)
+		}
+	}
+
+	if err != nil {
+		context.Auth.FlashError(context.Writer, req, err)
+		provider.confirmationPage(context)
+		return
+	}
+
+	context.SessionStorer.Flash(context.Writer, req, session.Message{Message: context.T("auth.flash.confirm_account", string(ConfirmFlashMessage)), Type: "success"})
+	context.Auth.Redirector.Redirect(context.Writer, context.Request, "send_confirmation")
+}
+
+func (provider Provider) confirm(context *auth.Context) {
+	// confirm user
+	if err := context.Auth.ConfirmHandler(context); err != nil {
+		context.Auth.FlashError(context.Writer, context.Request, err)
+		context.Auth.Redirector.Redirect(context.Writer, context.Request, "confirm_failed")
+	}
+}
+
+func (provider Provider) newPasswordPage(context *auth.Context) {
+	// render forgot password page
+	context.Auth.Config.Render.Execute("auth/password/new", context, context.Request, context.Writer)
+}
+
+func (provider Provider) recoverPassword(context *auth.Context) {
+	// send recover password mail
+	if err := provider.RecoverPasswordHandler(context); err != nil {
+		context.Auth.FlashError(context.Writer, context.Request, err)
+		http.Redirect(context.Writer, context.Request, context.AuthURL("password/new"), http.StatusSeeOther)
+	}
+}
+
+func (provider Provider) editPasswordPage(context *auth.Context) {
+	// render edit password page
+	token := context.Request.URL.Query().Get("token")
+	if _, _, err := context.Auth.ValidateActionToken(context.Request, auth.ActionResetPassword, token); err == nil {
+		context.Auth.Config.Render.Funcs(template.FuncMap{
+			"reset_password_token": func() string { return token },
+		}).Execute("auth/password/edit", context, context.Request, context.Writer)
+		return
+	}
+	context.Auth.FlashError(context.Writer, context.Request, ErrInvalidResetPasswordToken)
+	http.Redirect(context.Writer, context.Request, context.AuthURL("password/new"), http.StatusSeeOther)
+}
+
+func (provider Provider) updatePassword(context *auth.Context) {
+	// update password
+	if err := provider.ResetPasswordHandler(context); err != nil {
+		context.Auth.FlashError(context.Writer, context.Request, err)
+		http.Redirect(context.Writer, context.Request, context.AuthURL("password/new"), http.StatusSeeOther)
+	}
+}
+
+func (provider Provider) changePasswordPage(context *auth.Context) {
+	// render change password page
+	context.Auth.Config.Render.Execute("auth/password/change", context, context.Request, context.Writer)
+}
+
+func (provider Provider) changePassword(context *auth.Context) {
+	// change password with current password
+	if err := provider.ChangePasswordHandler(context); err != nil {
+		respondChangePasswordError(err, context)
+	}
+}
@@ -0,0 +1,153 @@
+package phone
+
+import (
+	"html/template"
+	"reflect"
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+)
+
+var (
+	// TokenMailSubject subject of mail that sends token by email
+	TokenMailSubject = "Your verification code"
+
+	// DefaultTokenMessages default messages of channels, channels that are not in it use DefaultTokenMessage, `{spoken_token}` will be replaced with token's digits separated by commas, which reads better with text-to-speech
+	DefaultTokenMessages = map[string]string{
+		"voice": "Your code is {spoken_token}. Again, your code is {spoken_token}.",
+	}
+)
+
+// Channel way to send tokens to users, like SMS, voice call, email
+type Channel interface {
+	// GetName return channel's name, like `sms`, it is recorded with sent tokens, users could choose channel with it
+	GetName() string
+	// Send send message that contains token to user of phone number
+	Send(phoneNumber string, message string, context *auth.Context) error
+}
+
+// SenderChannel channel send tokens with Sender, like SMS gateways, text-to-speech call hooks, WhatsApp-style messaging APIs, refer package sms for senders
+type SenderChannel struct {
+	Name string
+	// Sender default is Auth's SMSSender
+	Sender auth.SMSSender
+}
+
+// GetName return channel's name
+func (channel *SenderChannel) GetName() string {
+	return channel.Name
+}
+
+// Send send message with Sender
+func (channel *SenderChannel) Send(phoneNumber string, message string, context *auth.Context) error {
+	sender := channel.Sender
+	if sender == nil {
+		sender = context.Auth.SMSSender
+	}
+	return sender.Send(phoneNumber, message)
+}
+
+// EmailChannel channel send tokens by email, like falling back to email if SMS can't be delivered, mail is rendered with template `auth/phone_token`
+type EmailChannel struct {
+	// Email return email address of phone number's user, default is `Email` field of the user got with UserStorer
+	Email func(phoneNumber string, context *auth.Context) (string, error)
+}
+
+// GetName return channel's name, `email`
+func (channel *EmailChannel) GetName() string {
+	return "email"
+}
+
+// Send send message by email
+func (channel *EmailChannel) Send(phoneNumber string, message string, context *auth.Context) error {
+	getEmail := channel.Email
+	if getEmail == nil {
+		getEmail = userEmail
+	}
+
+	email, err := getEmail(phoneNumber, context)
+	if err != nil {
+		return err
+	}
+
+	if email == "" {
+		return ErrChannelUnavailable
+	}
+
+	return context.Auth.SendMail(context, auth.Mail{
+		Name:     "phone_token",
+		Template: "auth/phone_token",
+		Subject:  TokenMailSubject,
+		To:       email,
+		Funcs: template.FuncMap{
+			"token_message": func() string {
+				return message
+			},
+		},
+	})
+}
+
+func userEmail(phoneNumber string, context *auth.Context) (string, error) {
+	providerName := "phone"
+	if provider, ok := context.Provider.(*Provider); ok {
+		providerName = provider.GetName()
+	}
+
+	authInfo, err := context.Auth.IdentityStore.Find(context.Request, providerName, phoneNumber)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := context.Auth.UserStorer.Get(authInfo.ToClaims(), context)
+	if err != nil {
+		return "", err
+	}
+
+	if value := reflect.Indirect(reflect.ValueOf(user)); value.Kind() == reflect.Struct {
+		if field := value.FieldByName("Email"); field.Kind() == reflect.String {
+			return field.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// DefaultChannelPolicy choose channels to send token, it is the channel chosen with request's `channel` param, or all channels in order, which means later channels are fallbacks of former ones
+var DefaultChannelPolicy = func(phoneNumber string, context *auth.Context) ([]Channel, error) {
+	provider, _ := context.Provider.(*Provider)
+
+	if name := context.Request.FormValue("channel"); name != "" {
+		if channel := provider.GetChannel(name); channel != nil {
+			return []Channel{channel}, nil
+		}
+		return nil, ErrInvalidChannel
+	}
+	return provider.Channels, nil
+}
+
+// GetChannel get channel with name
+func (provider Provider) GetChannel(name string) Channel {
+	for _, channel := range provider.Channels {
+		if channel.GetName() == name {
+			return channel
+		}
+	}
+	return nil
+}
+
+// TokenMessageOf return message of token sent with channel, refer Config's TokenMessages, TokenMessage
+func (provider Provider) TokenMessageOf(channel string, token string, context *auth.Context) string {
+	message := provider.Config.TokenMessages[channel]
+
+	if message == "" {
+		if defaultMessage, ok := DefaultTokenMessages[channel]; ok {
+			message = context.Auth.Translate(context.Request, "phone.token_message."+channel, defaultMessage)
+		} else if message = provider.Config.TokenMessage; message == "" {
+			message = context.Auth.Translate(context.Request, "phone.token_message", DefaultTokenMessage)
+		}
+	}
+
+	return strings.NewReplacer(
+		"{token}", token,
+		"{spoken_token}", strings.Join(strings.Split(token, ""), ", "),
+	).Replace(message)
+}
@@ -1,12 +1,24 @@
 package phone
 
-import "errors"
+import (
+	"net/http"
+
+	"github.com/fahmibaswara/auth"
+)
 
 var (
 	// ErrInvalidToken Auth Token not match
-	ErrInvalidToken = errors.New("Token Not Match")
+	ErrInvalidToken = &auth.Error{Code: "invalid_phone_token", Status: http.StatusUnauthorized, Message: "Token Not Match", MessageID: "phone.errors.invalid_token"}
 	// ErrTokenExpired Auth Token Expired
-	ErrTokenExpired = errors.New("Token Has Expired")
+	ErrTokenExpired = &auth.Error{Code: "phone_token_expired", Status: http.StatusUnauthorized, Message: "Token Has Expired", MessageID: "phone.errors.token_expired"}
+	// ErrTooManyAttempts Auth Token failed to check too many times, no token could be checked or sent for the phone number until it expires
+	ErrTooManyAttempts = &auth.Error{Code: "phone_token_attempts_exceeded", Status: http.StatusTooManyRequests, Message: "Too many attempts, please try again later", MessageID: "phone.errors.too_many_attempts"}
+	// ErrResendTooSoon Auth Token is requested again before ResendInterval passed
+	ErrResendTooSoon = &auth.Error{Code: "phone_token_resend_too_soon", Status: http.StatusTooManyRequests, Message: "Please wait before requesting a new code", MessageID: "phone.errors.resend_too_soon"}
 	// ErrInvalidNumber Invalid Phone Number Format
-	ErrInvalidNumber = errors.New("Invalid Phone Number")
+	ErrInvalidNumber = &auth.Error{Code: "invalid_phone_number", Status: http.StatusUnprocessableEntity, Message: "Invalid Phone Number", MessageID: "phone.errors.invalid_number"}
+	// ErrInvalidChannel chosen channel doesn't exist
+	ErrInvalidChannel = &auth.Error{Code: "invalid_phone_channel", Status: http.StatusUnprocessableEntity, Message: "Code can't be sent this way", MessageID: "phone.errors.invalid_channel"}
+	// ErrChannelUnavailable channel can't send token to the user, like user has no email
+	ErrChannelUnavailable = &auth.Error{Code: "phone_channel_unavailable", Status: http.StatusUnprocessableEntity, Message: "Code can't be sent this way, please choose another one", MessageID: "phone.errors.channel_unavailable"}
 )
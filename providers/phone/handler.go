@@ -1,8 +1,7 @@
 package phone
 
 import (
-	"errors"
-	"html/template"
+	"encoding/json"
 	"net/http"
 	"reflect"
 	"strings"
@@ -19,15 +18,12 @@ var (
 	//DefaultTokenMessage Message for Message
 	DefaultTokenMessage = "your code is {token}"
 	//ErrPhoneNumberRequired Error Message Phone Number Required
-	ErrPhoneNumberRequired = errors.New("Phone Number Required")
+	ErrPhoneNumberRequired = &auth.Error{Code: "phone_number_required", Status: http.StatusUnprocessableEntity, Message: "Phone Number Required", MessageID: "phone.errors.phone_number_required"}
 	//ErrPhoneNotFound Error Message Phone Number Required
-	ErrPhoneNotFound = errors.New("Sorry, it seems your phone number havent registered yet")
+	ErrPhoneNotFound = &auth.Error{Code: "phone_not_found", Status: http.StatusNotFound, Message: "Sorry, it seems your phone number havent registered yet", MessageID: "phone.errors.phone_not_found"}
 )
 
 func respondAfterLogged(claims *claims.Claims, context *auth.Context) {
-	// login user
-	context.Auth.Login(context.Writer, context.Request, claims)
-
 	responder.With("html", func() {
 		// write cookie
 		context.Auth.Redirector.Redirect(context.Writer, context.Request, "login")
@@ -69,25 +65,29 @@ var DefaultConfirmationFormHandler = func(context *auth.Context, confirm func(*a
 	)
 
 	if err == nil && claims != nil {
-		context.SessionStorer.Flash(w, req, session.Message{Message: "logged"})
-		respondAfterLogged(claims, context)
-		return
+		// login user
+		if err = context.Auth.Login(w, req, claims); err == nil {
+			context.SessionStorer.Flash(w, req, session.Message{Message: context.T("auth.flash.logged", "logged")})
+			respondAfterLogged(claims, context)
+			return
+		}
 	}
 
-	context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(w, req, err)
 
 	// error handling
 	responder.With("html", func() {
-		context.Auth.Config.Render.Execute("auth/confirmation/providers/phone", context, req, w)
+		_, funcs := confirmationFuncs(context)
+		context.Auth.Config.Render.Funcs(funcs).Execute("auth/confirmation/providers/phone", context, req, w)
 	}).With([]string{"json"}, func() {
-		// TODO write json error
+		context.Auth.WriteError(w, req, err)
 	}).Respond(context.Request)
 }
 
 func respondAfterRequestToken(claims *claims.Claims, context *auth.Context) {
 	responder.With("html", func() {
 		// write cookie
-		http.Redirect(context.Writer, context.Request, context.Auth.AuthURL("phone/confirmation"), http.StatusFound)
+		http.Redirect(context.Writer, context.Request, context.AuthURL("phone/confirmation"), http.StatusFound)
 	}).With([]string{"json"}, func() {
 		// TODO write json token
 	}).Respond(context.Request)
@@ -102,41 +102,78 @@ var DefaultLoginFormHandler = func(context *auth.Context, authorize func(*auth.C
 	)
 
 	if err == nil && claims != nil {
-		req.ParseForm()
-		context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(req.Form.Get("phone_number")), Type: "phone_number"})
+		flashPhoneNumber(context, claims.Id)
 		respondAfterRequestToken(claims, context)
 		return
 	}
 
-	context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(w, req, err)
 
 	// error handling
 	responder.With("html", func() {
 		context.Auth.Config.Render.Execute("auth/login/providers/phone", context, req, w)
 	}).With([]string{"json"}, func() {
-		// TODO write json error
+		context.Auth.WriteError(w, req, err)
 	}).Respond(context.Request)
 }
 
+// DefaultResendTokenHandler default resend token behaviour, token is sent to `phone_number` again, with `channel` if chosen, like sending it by voice call if SMS didn't arrive
+var DefaultResendTokenHandler = func(context *auth.Context) {
+	var (
+		req         = context.Request
+		w           = context.Writer
+		provider, _ = context.Provider.(*Provider)
+	)
+
+	req.ParseForm()
+	phoneNumber := strings.TrimSpace(req.Form.Get("phone_number"))
+
+	authInfo, err := context.Auth.IdentityStore.Find(req, provider.GetName(), phoneNumber)
+	if err == auth.ErrIdentityNotFound {
+		err = ErrPhoneNotFound
+	}
+
+	if err == nil {
+		// only registered phone number is flashed for confirmation page
+		flashPhoneNumber(context, authInfo.UID)
+		err = provider.Config.SendTokenHandler(authInfo.UID, context, context.Auth.GetDB(req))
+	}
+
+	if err != nil {
+		context.Auth.FlashError(w, req, err)
+	}
+
+	responder.With("html", func() {
+		http.Redirect(w, req, context.AuthURL("phone/confirmation"), http.StatusFound)
+	}).With([]string{"json"}, func() {
+		if err != nil {
+			context.Auth.WriteError(w, req, err)
+			return
+		}
+
+		var channel string
+		if token, err := context.Auth.TokenStore.Find(req, authInfo.UID); err == nil {
+			channel = token.Channel
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"phone_number": authInfo.UID, "channel": channel})
+	}).Respond(req)
+}
+
 // DefaultAuthorizeHandler default authorize handler
 var DefaultAuthorizeHandler = func(context *auth.Context) (*claims.Claims, error) {
 	var (
-		authInfo    auth_identity.Basic
 		req         = context.Request
 		tx          = context.Auth.GetDB(req)
 		provider, _ = context.Provider.(*Provider)
 	)
 
 	req.ParseForm()
-	authInfo.Provider = provider.GetName()
-	authInfo.UID = strings.TrimSpace(req.Form.Get("phone_number"))
-
-	if tx.Model(context.Auth.AuthIdentityModel).Where(
-		map[string]interface{}{
-			"provider": authInfo.Provider,
-			"uid":      authInfo.UID,
-		}).Scan(&authInfo).RecordNotFound() {
+	authInfo, err := context.Auth.IdentityStore.Find(req, provider.GetName(), strings.TrimSpace(req.Form.Get("phone_number")))
+	if err == auth.ErrIdentityNotFound {
 		return nil, ErrPhoneNotFound
+	} else if err != nil {
+		return nil, err
 	}
 
 	if err := provider.Config.SendTokenHandler(authInfo.UID, context, tx); err != nil {
@@ -155,19 +192,18 @@ var DefaultRegisterFormHandler = func(context *auth.Context, register func(*auth
 	)
 
 	if err == nil && claims != nil {
-		req.ParseForm()
-		context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(req.Form.Get("phone_number")), Type: "phone_number"})
+		flashPhoneNumber(context, claims.Id)
 		respondAfterRequestToken(claims, context)
 		return
 	}
 
-	context.SessionStorer.Flash(w, req, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(w, req, err)
 
 	// error handling
 	responder.With("html", func() {
 		context.Auth.Config.Render.Execute("auth/register/providers/phone", context, req, w)
 	}).With([]string{"json"}, func() {
-		// TODO write json error
+		context.Auth.WriteError(w, req, err)
 	}).Respond(context.Request)
 }
 
@@ -213,13 +249,7 @@ var DefaultRegisterHandler = func(context *auth.Context) (*claims.Claims, error)
 	}
 
 	// create auth identity
-	authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-	if err = tx.Where(map[string]interface{}{
-		"provider":           authInfo.Provider,
-		"uid":                authInfo.UID,
-		"encrypted_password": authInfo.EncryptedPassword,
-		"user_id":            authInfo.UserID,
-	}).FirstOrCreate(authIdentity).Error; err != nil {
+	if err = context.Auth.IdentityStore.Create(req, &authInfo); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,23 @@
+package phone
+
+import (
+	"html/template"
+
+	"github.com/fahmibaswara/auth"
+)
+
+func registerMailPreviews(Auth *auth.Auth) {
+	Auth.RegisterMailPreview("phone_token", func(context *auth.Context) auth.Mail {
+		return auth.Mail{
+			Name:     "phone_token",
+			Template: "auth/phone_token",
+			Subject:  TokenMailSubject,
+			To:       "user@example.org",
+			Funcs: template.FuncMap{
+				"token_message": func() string {
+					return context.Auth.Translate(context.Request, "phone.token_message", DefaultTokenMessage)
+				},
+			},
+		}
+	})
+}
@@ -1,20 +1,29 @@
 package phone
 
 import (
-	"html/template"
-	"strings"
+	"time"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/claims"
 	"github.com/jinzhu/gorm"
-	"github.com/qor/session"
 )
 
 // Config phone provider config
 type Config struct {
 	SendTokenHandler func(phonenumber string, context *auth.Context, DB *gorm.DB) error
 	CheckAuthToken   func(phonenumber string, token string, context *auth.Context, DB *gorm.DB) (*claims.Claims, error)
-	TokenMessage     string
+	// TokenMessage message of token, `{token}` will be replaced with token, if blank, translation of `phone.token_message` or DefaultTokenMessage will be used, it is used for channels that don't have message in TokenMessages or DefaultTokenMessages
+	TokenMessage string
+	// TokenMessages messages of token with channel name as key, like `{"voice": "Your code is {spoken_token}"}`, if blank, channel's message in DefaultTokenMessages translated with `phone.token_message.{channel}` will be used
+	TokenMessages map[string]string
+	// Channels channels to send token, default is SMS with Auth's SMSSender, refer SenderChannel, EmailChannel
+	Channels []Channel
+	// ChannelPolicy choose channels to send token, the token is sent with the first channel that succeeded, default is DefaultChannelPolicy
+	ChannelPolicy func(phoneNumber string, context *auth.Context) ([]Channel, error)
+	// MaxTokenAttempts how many times tokens of a phone number could fail to be checked until the token expires, failed attempts are kept when the token is resent, default is 5
+	MaxTokenAttempts int
+	// ResendInterval how long to wait before a token could be sent to same phone number again, default is 1 minute
+	ResendInterval time.Duration
 
 	AuthorizeHandler    func(*auth.Context) (*claims.Claims, error)
 	TokenConfirmHandler func(*auth.Context) (*claims.Claims, error)
@@ -42,12 +51,24 @@ func New(config *Config) *Provider {
 		config.CheckAuthToken = DefaultCheckToken
 	}
 
-	if config.SendTokenHandler == nil {
-		config.SendTokenHandler = DefaultSendTokenHandler
+	if config.MaxTokenAttempts <= 0 {
+		config.MaxTokenAttempts = 5
+	}
+
+	if config.ResendInterval <= 0 {
+		config.ResendInterval = time.Minute
 	}
 
-	if config.TokenMessage == "" {
-		config.TokenMessage = DefaultTokenMessage
+	if len(config.Channels) == 0 {
+		config.Channels = []Channel{&SenderChannel{Name: "sms"}}
+	}
+
+	if config.ChannelPolicy == nil {
+		config.ChannelPolicy = DefaultChannelPolicy
+	}
+
+	if config.SendTokenHandler == nil {
+		config.SendTokenHandler = DefaultSendTokenHandler
 	}
 
 	if config.AuthorizeHandler == nil {
@@ -69,6 +90,19 @@ func (Provider) GetName() string {
 // ConfigAuth config auth
 func (provider Provider) ConfigAuth(auth *auth.Auth) {
 	auth.Render.RegisterViewPath("github.com/fahmibaswara/auth/providers/phone/views")
+
+	if auth.Mailer != nil {
+		auth.Mailer.RegisterViewPath("github.com/fahmibaswara/auth/providers/phone/views/mailers")
+	}
+
+	auth.Render.RegisterFuncMap("phone_channels", func() (names []string) {
+		for _, channel := range provider.Channels {
+			names = append(names, channel.GetName())
+		}
+		return
+	})
+
+	registerMailPreviews(auth)
 }
 
 // Login implemented login with phone provider
@@ -76,66 +110,12 @@ func (provider Provider) Login(context *auth.Context) {
 	DefaultLoginFormHandler(context, provider.AuthorizeHandler)
 }
 
-// Logout implemented logout with phone provider
-func (provider Provider) Logout(context *auth.Context) {
-	context.Auth.LogoutHandler(context)
-}
-
 // Register implemented register with phone provider
 func (provider Provider) Register(context *auth.Context) {
 	DefaultRegisterFormHandler(context, provider.RegisterHandler)
 }
 
-// Callback implement Callback with password provider
-func (provider Provider) Callback(context *auth.Context) {
-}
-
-// ServeHTTP implement ServeHTTP with phone provider
-func (provider Provider) ServeHTTP(context *auth.Context) {
-	var (
-		req         = context.Request
-		reqPath     = strings.TrimPrefix(req.URL.Path, context.Auth.URLPrefix)
-		paths       = strings.Split(reqPath, "/")
-		phoneNumber template.HTML
-	)
-
-	flases := context.SessionStorer.Flashes(context.Writer, context.Request)
-	for _, msg := range flases {
-		if msg.Type == "phone_number" {
-			phoneNumber = msg.Message
-		}
-	}
-
-	if len(paths) >= 2 {
-		switch paths[1] {
-		case "new":
-			// render change password page
-			context.Auth.Config.Render.Execute("auth/providers/new/phone", context, context.Request, context.Writer)
-			break
-		case "confirmation":
-			if len(paths) >= 3 {
-				switch paths[2] {
-				case "resend":
-					// render new confirmation page
-					context.Auth.Config.Render.Execute("auth/login/providers/phone", context, context.Request, context.Writer)
-				case "check":
-					DefaultConfirmationFormHandler(context, provider.TokenConfirmHandler)
-				}
-			}
-
-			if phoneNumber == "" {
-				context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: "Please Resubmit Phone Number"})
-				context.Auth.Redirector.Redirect(context.Writer, context.Request, "missing_phone_number")
-				return
-			}
-
-			context.Auth.Config.Render.RegisterFuncMap("req_phone_number", func() template.HTML { return phoneNumber })
-
-			// render new confirmation page
-			context.Auth.Config.Render.Execute("auth/confirmation/providers/phone", context, context.Request, context.Writer)
-			break
-		}
-	}
-
-	return
+// Metadata return metadata of phone provider
+func (provider Provider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "Phone", Kind: "phone"}
 }
@@ -0,0 +1,153 @@
+package phone_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/authtest"
+	"github.com/fahmibaswara/auth/providers/phone"
+)
+
+func newPhoneAuth(t *testing.T) (*authtest.Auth, *authtest.Client) {
+	Auth := authtest.New(nil)
+	Auth.RegisterProvider(phone.New(nil))
+	server := Auth.NewServer(nil)
+	t.Cleanup(server.Close)
+
+	if _, err := Auth.CreateIdentity("phone", "+628111", ""); err != nil {
+		t.Fatalf("failed to create identity, got %v", err)
+	}
+	return Auth, Auth.NewClient(server)
+}
+
+func postJSON(client *authtest.Client, path string, values url.Values) (int, string) {
+	req, _ := http.NewRequest("POST", client.Server.URL+path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	var result auth.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Code
+}
+
+func TestCheckTokenAttempts(t *testing.T) {
+	Auth, client := newPhoneAuth(t)
+	client.PostForm(Auth.AuthURL("phone/login"), url.Values{"phone_number": {"+628111"}})
+	token := Auth.SMS.LastCode("+628111")
+
+	wrong := "000000"
+	if token == wrong {
+		wrong = "111111"
+	}
+
+	for i := 1; i < 5; i++ {
+		if _, code := postJSON(client, Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+628111"}, "token": {wrong}}); code != auth.ErrInvalidAccount.Code {
+			t.Errorf("attempt %v should fail with %v, got %v", i, auth.ErrInvalidAccount.Code, code)
+		}
+	}
+
+	if status, code := postJSON(client, Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+628111"}, "token": {wrong}}); status != http.StatusTooManyRequests || code != phone.ErrTooManyAttempts.Code {
+		t.Errorf("last attempt should fail with %v, got %v %v", phone.ErrTooManyAttempts.Code, status, code)
+	}
+
+	if _, code := postJSON(client, Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+628111"}, "token": {token}}); code != phone.ErrTooManyAttempts.Code {
+		t.Errorf("token couldn't be used after too many attempts, got %v", code)
+	}
+}
+
+func TestResendToken(t *testing.T) {
+	Auth, client := newPhoneAuth(t)
+	client.PostForm(Auth.AuthURL("phone/login"), url.Values{"phone_number": {"+628111"}})
+
+	resend := func() string {
+		_, code := postJSON(client, Auth.AuthURL("phone/confirmation/resend"), url.Values{"phone_number": {"+628111"}})
+		return code
+	}
+
+	check := func(token string) string {
+		_, code := postJSON(client, Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+628111"}, "token": {token}})
+		return code
+	}
+
+	if code := resend(); code != phone.ErrResendTooSoon.Code || len(Auth.SMS.Messages()) != 1 {
+		t.Errorf("token shouldn't be resent before ResendInterval, got %v, %v messages", code, len(Auth.SMS.Messages()))
+	}
+
+	for i := 0; i < 4; i++ {
+		check("wrong")
+	}
+
+	Auth.Clock.Add(time.Minute)
+	if code := resend(); code != "" || len(Auth.SMS.Messages()) != 2 {
+		t.Errorf("token should be resent after ResendInterval, got %v, %v messages", code, len(Auth.SMS.Messages()))
+	}
+
+	if code := check("wrong"); code != phone.ErrTooManyAttempts.Code {
+		t.Errorf("failed attempts should be kept after resent, got %v", code)
+	}
+
+	Auth.Clock.Add(time.Minute)
+	if code := resend(); code != phone.ErrTooManyAttempts.Code || len(Auth.SMS.Messages()) != 2 {
+		t.Errorf("token shouldn't be resent after too many attempts, got %v, %v messages", code, len(Auth.SMS.Messages()))
+	}
+
+	Auth.Clock.Add(3 * time.Hour)
+	if code := resend(); code != "" || check(Auth.SMS.LastCode("+628111")) != "" {
+		t.Errorf("new token should be sent and used after previous one expired, got %v", code)
+	}
+}
+
+func TestTokenUsedOnce(t *testing.T) {
+	Auth, client := newPhoneAuth(t)
+
+	resp, err := client.PhoneLogin("+628111")
+	if err != nil || resp.StatusCode >= 400 {
+		t.Fatalf("should sign in with phone, got %v %v", resp, err)
+	}
+
+	if _, err := Auth.TokenStore.Find(nil, "+628111"); err != auth.ErrTokenNotFound {
+		t.Errorf("token should be deleted after used, got %v", err)
+	}
+
+	if _, code := postJSON(client, Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {"+628111"}, "token": {Auth.SMS.LastCode("+628111")}}); code != auth.ErrInvalidAccount.Code {
+		t.Errorf("token should be used only once, got %v", code)
+	}
+}
+
+func TestConfirmationPagePhoneNumber(t *testing.T) {
+	Auth, client := newPhoneAuth(t)
+	script := "<script>alert(1)</script>"
+
+	// submitted phone number is escaped when re-rendering confirmation page
+	resp, _ := client.PostForm(Auth.AuthURL("phone/confirmation/check"), url.Values{"phone_number": {script}, "token": {"123456"}})
+	body, _ := ioutil.ReadAll(resp.Body)
+	if strings.Contains(string(body), script) {
+		t.Errorf("submitted phone number should be escaped, got %v", string(body))
+	}
+
+	// unregistered phone number is not flashed for confirmation page
+	client.PostForm(Auth.AuthURL("phone/confirmation/resend"), url.Values{"phone_number": {script}})
+	if resp, _ = client.Get(Auth.AuthURL("phone/confirmation")); resp.StatusCode == http.StatusOK {
+		t.Errorf("confirmation page should require a registered phone number")
+	}
+
+	// registered phone number is flashed for confirmation page
+	client.PostForm(Auth.AuthURL("phone/confirmation/resend"), url.Values{"phone_number": {"+628111"}})
+	resp, _ = client.Get(Auth.AuthURL("phone/confirmation"))
+	body, _ = ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `value="&#43;628111"`) {
+		t.Errorf("confirmation page should be rendered with phone number, got %v %v", resp.StatusCode, string(body))
+	}
+}
@@ -0,0 +1,102 @@
+package phone
+
+import (
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/qor/session"
+)
+
+// RegisterRoutes register routes of phone provider
+func (provider Provider) RegisterRoutes(Auth *auth.Auth) {
+	name := provider.GetName()
+	for _, route := range []auth.Route{
+		{Method: "GET", Path: "new", Description: "render phone login page", Handler: provider.newPage},
+		{Method: "GET", Path: "confirmation", Description: "render token confirmation page", Handler: provider.confirmationPage},
+		{Method: "GET", Path: "confirmation/resend", Description: "render phone login page to resend token", Handler: provider.resendPage},
+		{Method: "POST", Path: "confirmation/resend", Description: "resend token, `channel` param chooses channel", Handler: provider.resendToken},
+		{Method: "POST", Path: "confirmation/check", Description: "check token and login", Handler: provider.checkToken},
+	} {
+		route.Path = name + "/" + route.Path
+		route.Provider = name
+		Auth.AddRoute(route)
+	}
+}
+
+func (provider Provider) newPage(context *auth.Context) {
+	context.Auth.Config.Render.Execute("auth/providers/new/phone", context, context.Request, context.Writer)
+}
+
+func (provider Provider) confirmationPage(context *auth.Context) {
+	phoneNumber, funcs := confirmationFuncs(context)
+	if phoneNumber == "" {
+		context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: context.T("phone.flash.resubmit_phone_number", "Please Resubmit Phone Number")})
+		context.Auth.Redirector.Redirect(context.Writer, context.Request, "missing_phone_number")
+		return
+	}
+
+	// render new confirmation page
+	context.Auth.Config.Render.Funcs(funcs).Execute("auth/confirmation/providers/phone", context, context.Request, context.Writer)
+}
+
+func (provider Provider) resendPage(context *auth.Context) {
+	_, funcs := confirmationFuncs(context)
+	context.Auth.Config.Render.Funcs(funcs).Execute("auth/login/providers/phone", context, context.Request, context.Writer)
+}
+
+func (provider Provider) resendToken(context *auth.Context) {
+	DefaultResendTokenHandler(context)
+}
+
+func (provider Provider) checkToken(context *auth.Context) {
+	DefaultConfirmationFormHandler(context, provider.TokenConfirmHandler)
+}
+
+// flashPhoneNumber flash phone number for confirmation page, it should be a registered phone number, it is escaped as flashes are HTML
+func flashPhoneNumber(context *auth.Context, phoneNumber string) {
+	context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: template.HTML(template.HTMLEscapeString(phoneNumber)), Type: "phone_number"})
+}
+
+// confirmationFuncs return funcs `req_phone_number`, `phone_token_channel` for confirmation pages, and phone number flashed by login form or submitted
+func confirmationFuncs(context *auth.Context) (string, template.FuncMap) {
+	var (
+		req         = context.Request
+		phoneNumber string
+	)
+
+	flases := context.SessionStorer.Flashes(context.Writer, context.Request)
+	for _, msg := range flases {
+		if msg.Type == "phone_number" {
+			phoneNumber = html.UnescapeString(string(msg.Message))
+		} else {
+			// keep other messages, like errors of resending token, for rendered pages
+			context.SessionStorer.Flash(context.Writer, context.Request, msg)
+		}
+	}
+
+	if phoneNumber == "" && req.Method == "POST" {
+		// confirmation page is rendered with submitted phone number if failed to check token
+		phoneNumber = strings.TrimSpace(req.FormValue("phone_number"))
+	}
+
+	return phoneNumber, template.FuncMap{
+		"req_phone_number": func() string { return phoneNumber },
+		"phone_token_channel": func() string {
+			if token, err := context.Auth.TokenStore.Find(req, phoneNumber); err == nil {
+				return token.Channel
+			}
+			return ""
+		},
+		// channels of request's provider, which could be a provider of tenant
+		"phone_channels": func() (names []string) {
+			if provider, ok := context.Provider.(*Provider); ok {
+				for _, channel := range provider.Channels {
+					names = append(names, channel.GetName())
+				}
+			}
+			return
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package phone
+
+import (
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+	"github.com/fahmibaswara/auth/claims"
+)
+
+// ChallengeSecondFactor send token to phone number of claims' user, implements auth.SecondFactor
+func (provider Provider) ChallengeSecondFactor(context *auth.Context, claims *claims.Claims) error {
+	phoneNumber, err := provider.phoneNumberOf(context, claims)
+	if err != nil {
+		return err
+	}
+
+	context.Provider = &provider
+	return provider.SendTokenHandler(phoneNumber, context, context.Auth.GetDB(context.Request))
+}
+
+// VerifySecondFactor check token submitted with param `token`, implements auth.SecondFactor, the token can't be used again after verified
+func (provider Provider) VerifySecondFactor(context *auth.Context, claims *claims.Claims) error {
+	phoneNumber, err := provider.phoneNumberOf(context, claims)
+	if err != nil {
+		return err
+	}
+
+	context.Provider = &provider
+	if _, err = provider.CheckAuthToken(phoneNumber, strings.TrimSpace(context.Request.FormValue("token")), context, context.Auth.GetDB(context.Request)); err != nil {
+		return err
+	}
+	return context.Auth.TokenStore.Delete(context.Request, phoneNumber)
+}
+
+// phoneNumberOf find phone number of claims' user
+func (provider Provider) phoneNumberOf(context *auth.Context, claims *claims.Claims) (string, error) {
+	identity, err := context.Auth.IdentityStore.FindByUserID(context.Request, provider.GetName(), claims.UserID)
+	if err == auth.ErrIdentityNotFound {
+		return "", ErrPhoneNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return identity.UID, nil
+}
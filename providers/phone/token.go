@@ -1,9 +1,9 @@
 package phone
 
 import (
-	"math/rand"
-	"reflect"
-	"strings"
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -11,80 +11,113 @@ import (
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
-	"github.com/qor/qor/utils"
 )
 
-var ()
-
-// DefaultSendTokenHandler default Token Verification Sender
+// DefaultSendTokenHandler default Token Verification Sender, token is sent with channels chosen by ChannelPolicy in order, until one of them succeeded, it couldn't be sent again before ResendInterval, failed attempts of previous token are kept until it expires
 var DefaultSendTokenHandler = func(phonenumber string, context *auth.Context, tx *gorm.DB) error {
 	var (
-		err         error
 		provider, _ = context.Provider.(*Provider)
+		now         = context.Auth.Now()
+		validUntil  = now.Add(time.Hour * 3)
+		attempts    int
 	)
-	tokenIdentity := reflect.New(utils.ModelType(context.Auth.Config.UserTokenModel)).Interface()
-	if err = tx.Where(map[string]interface{}{
-		"identity": phonenumber,
-	}).FirstOrCreate(tokenIdentity).Error; err != nil {
-		return auth.ErrInvalidAccount
-	}
-	token := generateToken(6)
 
-	tx.Model(tokenIdentity).Where("identity = ?", phonenumber).Update(map[string]interface{}{
-		"token":      token,
-		"validUntil": time.Now().Add(time.Hour * 3),
-	})
+	if previous, err := context.Auth.TokenStore.Find(context.Request, phonenumber); err == nil && previous.ValidUntil != nil && now.Before(*previous.ValidUntil) {
+		if previous.Attempts >= provider.Config.MaxTokenAttempts {
+			return ErrTooManyAttempts
+		}
 
-	message := strings.NewReplacer(
-		"{token}", token,
-	).Replace(provider.Config.TokenMessage)
+		if previous.SentAt != nil && now.Before(previous.SentAt.Add(provider.Config.ResendInterval)) {
+			return ErrResendTooSoon
+		}
+		attempts = previous.Attempts
+	}
+
+	token, err := generateToken(6)
+	if err != nil {
+		return err
+	}
 
-	if err = context.Auth.SMSSender.Send(phonenumber, message); err != nil {
+	channels, err := provider.Config.ChannelPolicy(phonenumber, context)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if len(channels) == 0 {
+		return ErrInvalidChannel
+	}
+
+	for _, channel := range channels {
+		// token is saved after it is sent, so previous token still works if all channels failed
+		if err = channel.Send(phonenumber, provider.TokenMessageOf(channel.GetName(), token, context), context); err == nil {
+			return context.Auth.TokenStore.Save(context.Request, &auth_identity.AuthToken{
+				Identity:   phonenumber,
+				Token:      token,
+				ValidUntil: &validUntil,
+				Channel:    channel.GetName(),
+				Attempts:   attempts,
+				SentAt:     &now,
+			})
+		}
+	}
+
+	return err
 }
 
-// DefaultCheckToken default confirmation handler
+// DefaultCheckToken default confirmation handler, token is deleted after it is used, it couldn't be used after failed to check MaxTokenAttempts times
 var DefaultCheckToken = func(phonenumber string, token string, context *auth.Context, DB *gorm.DB) (*claims.Claims, error) {
 	var (
-		authInfo      auth_identity.Basic
-		tokenIdentity auth_identity.AuthToken
-		provider, _   = context.Provider.(*Provider)
+		req         = context.Request
+		provider, _ = context.Provider.(*Provider)
 	)
 
-	if DB.Model(context.Auth.Config.UserTokenModel).Where(map[string]interface{}{
-		"identity": phonenumber,
-		"token":    token,
-	}).Scan(&tokenIdentity).RecordNotFound() {
+	tokenIdentity, err := context.Auth.TokenStore.Find(req, phonenumber)
+	if err != nil {
 		return nil, auth.ErrInvalidAccount
 	}
 
-	now := time.Now()
-	if now.After(*tokenIdentity.ValidUntil) {
-		return nil, ErrTokenExpired
+	// token is kept after too many failed attempts, so it couldn't be brute forced, or resent to reset the count, until it expires
+	if tokenIdentity.Attempts >= provider.Config.MaxTokenAttempts {
+		return nil, ErrTooManyAttempts
 	}
 
-	authInfo.Provider = provider.GetName()
-	authInfo.UID = phonenumber
+	if subtle.ConstantTimeCompare([]byte(tokenIdentity.Token), []byte(token)) != 1 {
+		tokenIdentity.Attempts++
+		if err = context.Auth.TokenStore.Save(req, tokenIdentity); err != nil {
+			return nil, err
+		}
 
-	if DB.Model(context.Auth.AuthIdentityModel).Where(
-		map[string]interface{}{
-			"provider": authInfo.Provider,
-			"uid":      authInfo.UID,
-		}).Scan(&authInfo).RecordNotFound() {
+		if tokenIdentity.Attempts >= provider.Config.MaxTokenAttempts {
+			return nil, ErrTooManyAttempts
+		}
 		return nil, auth.ErrInvalidAccount
 	}
 
+	now := context.Auth.Now()
+	if tokenIdentity.ValidUntil == nil || now.After(*tokenIdentity.ValidUntil) {
+		return nil, ErrTokenExpired
+	}
+
+	authInfo, err := context.Auth.IdentityStore.Find(req, provider.GetName(), phonenumber)
+	if err != nil {
+		return nil, auth.ErrInvalidAccount
+	}
+
+	if err = context.Auth.TokenStore.Delete(req, phonenumber); err != nil {
+		return nil, err
+	}
 	return authInfo.ToClaims(), nil
 }
 
-func generateToken(len int) string {
-	rand.Seed(time.Now().UnixNano())
-	a := make([]byte, len)
-	for i := 0; i <= len-1; i++ {
-		a[i] = byte(48 + rand.Intn(10)) // 48 (ascii) -> 0
+// generateToken generate numeric token with crypto/rand
+func generateToken(length int) (string, error) {
+	a := make([]byte, length)
+	for i := range a {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		a[i] = byte('0' + n.Int64())
 	}
-	return string(a)
+	return string(a), nil
 }
@@ -3,17 +3,13 @@ package twitter
 import (
 	"encoding/json"
 	"errors"
-	"html/template"
 	"io/ioutil"
 	"net/http"
-	"reflect"
 
 	"github.com/fahmibaswara/auth"
 	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
 	"github.com/mrjones/oauth"
-	"github.com/qor/qor/utils"
-	"github.com/qor/session"
 )
 
 var UserInfoURL = "https://api.twitter.com/1.1/account/verify_credentials.json?include_email=true"
@@ -57,11 +53,9 @@ func New(config *Config) *Provider {
 				requestToken = &oauth.RequestToken{}
 				consumer     = provider.NewConsumer(context)
 				oauthToken   = context.Request.URL.Query().Get("oauth_verifier")
-				authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-				tx           = context.Auth.GetDB(context.Request)
 			)
 
-			Claims, err := provider.Auth.Get(context.Request)
+			Claims, err := context.Auth.Get(context.Request)
 			if err != nil {
 				return nil, err
 			}
@@ -104,11 +98,10 @@ func New(config *Config) *Provider {
 			authInfo.Provider = provider.GetName()
 			authInfo.UID = schema.UID
 
-			if !tx.Model(authIdentity).Where(map[string]interface{}{
-				"provider": authInfo.Provider,
-				"uid":      authInfo.UID,
-			}).Scan(&authInfo).RecordNotFound() {
-				return authInfo.ToClaims(), nil
+			if identity, err := context.Auth.IdentityStore.Find(context.Request, authInfo.Provider, authInfo.UID); err == nil {
+				return identity.ToClaims(), nil
+			} else if err != auth.ErrIdentityNotFound {
+				return nil, err
 			}
 
 			if _, userID, err := context.Auth.UserStorer.Save(&schema, context); err == nil {
@@ -119,10 +112,7 @@ func New(config *Config) *Provider {
 				return nil, err
 			}
 
-			if err = tx.Where(map[string]interface{}{
-				"provider": authInfo.Provider,
-				"uid":      authInfo.UID,
-			}).FirstOrCreate(authIdentity).Error; err == nil {
+			if err = context.Auth.IdentityStore.Create(context.Request, &authInfo); err == nil {
 				return authInfo.ToClaims(), nil
 			}
 
@@ -164,30 +154,26 @@ func (provider Provider) Login(context *auth.Context) {
 		scheme = "http://"
 	}
 
-	requestToken, u, err := consumer.GetRequestTokenAndUrl(scheme + context.Request.Host + context.Auth.AuthURL("twitter/callback"))
+	requestToken, u, err := consumer.GetRequestTokenAndUrl(scheme + context.Request.Host + context.AuthURL("twitter/callback"))
 
 	if err == nil {
 		// save requestToken into session
 		Claims := &claims.Claims{}
-		if c, err := provider.Auth.Get(context.Request); err == nil {
+		if c, err := context.Auth.Get(context.Request); err == nil {
 			Claims = c
 		}
 		tokenStr, _ := json.Marshal(requestToken)
 		Claims.Issuer = string(tokenStr)
-		provider.Auth.Update(context.Writer, context.Request, Claims)
+		context.Auth.Update(context.Writer, context.Request, Claims)
 
 		http.Redirect(context.Writer, context.Request, u, http.StatusFound)
 		return
 	}
 
-	context.SessionStorer.Flash(context.Writer, context.Request, session.Message{Message: template.HTML(err.Error()), Type: "error"})
+	context.Auth.FlashError(context.Writer, context.Request, err)
 	context.Auth.Config.Render.Execute("auth/login", context, context.Request, context.Writer)
 }
 
-// Logout implemented logout with twitter provider
-func (Provider) Logout(context *auth.Context) {
-}
-
 // Register implemented register with twitter provider
 func (provider Provider) Register(context *auth.Context) {
 	provider.Login(context)
@@ -198,8 +184,9 @@ func (provider Provider) Callback(context *auth.Context) {
 	context.Auth.LoginHandler(context, provider.AuthorizeHandler)
 }
 
-// ServeHTTP implement ServeHTTP with twitter provider
-func (Provider) ServeHTTP(*auth.Context) {
+// Metadata return metadata of twitter provider
+func (Provider) Metadata() auth.ProviderMetadata {
+	return auth.ProviderMetadata{DisplayName: "Twitter", Kind: "oauth"}
 }
 
 // UserInfo twitter user info structure
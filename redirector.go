@@ -15,9 +15,18 @@ type RedirectorInterface interface {
 // Redirector default redirector
 type Redirector struct {
 	*redirect_back.RedirectBack
+	// Auth is used to redirect to saved `return_to` after login, refer Auth.SetReturnTo
+	Auth *Auth
 }
 
-// Redirect redirect back after action
+// Redirect redirect back after action, or saved `return_to` after login
 func (redirector Redirector) Redirect(w http.ResponseWriter, req *http.Request, action string) {
+	if action == "login" && redirector.Auth != nil {
+		if returnTo := redirector.Auth.PopReturnTo(w, req); returnTo != "" {
+			http.Redirect(w, req, returnTo, http.StatusSeeOther)
+			return
+		}
+	}
+
 	redirector.RedirectBack.RedirectBack(w, req)
 }
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SafeReturnTo return returnTo if it is a path of current site, like `/orders?page=2`, or blank, so users won't be redirected to other sites
+func SafeReturnTo(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		return ""
+	}
+
+	if u, err := url.Parse(returnTo); err != nil || u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	return returnTo
+}
+
+// SetReturnTo remember where to return after signed in, unsafe URLs are ignored, refer SafeReturnTo
+func (auth *Auth) SetReturnTo(w http.ResponseWriter, req *http.Request, returnTo string) {
+	if returnTo = SafeReturnTo(returnTo); returnTo != "" {
+		http.SetCookie(w, &http.Cookie{Name: auth.Config.ReturnToCookieName, Value: url.QueryEscape(returnTo), Path: "/", HttpOnly: true, Expires: time.Now().Add(time.Hour)})
+	}
+}
+
+// PopReturnTo get and forget where to return after signed in
+func (auth *Auth) PopReturnTo(w http.ResponseWriter, req *http.Request) string {
+	cookie, err := req.Cookie(auth.Config.ReturnToCookieName)
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: auth.Config.ReturnToCookieName, Path: "/", MaxAge: -1})
+	returnTo, _ := url.QueryUnescape(cookie.Value)
+	return SafeReturnTo(returnTo)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+)
+
+// RolesGetter could be implemented by UserModel to provide user's roles, which will be loaded into claims when login, if not implemented, value of UserModel's `Role` field (saved from Schema's Role when register) will be used
+type RolesGetter interface {
+	GetRoles() []string
+}
+
+// PermissionsGetter could be implemented by UserModel to provide user's permissions, which will be loaded into claims when login
+type PermissionsGetter interface {
+	GetPermissions() []string
+}
+
+func (auth *Auth) userHasRoles() bool {
+	model := auth.Config.UserModel
+	if model == nil {
+		model = auth.Config.AuthIdentityModel
+	}
+
+	record := reflect.New(utils.ModelType(model)).Interface()
+	if _, ok := record.(RolesGetter); ok {
+		return true
+	}
+
+	if _, ok := record.(PermissionsGetter); ok {
+		return true
+	}
+
+	field, ok := utils.ModelType(model).FieldByName("Role")
+	return ok && field.Type.Kind() == reflect.String
+}
+
+func userRoles(user interface{}) []string {
+	if getter, ok := user.(RolesGetter); ok {
+		return getter.GetRoles()
+	}
+
+	if value := reflect.Indirect(reflect.ValueOf(user)); value.Kind() == reflect.Struct {
+		if field := value.FieldByName("Role"); field.IsValid() && field.Kind() == reflect.String && field.String() != "" {
+			return []string{field.String()}
+		}
+	}
+	return nil
+}
+
+// LoadRoles load roles and permissions of claims' user into claims, refer RolesGetter, PermissionsGetter
+func (auth *Auth) LoadRoles(req *http.Request, claims *claims.Claims) error {
+	if !auth.rolesFromUser {
+		return nil
+	}
+
+	user, err := auth.loadUser(req, claims)
+	if err != nil {
+		return err
+	}
+
+	claims.Roles = userRoles(user)
+	claims.Permissions = nil
+	if getter, ok := user.(PermissionsGetter); ok {
+		claims.Permissions = getter.GetPermissions()
+	}
+
+	now := auth.Now()
+	claims.RolesLoadedAt = &now
+	return nil
+}
+
+// RefreshRoles reload roles and permissions of current session, call it after current user's roles changed
+func (auth *Auth) RefreshRoles(w http.ResponseWriter, req *http.Request) (*claims.Claims, error) {
+	claims, err := auth.Get(req)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	auth.InvalidateUser(claims)
+	if err = auth.LoadRoles(req, claims); err != nil {
+		return nil, err
+	}
+	return claims, auth.Update(w, req, claims)
+}
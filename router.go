@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RoutePattern format of path patterns of routers, route's params `:name` are formatted with Param, wildcard `*` is formatted to Wildcard
+type RoutePattern struct {
+	Param    func(name string) string
+	Wildcard string
+}
+
+var (
+	// ChiPattern patterns of github.com/go-chi/chi, like `/auth/mailers/preview/{name}`, `/auth/assets/*`
+	ChiPattern = RoutePattern{Param: func(name string) string { return "{" + name + "}" }, Wildcard: "*"}
+	// GorillaPattern patterns of github.com/gorilla/mux, like `/auth/mailers/preview/{name}`, `/auth/assets/{path:.*}`
+	GorillaPattern = RoutePattern{Param: func(name string) string { return "{" + name + "}" }, Wildcard: "{path:.*}"}
+	// EchoPattern patterns of github.com/labstack/echo, like `/auth/mailers/preview/:name`, `/auth/assets/*`
+	EchoPattern = RoutePattern{Param: func(name string) string { return ":" + name }, Wildcard: "*"}
+	// ServeMuxPattern patterns of http.ServeMux since Go 1.22, like `/auth/mailers/preview/{name}`, `/auth/assets/{path...}`, method need to be prefixed to the pattern, for old http.ServeMux, use HandleServeMux
+	ServeMuxPattern = RoutePattern{Param: func(name string) string { return "{" + name + "}" }, Wildcard: "{path...}"}
+)
+
+// Format format route's path to pattern with URLPrefix
+func (pattern RoutePattern) Format(prefix string, routePath string) string {
+	segments := splitPath(routePath)
+	for idx, segment := range segments {
+		if segment == "*" && idx == len(segments)-1 {
+			segments[idx] = pattern.Wildcard
+		} else if strings.HasPrefix(segment, ":") {
+			segments[idx] = pattern.Param(segment[1:])
+		}
+	}
+	return path.Join(append([]string{"/", prefix}, segments...)...)
+}
+
+// RouteHandler return http.Handler serves the route, params of the route are parsed from request's path, so it works with any router that routes the path to it, route with same method and path of request's tenant is served instead if there is, refer RegisterTenantProvider
+func (auth *Auth) RouteHandler(route Route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req, err := auth.resolveTenant(req)
+		if err != nil {
+			auth.WriteError(w, req, err)
+			return
+		}
+
+		served := &route
+		if tenantRoute := auth.tenantRoute(req, route); tenantRoute != nil {
+			served = tenantRoute
+		} else if route.tenant != "" {
+			http.NotFound(w, req)
+			return
+		}
+
+		params, ok := matchRoutePath(served.Path, strings.TrimPrefix(req.URL.Path, auth.URLPrefix))
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		auth.serveRoute(w, req, served, params)
+	})
+}
+
+// tenantRoute find route of request's tenant with same method and path
+func (auth *Auth) tenantRoute(req *http.Request, route Route) *Route {
+	routes := auth.tenantRoutes(req)
+	for idx, r := range routes {
+		if r.Method == route.Method && r.Path == route.Path {
+			return &routes[idx]
+		}
+	}
+	return nil
+}
+
+// allRoutes return routes and routes of all tenants, tenants' routes with same method and path as others are skipped
+func (auth *Auth) allRoutes() []Route {
+	var (
+		routes = auth.GetRoutes()
+		added  = map[[2]string]bool{}
+	)
+
+	for _, route := range routes {
+		added[[2]string{route.Method, route.Path}] = true
+	}
+
+	for tenant := range auth.tenants {
+		for _, route := range auth.GetTenantRoutes(tenant) {
+			if key := [2]string{route.Method, route.Path}; !added[key] {
+				added[key] = true
+				routes = append(routes, route)
+			}
+		}
+	}
+	return sortRoutes(routes)
+}
+
+// HandleRoutes register all routes into routers with handle, includes routes of tenants, pattern is route's full path formatted with format, method is blank for routes that accept any method, routes end with wildcard are registered with their base path too, as `assets/*` matches `assets`, refer README for examples of routers
+func (auth *Auth) HandleRoutes(format RoutePattern, handle func(method string, pattern string, handler http.Handler)) {
+	for _, route := range auth.allRoutes() {
+		handler := auth.RouteHandler(route)
+		if strings.HasSuffix("/"+route.Path, "/*") {
+			handle(route.Method, format.Format(auth.URLPrefix, strings.TrimSuffix(route.Path, "*")), handler)
+		}
+		handle(route.Method, format.Format(auth.URLPrefix, route.Path), handler)
+	}
+}
+
+// ChiRouter routers register handlers with method, like chi.Router
+type ChiRouter interface {
+	Handle(pattern string, handler http.Handler)
+	Method(method string, pattern string, handler http.Handler)
+}
+
+// HandleChi register all routes into chi router, so routes could work with chi's middlewares
+func (auth *Auth) HandleChi(router ChiRouter) {
+	auth.HandleRoutes(ChiPattern, func(method string, pattern string, handler http.Handler) {
+		if method == "" {
+			router.Handle(pattern, handler)
+		} else {
+			router.Method(method, pattern, handler)
+		}
+	})
+}
+
+// HandleServeMux register routes into http.ServeMux with patterns without methods and params, like `/auth/login`, `/auth/mailers/preview/`, requests are dispatched with Auth's route table, so other paths under URLPrefix could be served by other handlers of the mux
+func (auth *Auth) HandleServeMux(mux *http.ServeMux) {
+	var (
+		handler  = auth.NewServeMux()
+		patterns = map[string]bool{}
+	)
+
+	for _, route := range auth.allRoutes() {
+		var (
+			segments = splitPath(route.Path)
+			static   []string
+			pattern  string
+		)
+
+		for _, segment := range segments {
+			if segment == "*" || strings.HasPrefix(segment, ":") {
+				break
+			}
+			static = append(static, segment)
+		}
+
+		pattern = path.Join(append([]string{"/", auth.URLPrefix}, static...)...)
+		if len(static) < len(segments) {
+			pattern = strings.TrimSuffix(pattern, "/") + "/"
+		}
+
+		if !patterns[pattern] {
+			patterns[pattern] = true
+			mux.Handle(pattern, handler)
+		}
+	}
+}
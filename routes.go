@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Route route of Auth, routes are registered into Auth's route table with AddRoute, which is served by NewServeMux, or could be registered into other routers, refer HandleRoutes
+type Route struct {
+	// Method HTTP method, blank means any method
+	Method string
+	// Path path relative to URLPrefix, like `password/recover`, segments start with `:` are params, like `mailers/preview/:name`, last segment `*` matches rest of the path, including blank, like `assets/*`
+	Path string
+	// Provider name of provider that the route belongs to, the provider will be set to Context
+	Provider string
+	Handler  func(*Context)
+	// Description what the route does, used for docs
+	Description string
+	// tenant the route is only served for the tenant, refer RegisterTenantProvider
+	tenant string
+}
+
+// AddRoute add route into route table, route with same method and path will be replaced, static segments are preferred to params, params are preferred to `*` when matching requests
+func (auth *Auth) AddRoute(route Route) {
+	route.Method = strings.ToUpper(route.Method)
+	route.Path = strings.Trim(route.Path, "/")
+
+	for idx, r := range auth.routes {
+		if r.Method == route.Method && r.Path == route.Path {
+			auth.routes[idx] = route
+			return
+		}
+	}
+	auth.routes = append(auth.routes, route)
+}
+
+// GetRoutes return all routes sorted by path and method, like for docs and tests
+func (auth *Auth) GetRoutes() []Route {
+	return sortRoutes(auth.routes)
+}
+
+func sortRoutes(routes []Route) []Route {
+	routes = append([]Route{}, routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Param return param of matched route, like `name` of `mailers/preview/:name`, `*` for rest of path matched by wildcard
+func (context Context) Param(name string) string {
+	return context.Params[name]
+}
+
+// matchRoute find route for request with path relative to URLPrefix, routes of request's tenant are preferred, refer findRoute
+func (auth *Auth) matchRoute(req *http.Request, reqPath string) (route *Route, params map[string]string, allowed []string, status int) {
+	if routes := auth.tenantRoutes(req); len(routes) > 0 {
+		if route, params, allowed, status = findRoute(routes, req.Method, reqPath); status != http.StatusNotFound {
+			return
+		}
+	}
+	return findRoute(auth.routes, req.Method, reqPath)
+}
+
+// findRoute find route for method and path relative to URLPrefix, status is http.StatusNotFound if no route matched the path, http.StatusMethodNotAllowed if routes matched the path but not the method
+func findRoute(routes []Route, method string, reqPath string) (route *Route, params map[string]string, allowed []string, status int) {
+	var (
+		bestRank []int
+		matched  []int
+	)
+
+	for idx, r := range routes {
+		if _, ok := matchRoutePath(r.Path, reqPath); ok {
+			rank := routeRank(r.Path)
+			if compareRanks(rank, bestRank) < 0 || bestRank == nil {
+				bestRank, matched = rank, []int{idx}
+			} else if compareRanks(rank, bestRank) == 0 {
+				matched = append(matched, idx)
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil, nil, http.StatusNotFound
+	}
+
+	for _, idx := range matched {
+		r := routes[idx]
+		if r.Method == method || (r.Method == "GET" && method == "HEAD") {
+			route = &routes[idx]
+			break
+		} else if r.Method == "" && route == nil {
+			route = &routes[idx]
+		}
+		allowed = append(allowed, r.Method)
+	}
+
+	if route == nil {
+		return nil, nil, allowed, http.StatusMethodNotAllowed
+	}
+
+	params, _ = matchRoutePath(route.Path, reqPath)
+	return route, params, nil, http.StatusOK
+}
+
+// serveRoute serve request with route
+func (auth *Auth) serveRoute(w http.ResponseWriter, req *http.Request, route *Route, params map[string]string) {
+	req = WithRequestCache(req)
+	context := &Context{Auth: auth, Request: req, Writer: w, Params: params}
+	if route.Provider != "" {
+		context.Provider = auth.GetTenantProvider(req, route.Provider)
+	}
+	route.Handler(context)
+}
+
+// matchRoutePath match path relative to URLPrefix with route's path, returns params
+func matchRoutePath(routePath string, reqPath string) (map[string]string, bool) {
+	var (
+		params        = map[string]string{}
+		routeSegments = splitPath(routePath)
+		reqSegments   = splitPath(reqPath)
+	)
+
+	for idx, segment := range routeSegments {
+		if segment == "*" && idx == len(routeSegments)-1 {
+			if idx < len(reqSegments) {
+				params["*"] = strings.Join(reqSegments[idx:], "/")
+			}
+			return params, true
+		}
+
+		if idx >= len(reqSegments) {
+			return nil, false
+		}
+
+		if strings.HasPrefix(segment, ":") {
+			if reqSegments[idx] == "" {
+				return nil, false
+			}
+			params[segment[1:]] = reqSegments[idx]
+		} else if segment != reqSegments[idx] {
+			return nil, false
+		}
+	}
+
+	if len(routeSegments) != len(reqSegments) {
+		return nil, false
+	}
+	return params, true
+}
+
+// routeRank rank of route's segments, static segment is 0, param is 1, wildcard is 2, lower rank is preferred
+func routeRank(routePath string) []int {
+	var rank []int
+	for _, segment := range splitPath(routePath) {
+		switch {
+		case segment == "*":
+			rank = append(rank, 2)
+		case strings.HasPrefix(segment, ":"):
+			rank = append(rank, 1)
+		default:
+			rank = append(rank, 0)
+		}
+	}
+	return rank
+}
+
+func compareRanks(a []int, b []int) int {
+	for idx := 0; idx < len(a) && idx < len(b); idx++ {
+		if a[idx] != b[idx] {
+			return a[idx] - b[idx]
+		}
+	}
+	// longer route is more specific, except it ends with wildcard
+	return len(b) - len(a)
+}
+
+func splitPath(pth string) []string {
+	if pth = strings.Trim(pth, "/"); pth == "" {
+		return nil
+	}
+	return strings.Split(pth, "/")
+}
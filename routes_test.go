@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFindRoute(t *testing.T) {
+	routes := []Route{
+		{Method: "GET", Path: "login"},
+		{Method: "POST", Path: "password/login"},
+		{Method: "GET", Path: "password/new"},
+		{Method: "GET", Path: "password/:action"},
+		{Method: "GET", Path: "mailers/preview/:name"},
+		{Method: "GET", Path: "assets/*"},
+		{Method: "GET", Path: "assets/auth.css"},
+		{Path: "logout"},
+	}
+
+	cases := []struct {
+		method  string
+		path    string
+		route   string
+		params  map[string]string
+		status  int
+		allowed []string
+	}{
+		{method: "GET", path: "login", route: "login", status: http.StatusOK},
+		{method: "HEAD", path: "/login/", route: "login", status: http.StatusOK},
+		{method: "POST", path: "login", status: http.StatusMethodNotAllowed, allowed: []string{"GET"}},
+		{method: "GET", path: "password/edit", route: "password/:action", params: map[string]string{"action": "edit"}, status: http.StatusOK},
+		{method: "GET", path: "password/login", status: http.StatusMethodNotAllowed, allowed: []string{"POST"}},
+		{method: "GET", path: "password/new", route: "password/new", status: http.StatusOK},
+		{method: "GET", path: "mailers/preview/confirmation", route: "mailers/preview/:name", params: map[string]string{"name": "confirmation"}, status: http.StatusOK},
+		{method: "GET", path: "mailers/preview", status: http.StatusNotFound},
+		{method: "GET", path: "assets/auth.css", route: "assets/auth.css", status: http.StatusOK},
+		{method: "GET", path: "assets/js/auth.js", route: "assets/*", params: map[string]string{"*": "js/auth.js"}, status: http.StatusOK},
+		{method: "GET", path: "assets", route: "assets/*", params: map[string]string{}, status: http.StatusOK},
+		{method: "DELETE", path: "logout", route: "logout", status: http.StatusOK},
+		{method: "GET", path: "register", status: http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		route, params, allowed, status := findRoute(routes, c.method, c.path)
+		if status != c.status {
+			t.Errorf("%v %v: status should be %v, got %v", c.method, c.path, c.status, status)
+			continue
+		}
+
+		if c.route != "" && (route == nil || route.Path != c.route) {
+			t.Errorf("%v %v: should match %v, got %v", c.method, c.path, c.route, route)
+		}
+
+		for name, value := range c.params {
+			if params[name] != value {
+				t.Errorf("%v %v: param %v should be %v, got %v", c.method, c.path, name, value, params[name])
+			}
+		}
+
+		if len(allowed) != len(c.allowed) || len(allowed) > 0 && allowed[0] != c.allowed[0] {
+			t.Errorf("%v %v: allowed methods should be %v, got %v", c.method, c.path, c.allowed, allowed)
+		}
+	}
+}
+
+func TestRoutePatternFormat(t *testing.T) {
+	cases := []struct {
+		pattern  RoutePattern
+		path     string
+		expected string
+	}{
+		{pattern: ChiPattern, path: "mailers/preview/:name", expected: "/auth/mailers/preview/{name}"},
+		{pattern: ChiPattern, path: "assets/*", expected: "/auth/assets/*"},
+		{pattern: GorillaPattern, path: "assets/*", expected: "/auth/assets/{path:.*}"},
+		{pattern: EchoPattern, path: "mailers/preview/:name", expected: "/auth/mailers/preview/:name"},
+		{pattern: ServeMuxPattern, path: "assets/*", expected: "/auth/assets/{path...}"},
+		{pattern: ServeMuxPattern, path: "password/login", expected: "/auth/password/login"},
+	}
+
+	for _, c := range cases {
+		if pattern := c.pattern.Format("auth", c.path); pattern != c.expected {
+			t.Errorf("%v should be formatted to %v, got %v", c.path, c.expected, pattern)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fahmibaswara/auth/claims"
+	"github.com/qor/qor/utils"
+)
+
+// SessionRevokerInterface session revoker interface, used to invalidate sessions that issued before a time
+type SessionRevokerInterface interface {
+	// Revoke revoke all sessions of claims' user that issued before time
+	Revoke(req *http.Request, claims *claims.Claims, before time.Time) error
+	// IsRevoked check if claims' session has been revoked
+	IsRevoked(req *http.Request, claims *claims.Claims) bool
+}
+
+// SessionKey return key used to identify claims' user when revoke sessions
+func SessionKey(claims *claims.Claims) string {
+	if claims.TenantID != "" {
+		tenantClaims := *claims
+		tenantClaims.TenantID = ""
+		return "tenant:" + claims.TenantID + ":" + SessionKey(&tenantClaims)
+	}
+
+	if claims.UserID != "" {
+		return "user:" + claims.UserID
+	}
+	return claims.Provider + ":" + claims.Id
+}
+
+// RevokeSessions revoke all sessions of claims' user, if current session belongs to the user, it will be issued again, so only other sessions are signed out
+func (auth *Auth) RevokeSessions(w http.ResponseWriter, req *http.Request, claims *claims.Claims) error {
+	if auth.Config.SessionRevoker == nil {
+		return ErrSessionRevokerRequired
+	}
+
+	if err := auth.Config.SessionRevoker.Revoke(req, claims, auth.Now()); err != nil {
+		return err
+	}
+
+	if w != nil {
+		if currentClaims, err := auth.SessionStorer.ValidateClaims(auth.currentToken(req)); err == nil && SessionKey(currentClaims) == SessionKey(claims) {
+			now := auth.Now()
+			currentClaims.IssuedAt = now.Unix()
+			return auth.Update(w, req, currentClaims)
+		}
+	}
+	return nil
+}
+
+func (auth *Auth) currentToken(req *http.Request) string {
+	if storer, ok := auth.SessionStorer.(*SessionStorer); ok {
+		return storer.GetToken(req)
+	}
+	return req.Header.Get("Authorization")
+}
+
+// MemorySessionRevoker session revoker that keeps revocations in memory, only works for single process deployment
+type MemorySessionRevoker struct {
+	mutex       sync.RWMutex
+	revocations map[string]int64
+}
+
+// Revoke revoke all sessions of claims' user that issued before time
+func (revoker *MemorySessionRevoker) Revoke(req *http.Request, claims *claims.Claims, before time.Time) error {
+	revoker.mutex.Lock()
+	defer revoker.mutex.Unlock()
+
+	if revoker.revocations == nil {
+		revoker.revocations = map[string]int64{}
+	}
+	revoker.revocations[SessionKey(claims)] = before.Unix()
+	return nil
+}
+
+// IsRevoked check if claims' session has been revoked
+func (revoker *MemorySessionRevoker) IsRevoked(req *http.Request, claims *claims.Claims) bool {
+	revoker.mutex.RLock()
+	defer revoker.mutex.RUnlock()
+
+	if before, ok := revoker.revocations[SessionKey(claims)]; ok {
+		return claims.IssuedAt < before
+	}
+	return false
+}
+
+// DBSessionRevoker session revoker that saves revocations into database with SessionRevocationModel
+type DBSessionRevoker struct {
+	Auth *Auth
+	// SessionRevocationModel a model used to save revocations, https://github.com/fahmibaswara/auth/blob/master/auth_identity/session_revocation.go is the default implemention
+	SessionRevocationModel interface{}
+}
+
+// Revoke revoke all sessions of claims' user that issued before time
+func (revoker *DBSessionRevoker) Revoke(req *http.Request, claims *claims.Claims, before time.Time) error {
+	var (
+		tx         = revoker.Auth.GetDB(req)
+		revocation = reflect.New(utils.ModelType(revoker.SessionRevocationModel)).Interface()
+	)
+
+	return tx.Where(map[string]interface{}{
+		"session_key": SessionKey(claims),
+	}).Assign(map[string]interface{}{
+		"revoked_before": before.Unix(),
+	}).FirstOrCreate(revocation).Error
+}
+
+// IsRevoked check if claims' session has been revoked
+func (revoker *DBSessionRevoker) IsRevoked(req *http.Request, claims *claims.Claims) bool {
+	var (
+		tx         = revoker.Auth.GetDB(req)
+		revocation struct{ RevokedBefore int64 }
+	)
+
+	if tx.Model(revoker.SessionRevocationModel).Where(map[string]interface{}{
+		"session_key": SessionKey(claims),
+	}).Scan(&revocation).RecordNotFound() {
+		return false
+	}
+	return claims.IssuedAt < revocation.RevokedBefore
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/fahmibaswara/auth/claims"
@@ -36,18 +37,36 @@ type SessionStorer struct {
 	SigningMethod  jwt.SigningMethod
 	SignedString   string
 	SessionManager session.ManagerInterface
+	SessionRevoker SessionRevokerInterface
+	// Clock current time used to validate expiration of tokens, default is time.Now, Auth will set it to its Clock if blank
+	Clock func() time.Time
+	// Tenant return tenant of request, sessions issued for other tenants are rejected with ErrTenantMismatch, Auth will set it to GetTenant if it is multi-tenant and Tenant is blank
+	Tenant func(req *http.Request) string
 }
 
 // Get get claims from request
 func (sessionStorer *SessionStorer) Get(req *http.Request) (*claims.Claims, error) {
+	claims, err := sessionStorer.ValidateClaims(sessionStorer.GetToken(req))
+
+	if err == nil && sessionStorer.Tenant != nil && claims.TenantID != sessionStorer.Tenant(req) {
+		return nil, ErrTenantMismatch
+	}
+
+	if err == nil && sessionStorer.SessionRevoker != nil && sessionStorer.SessionRevoker.IsRevoked(req, claims) {
+		return nil, ErrSessionRevoked
+	}
+	return claims, err
+}
+
+// GetToken get token string from request's header or cookie
+func (sessionStorer *SessionStorer) GetToken(req *http.Request) string {
 	tokenString := req.Header.Get("Authorization")
 
 	// Get Token from Cookie
 	if tokenString == "" {
 		tokenString = sessionStorer.SessionManager.Get(req, sessionStorer.SessionName)
 	}
-
-	return sessionStorer.ValidateClaims(tokenString)
+	return tokenString
 }
 
 // Update update claims with session manager
@@ -82,7 +101,8 @@ func (sessionStorer *SessionStorer) SignedToken(claims *claims.Claims) string {
 
 // ValidateClaims validate auth token
 func (sessionStorer *SessionStorer) ValidateClaims(tokenString string) (*claims.Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &claims.Claims{}, func(token *jwt.Token) (interface{}, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &claims.Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if token.Method != sessionStorer.SigningMethod {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
@@ -93,8 +113,18 @@ func (sessionStorer *SessionStorer) ValidateClaims(tokenString string) (*claims.
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*claims.Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*claims.Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	now := time.Now
+	if sessionStorer.Clock != nil {
+		now = sessionStorer.Clock
+	}
+
+	if unix := now().Unix(); !claims.VerifyExpiresAt(unix, false) || !claims.VerifyIssuedAt(unix, false) || !claims.VerifyNotBefore(unix, false) {
+		return nil, errors.New("token is expired or not valid yet")
 	}
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
@@ -0,0 +1,94 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout default timeout of requests to SMS gateways
+var DefaultTimeout = 10 * time.Second
+
+// HTTPSender send SMS to an HTTP webhook as JSON
+type HTTPSender struct {
+	// URL webhook URL, SMS are posted to it as JSON `{"to": "+6281234567890", "content": "your code is 123456"}`
+	URL string
+	// Header extra headers of requests, like `Authorization`
+	Header http.Header
+	// Body build request body, which will be encoded as JSON, default is `{"to": destination, "content": content}`
+	Body func(destination string, content string) interface{}
+	// Client HTTP client, default client's timeout is DefaultTimeout
+	Client *http.Client
+}
+
+// Send post SMS to webhook, 2xx responses are regarded as sent, `422` as invalid destination, `429` as rate limited, 5xx as temporary errors, others as rejected
+func (sender *HTTPSender) Send(destination string, content string) error {
+	var body interface{} = map[string]string{"to": destination, "content": content}
+	if sender.Body != nil {
+		body = sender.Body(destination, content)
+	}
+
+	// errors of building the request won't be fixed by retrying
+	data, err := json.Marshal(body)
+	if err != nil {
+		return ErrRejected.Wrap(err)
+	}
+
+	req, err := http.NewRequest("POST", sender.URL, bytes.NewReader(data))
+	if err != nil {
+		return ErrRejected.Wrap(err)
+	}
+
+	for key, values := range sender.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient(sender.Client).Do(req)
+	if err != nil {
+		return ErrTemporary.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	var (
+		result        struct{ Code, Message string }
+		deliveryError = responseError("http", resp)
+	)
+
+	if json.Unmarshal([]byte(deliveryError.Message), &result) == nil && (result.Code != "" || result.Message != "") {
+		deliveryError.Code, deliveryError.Message = result.Code, result.Message
+	}
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return ErrInvalidDestination.Wrap(deliveryError)
+	}
+	return classifyStatus(resp.StatusCode).Wrap(deliveryError)
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: DefaultTimeout}
+	}
+	return client
+}
+
+// responseError build delivery error from failed response, its message is the response body
+func responseError(sender string, resp *http.Response) *DeliveryError {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	deliveryError := &DeliveryError{Sender: sender, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		deliveryError.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return deliveryError
+}
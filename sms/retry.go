@@ -0,0 +1,53 @@
+package sms
+
+import (
+	"time"
+
+	"github.com/fahmibaswara/auth"
+)
+
+// RetrySender retry sending with Sender after temporary errors and rate limits, with exponential backoff
+type RetrySender struct {
+	Sender auth.SMSSender
+	// Attempts max attempts, default is 3
+	Attempts int
+	// Backoff wait before the first retry, it is doubled for each retry, default is 1 second, the gateway's Retry-After is used if it is longer
+	Backoff time.Duration
+	// Sleep default is time.Sleep, could be replaced in tests
+	Sleep func(time.Duration)
+}
+
+// Send send SMS with Sender, returns last error if all attempts failed
+func (sender *RetrySender) Send(destination string, content string) error {
+	var (
+		attempts = sender.Attempts
+		backoff  = sender.Backoff
+		sleep    = sender.Sleep
+		err      error
+	)
+
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err = sender.Sender.Send(destination, content); !Retryable(err) || attempt >= attempts {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter := RetryAfter(err); retryAfter > wait {
+			wait = retryAfter
+		}
+		sleep(wait)
+		backoff *= 2
+	}
+}
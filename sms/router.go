@@ -0,0 +1,71 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fahmibaswara/auth"
+)
+
+// Route route SMS to destinations with prefixes to Sender
+type Route struct {
+	// Prefixes country calling codes or number prefixes, like `+62`, `+1`, numbers are normalized before matching, refer Normalize
+	Prefixes []string
+	Sender   auth.SMSSender
+}
+
+// Router send SMS with the sender of route that has the longest matched prefix, like sending SMS to Indonesia with a local gateway
+type Router struct {
+	Routes []Route
+	// Default sender used if no route matched, SMS to unmatched destinations are regarded as invalid destination if it is blank
+	Default auth.SMSSender
+}
+
+// Send send SMS with matched route's sender
+func (router *Router) Send(destination string, content string) error {
+	if sender := router.Sender(destination); sender != nil {
+		return sender.Send(destination, content)
+	}
+	return ErrInvalidDestination.Wrap(fmt.Errorf("sms: no route for %v", destination))
+}
+
+// Sender return sender of destination
+func (router *Router) Sender(destination string) auth.SMSSender {
+	var (
+		number  = Normalize(destination)
+		matched = -1
+		sender  = router.Default
+	)
+
+	for _, route := range router.Routes {
+		for _, prefix := range route.Prefixes {
+			if prefix = Normalize(prefix); len(prefix) > matched && strings.HasPrefix(number, prefix) {
+				matched, sender = len(prefix), route.Sender
+			}
+		}
+	}
+	return sender
+}
+
+// Failover send SMS with Senders in order, until one of them sent it, destinations regarded as invalid by a sender won't be tried with others
+type Failover struct {
+	Senders []auth.SMSSender
+	// OnFailover called when a sender failed and the next one will be tried, like logging it
+	OnFailover func(sender auth.SMSSender, destination string, err error)
+}
+
+// Send send SMS with senders, returns last error if all of them failed
+func (failover *Failover) Send(destination string, content string) error {
+	var err error
+	for idx, sender := range failover.Senders {
+		if err = sender.Send(destination, content); err == nil || errors.Is(err, ErrInvalidDestination) {
+			return err
+		}
+
+		if failover.OnFailover != nil && idx < len(failover.Senders)-1 {
+			failover.OnFailover(sender, destination, err)
+		}
+	}
+	return err
+}
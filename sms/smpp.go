@@ -0,0 +1,335 @@
+package sms
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/fahmibaswara/auth"
+)
+
+// SMPP 3.4 command IDs
+const (
+	smppGenericNack     uint32 = 0x80000000
+	smppBindTransmitter uint32 = 0x00000002
+	smppSubmitSM        uint32 = 0x00000004
+	smppUnbind          uint32 = 0x00000006
+	smppUnbindResp      uint32 = 0x80000006
+	smppEnquireLink     uint32 = 0x00000015
+	smppEnquireLinkResp uint32 = 0x80000015
+
+	smppMessagePayload uint16 = 0x0424
+)
+
+// smppStatuses SMPP command statuses, with their names and classification
+var smppStatuses = map[uint32]struct {
+	name string
+	err  *auth.Error
+}{
+	0x04: {"ESME_RINVBNDSTS", ErrTemporary},
+	0x05: {"ESME_RALYBND", ErrTemporary},
+	0x08: {"ESME_RSYSERR", ErrTemporary},
+	0x0A: {"ESME_RINVSRCADR", ErrRejected},
+	0x0B: {"ESME_RINVDSTADR", ErrInvalidDestination},
+	0x0D: {"ESME_RBINDFAIL", ErrRejected},
+	0x0E: {"ESME_RINVPASWD", ErrRejected},
+	0x0F: {"ESME_RINVSYSID", ErrRejected},
+	0x14: {"ESME_RMSGQFUL", ErrTemporary},
+	0x45: {"ESME_RSUBMITFAIL", ErrTemporary},
+	0x50: {"ESME_RINVDSTTON", ErrInvalidDestination},
+	0x51: {"ESME_RINVDSTNPI", ErrInvalidDestination},
+	0x58: {"ESME_RTHROTTLED", ErrRateLimited},
+	0x64: {"ESME_RX_T_APPN", ErrTemporary},
+}
+
+// SMPPSender send SMS with SMPP 3.4 as a transmitter, it binds when sending the first SMS and keeps the connection, which will be re-established after errors, call Close to unbind it
+type SMPPSender struct {
+	// Addr SMSC address, like `smsc.example.com:2775`
+	Addr       string
+	SystemID   string
+	Password   string
+	SystemType string
+	// SourceAddr sender's phone number, short code or alphanumeric sender ID
+	SourceAddr string
+	// TLS connect with TLS if not nil
+	TLS *tls.Config
+	// Timeout timeout of connecting and each request, default is DefaultTimeout
+	Timeout time.Duration
+	// EnquireInterval connection idle longer than it will be checked with `enquire_link` before sending, default is 30 seconds
+	EnquireInterval time.Duration
+
+	mutex    sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+	sequence uint32
+	lastUsed time.Time
+}
+
+type smppPDU struct {
+	commandID uint32
+	status    uint32
+	sequence  uint32
+	body      []byte
+}
+
+// Send submit SMS to SMSC, ASCII content is sent as IA5, others as UCS2, content longer than a short message is sent as message payload
+func (sender *SMPPSender) Send(destination string, content string) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if sender.conn != nil && time.Since(sender.lastUsed) > sender.enquireInterval() {
+		if _, err := sender.request(smppEnquireLink, nil); err != nil {
+			sender.close()
+		}
+	}
+
+	if sender.conn == nil {
+		if err := sender.bind(); err != nil {
+			return err
+		}
+	}
+
+	resp, err := sender.request(smppSubmitSM, sender.submitSM(destination, content))
+	if err != nil {
+		sender.close()
+		return ErrTemporary.Wrap(err)
+	}
+
+	if resp.status != 0 {
+		if resp.status == 0x04 {
+			// not bound, bind again next time
+			sender.close()
+		}
+		return smppError(resp.status)
+	}
+	return nil
+}
+
+// Close unbind and close the connection
+func (sender *SMPPSender) Close() error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if sender.conn == nil {
+		return nil
+	}
+
+	sender.request(smppUnbind, nil)
+	return sender.close()
+}
+
+func (sender *SMPPSender) timeout() time.Duration {
+	if sender.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return sender.Timeout
+}
+
+func (sender *SMPPSender) enquireInterval() time.Duration {
+	if sender.EnquireInterval == 0 {
+		return 30 * time.Second
+	}
+	return sender.EnquireInterval
+}
+
+func (sender *SMPPSender) bind() error {
+	var (
+		conn   net.Conn
+		err    error
+		dialer = &net.Dialer{Timeout: sender.timeout()}
+	)
+
+	if sender.TLS != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", sender.Addr, sender.TLS)
+	} else {
+		conn, err = dialer.Dial("tcp", sender.Addr)
+	}
+
+	if err != nil {
+		return ErrTemporary.Wrap(err)
+	}
+
+	sender.conn, sender.reader = conn, bufio.NewReader(conn)
+
+	var body bytes.Buffer
+	writeCString(&body, sender.SystemID)
+	writeCString(&body, sender.Password)
+	writeCString(&body, sender.SystemType)
+	// interface version, addr_ton, addr_npi, address_range
+	body.Write([]byte{0x34, 0, 0})
+	writeCString(&body, "")
+
+	resp, err := sender.request(smppBindTransmitter, body.Bytes())
+	if err != nil {
+		sender.close()
+		return ErrTemporary.Wrap(err)
+	}
+
+	if resp.status != 0 {
+		sender.close()
+		return smppError(resp.status)
+	}
+	return nil
+}
+
+func (sender *SMPPSender) close() error {
+	var err error
+	if sender.conn != nil {
+		err = sender.conn.Close()
+	}
+	sender.conn, sender.reader = nil, nil
+	return err
+}
+
+// request send request to SMSC and wait for its response, SMSC's enquire_link requests are responded while waiting
+func (sender *SMPPSender) request(commandID uint32, body []byte) (smppPDU, error) {
+	sender.sequence++
+	if sender.sequence > 0x7FFFFFFF {
+		sender.sequence = 1
+	}
+	sequence := sender.sequence
+
+	sender.conn.SetDeadline(time.Now().Add(sender.timeout()))
+	if err := writePDU(sender.conn, smppPDU{commandID: commandID, sequence: sequence, body: body}); err != nil {
+		return smppPDU{}, err
+	}
+
+	for {
+		resp, err := readPDU(sender.reader)
+		if err != nil {
+			return resp, err
+		}
+
+		switch {
+		case resp.sequence == sequence && (resp.commandID == commandID|smppGenericNack || resp.commandID == smppGenericNack):
+			sender.lastUsed = time.Now()
+			if resp.commandID == smppGenericNack && resp.status == 0 {
+				resp.status = 0x08
+			}
+			return resp, nil
+		case resp.commandID == smppEnquireLink:
+			if err := writePDU(sender.conn, smppPDU{commandID: smppEnquireLinkResp, sequence: resp.sequence}); err != nil {
+				return resp, err
+			}
+		case resp.commandID == smppUnbind:
+			writePDU(sender.conn, smppPDU{commandID: smppUnbindResp, sequence: resp.sequence})
+			return resp, errors.New("sms: SMSC unbound")
+		}
+	}
+}
+
+func (sender *SMPPSender) submitSM(destination string, content string) []byte {
+	var body bytes.Buffer
+
+	// service_type
+	writeCString(&body, "")
+	ton, npi, addr := smppAddress(sender.SourceAddr)
+	body.Write([]byte{ton, npi})
+	writeCString(&body, addr)
+	ton, npi, addr = smppAddress(destination)
+	body.Write([]byte{ton, npi})
+	writeCString(&body, addr)
+	// esm_class, protocol_id, priority_flag
+	body.Write([]byte{0, 0, 0})
+	// schedule_delivery_time, validity_period
+	writeCString(&body, "")
+	writeCString(&body, "")
+
+	dataCoding, message := smppEncode(content)
+	// registered_delivery, replace_if_present_flag, data_coding, sm_default_msg_id
+	body.Write([]byte{0, 0, dataCoding, 0})
+
+	if len(message) <= 254 {
+		body.WriteByte(byte(len(message)))
+		body.Write(message)
+	} else {
+		body.WriteByte(0)
+		binary.Write(&body, binary.BigEndian, smppMessagePayload)
+		binary.Write(&body, binary.BigEndian, uint16(len(message)))
+		body.Write(message)
+	}
+	return body.Bytes()
+}
+
+// smppAddress return type of number, numbering plan indicator and address, international numbers are sent without `+`, alphanumeric addresses as alphanumeric type
+func smppAddress(address string) (ton byte, npi byte, addr string) {
+	address = Normalize(address)
+	switch {
+	case strings.HasPrefix(address, "+"):
+		return 1, 1, address[1:]
+	case strings.IndexFunc(address, func(r rune) bool { return r < '0' || r > '9' }) >= 0:
+		return 5, 0, address
+	default:
+		return 0, 1, address
+	}
+}
+
+// smppEncode encode content as IA5 if it is ASCII, otherwise as UCS2
+func smppEncode(content string) (dataCoding byte, message []byte) {
+	for _, r := range content {
+		if r > 0x7F {
+			var buf bytes.Buffer
+			for _, code := range utf16.Encode([]rune(content)) {
+				binary.Write(&buf, binary.BigEndian, code)
+			}
+			return 0x08, buf.Bytes()
+		}
+	}
+	return 0x01, []byte(content)
+}
+
+func smppError(status uint32) error {
+	deliveryError := &DeliveryError{Sender: "smpp", StatusCode: int(status), Code: fmt.Sprintf("0x%08X", status)}
+	if s, ok := smppStatuses[status]; ok {
+		deliveryError.Code = s.name
+		return s.err.Wrap(deliveryError)
+	}
+	return ErrRejected.Wrap(deliveryError)
+}
+
+func writeCString(buf *bytes.Buffer, value string) {
+	buf.WriteString(value)
+	buf.WriteByte(0)
+}
+
+func writePDU(w io.Writer, pdu smppPDU) error {
+	buf := make([]byte, 16+len(pdu.body))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(buf)))
+	binary.BigEndian.PutUint32(buf[4:], pdu.commandID)
+	binary.BigEndian.PutUint32(buf[8:], pdu.status)
+	binary.BigEndian.PutUint32(buf[12:], pdu.sequence)
+	copy(buf[16:], pdu.body)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+func readPDU(r io.Reader) (smppPDU, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return smppPDU{}, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	if length < 16 || length > 64*1024 {
+		return smppPDU{}, fmt.Errorf("sms: invalid SMPP PDU length %v", length)
+	}
+
+	pdu := smppPDU{
+		commandID: binary.BigEndian.Uint32(header[4:]),
+		status:    binary.BigEndian.Uint32(header[8:]),
+		sequence:  binary.BigEndian.Uint32(header[12:]),
+		body:      make([]byte, length-16),
+	}
+	_, err := io.ReadFull(r, pdu.body)
+	return pdu, err
+}
@@ -0,0 +1,66 @@
+package sms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth/authtest"
+)
+
+func TestSMPPSender(t *testing.T) {
+	server := authtest.NewSMPPServer()
+	server.SystemID, server.Password = "auth", "secret"
+	defer server.Close()
+
+	if err := (&SMPPSender{Addr: server.Addr, SystemID: "auth", Password: "wrong"}).Send("+6281234567890", "your code is 123456"); !errors.Is(err, ErrRejected) {
+		t.Errorf("bind with invalid password should be rejected, got %v", err)
+	}
+
+	sender := &SMPPSender{Addr: server.Addr, SystemID: "auth", Password: "secret", SourceAddr: "AUTH", Timeout: time.Second}
+	defer sender.Close()
+
+	cases := []struct {
+		name        string
+		destination string
+		content     string
+		status      uint32
+		err         error
+	}{
+		{name: "international number", destination: "+62 812-3456-7890", content: "your code is 123456"},
+		{name: "unicode content", destination: "+6281234567890", content: "kode Anda 123456 ✓"},
+		{name: "message payload", destination: "+6281234567890", content: string(make([]byte, 300))},
+		{name: "throttled", destination: "+6281234567890", content: "your code is 123456", status: 0x58, err: ErrRateLimited},
+		{name: "invalid destination", destination: "+6281234567890", content: "your code is 123456", status: 0x0B, err: ErrInvalidDestination},
+	}
+
+	for _, c := range cases {
+		if c.status != 0 {
+			server.Fail(c.status, 1)
+		}
+
+		if err := sender.Send(c.destination, c.content); !errors.Is(err, c.err) {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+			continue
+		}
+
+		if sms, ok := server.SMS.LastMessage(Normalize(c.destination)); c.err == nil && (!ok || sms.Content != c.content) {
+			t.Errorf("%v: SMSC should receive %q, got %q", c.name, c.content, sms.Content)
+		}
+	}
+
+	// idle connection is checked with enquire_link, and bound again if SMSC closed it
+	sender.EnquireInterval = time.Nanosecond
+	if err := sender.Send("+6281234567890", "enquired"); err != nil {
+		t.Errorf("should send after enquire_link, got %v", err)
+	}
+
+	server.CloseConnections()
+	if err := sender.Send("+6281234567890", "bound again"); err != nil {
+		t.Errorf("should bind again after connection closed, got %v", err)
+	}
+
+	if sms, _ := server.SMS.LastMessage("+6281234567890"); sms.Content != "bound again" {
+		t.Errorf("SMSC should receive SMS after bound again, got %q", sms.Content)
+	}
+}
@@ -0,0 +1,92 @@
+// Package sms provides SMS senders for Auth, like HTTP webhook, Twilio, SMPP, and senders to retry, route and fail over between them
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+)
+
+var (
+	// ErrInvalidDestination SMS can't be delivered to the destination, like invalid or unreachable number, it won't be retried or failed over
+	ErrInvalidDestination = &auth.Error{Code: "sms_invalid_destination", Status: http.StatusUnprocessableEntity, Message: "SMS can't be sent to this phone number", MessageID: "sms.errors.invalid_destination"}
+	// ErrRateLimited SMS gateway rate limited requests, it will be retried
+	ErrRateLimited = &auth.Error{Code: "sms_rate_limited", Status: http.StatusTooManyRequests, Message: "Too many SMS have been sent, please try again later", MessageID: "sms.errors.rate_limited"}
+	// ErrTemporary SMS gateway is unavailable at the moment, like network errors, server errors, it will be retried
+	ErrTemporary = &auth.Error{Code: "sms_unavailable", Status: http.StatusServiceUnavailable, Message: "SMS can't be sent at the moment, please try again later", MessageID: "sms.errors.unavailable"}
+	// ErrRejected SMS gateway rejected the SMS, like invalid credentials, content, it won't be retried, but could be failed over
+	ErrRejected = &auth.Error{Code: "sms_rejected", Status: http.StatusBadGateway, Message: "SMS can't be sent, please try again later", MessageID: "sms.errors.rejected"}
+)
+
+// DeliveryError error responded by SMS gateway, it is the cause of classified errors, like `ErrTemporary.Wrap(&DeliveryError{...})`
+type DeliveryError struct {
+	// Sender name of the sender, like `http`, `twilio`, `smpp`
+	Sender string
+	// StatusCode HTTP status or SMPP command status
+	StatusCode int
+	// Code gateway's error code, like Twilio's `21211`, SMPP's `ESME_RTHROTTLED`
+	Code string
+	// Message gateway's error message
+	Message string
+	// RetryAfter how long to wait before retry, if the gateway told
+	RetryAfter time.Duration
+}
+
+// Error return error message
+func (err *DeliveryError) Error() string {
+	message := fmt.Sprintf("sms: %v responded %v", err.Sender, err.StatusCode)
+	if err.Code != "" {
+		message += " " + err.Code
+	}
+	if err.Message != "" {
+		message += ": " + err.Message
+	}
+	return message
+}
+
+// Retryable check if sending could be retried after err, senders of the package classify all their errors, errors that are not classified, like network errors of custom senders, are regarded as temporary
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var e *auth.Error
+	if !errors.As(err, &e) {
+		return true
+	}
+	return errors.Is(err, ErrTemporary) || errors.Is(err, ErrRateLimited)
+}
+
+// RetryAfter return how long to wait before retry if the gateway told
+func RetryAfter(err error) time.Duration {
+	var e *DeliveryError
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
+// classifyStatus classify HTTP status of gateways' responses
+func classifyStatus(status int) *auth.Error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500 || status == http.StatusRequestTimeout:
+		return ErrTemporary
+	default:
+		return ErrRejected
+	}
+}
+
+// Normalize normalize phone number for matching, spaces, dashes, dots, parentheses are removed, international prefix `00` is replaced with `+`
+func Normalize(phoneNumber string) string {
+	phoneNumber = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phoneNumber))
+	if strings.HasPrefix(phoneNumber, "00") {
+		phoneNumber = "+" + phoneNumber[2:]
+	}
+	return phoneNumber
+}
@@ -0,0 +1,235 @@
+package sms
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fahmibaswara/auth"
+)
+
+type senderFunc func(destination string, content string) error
+
+func (send senderFunc) Send(destination string, content string) error {
+	return send(destination, content)
+}
+
+func TestHTTPSender(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		err        error
+		retryable  bool
+		wait       time.Duration
+	}{
+		{name: "sent", status: http.StatusOK},
+		{name: "invalid destination", status: http.StatusUnprocessableEntity, body: `{"code": "invalid_number", "message": "invalid number"}`, err: ErrInvalidDestination},
+		{name: "rate limited", status: http.StatusTooManyRequests, retryAfter: "7", err: ErrRateLimited, retryable: true, wait: 7 * time.Second},
+		{name: "server error", status: http.StatusServiceUnavailable, err: ErrTemporary, retryable: true},
+		{name: "unauthorized", status: http.StatusUnauthorized, err: ErrRejected},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if c.retryAfter != "" {
+				w.Header().Set("Retry-After", c.retryAfter)
+			}
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+
+		err := (&HTTPSender{URL: server.URL}).Send("+6281234567890", "your code is 123456")
+		server.Close()
+
+		if c.err == nil && err != nil || c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+
+		if Retryable(err) != c.retryable {
+			t.Errorf("%v: retryable should be %v", c.name, c.retryable)
+		}
+
+		if RetryAfter(err) != c.wait {
+			t.Errorf("%v: should retry after %v, got %v", c.name, c.wait, RetryAfter(err))
+		}
+	}
+
+	if err := (&HTTPSender{URL: "://invalid"}).Send("+6281234567890", "your code is 123456"); !errors.Is(err, ErrRejected) || Retryable(err) {
+		t.Errorf("invalid URL should be rejected without retry, got %v", err)
+	}
+}
+
+func TestTwilioSender(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		err    error
+	}{
+		{name: "sent", status: http.StatusCreated, body: `{"sid": "SM1"}`},
+		{name: "invalid 'To' number", status: http.StatusBadRequest, body: `{"code": 21211, "message": "invalid 'To' number"}`, err: ErrInvalidDestination},
+		{name: "rejected", status: http.StatusBadRequest, body: `{"code": 21606, "message": "invalid 'From' number"}`, err: ErrRejected},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"code": 20429, "message": "too many requests"}`, err: ErrRateLimited},
+		{name: "server error", status: http.StatusInternalServerError, err: ErrTemporary},
+	}
+
+	for _, c := range cases {
+		var path, to, user string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			path, to = req.URL.Path, req.FormValue("To")
+			user, _, _ = req.BasicAuth()
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+
+		err := (&TwilioSender{AccountSID: "AC1", AuthToken: "secret", From: "+15005550006", BaseURL: server.URL}).Send("+6281234567890", "your code is 123456")
+		server.Close()
+
+		if c.err == nil && err != nil || c.err != nil && !errors.Is(err, c.err) {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+
+		if path != "/2010-04-01/Accounts/AC1/Messages.json" || to != "+6281234567890" || user != "AC1" {
+			t.Errorf("%v: should post message to Twilio's messages API, got %v %v %v", c.name, path, to, user)
+		}
+	}
+}
+
+func TestRetrySender(t *testing.T) {
+	rateLimited := ErrRateLimited.Wrap(&DeliveryError{Sender: "http", StatusCode: http.StatusTooManyRequests, RetryAfter: 10 * time.Second})
+
+	cases := []struct {
+		name     string
+		errs     []error
+		err      error
+		attempts int
+		waits    []time.Duration
+	}{
+		{name: "sent", errs: []error{nil}, attempts: 1},
+		{name: "sent after retry", errs: []error{ErrTemporary, ErrTemporary, nil}, attempts: 3, waits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "all attempts failed", errs: []error{ErrTemporary, ErrTemporary, ErrTemporary, nil}, err: ErrTemporary, attempts: 3, waits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "retry after of gateway", errs: []error{rateLimited, nil}, attempts: 2, waits: []time.Duration{10 * time.Second}},
+		{name: "invalid destination", errs: []error{ErrInvalidDestination, nil}, err: ErrInvalidDestination, attempts: 1},
+		{name: "rejected", errs: []error{ErrRejected, nil}, err: ErrRejected, attempts: 1},
+	}
+
+	for _, c := range cases {
+		var (
+			attempts int
+			waits    []time.Duration
+		)
+
+		sender := &RetrySender{
+			Sender: senderFunc(func(destination string, content string) error {
+				attempts++
+				return c.errs[attempts-1]
+			}),
+			Sleep: func(wait time.Duration) { waits = append(waits, wait) },
+		}
+
+		if err := sender.Send("+6281234567890", "your code is 123456"); !errors.Is(err, c.err) {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+
+		if attempts != c.attempts {
+			t.Errorf("%v: should send %v times, got %v", c.name, c.attempts, attempts)
+		}
+
+		if len(waits) != len(c.waits) {
+			t.Errorf("%v: should wait %v, got %v", c.name, c.waits, waits)
+			continue
+		}
+
+		for idx, wait := range waits {
+			if wait != c.waits[idx] {
+				t.Errorf("%v: should wait %v, got %v", c.name, c.waits, waits)
+			}
+		}
+	}
+}
+
+func TestRouter(t *testing.T) {
+	var sent string
+	sender := func(name string) senderFunc {
+		return func(destination string, content string) error {
+			sent = name
+			return nil
+		}
+	}
+
+	router := &Router{
+		Routes: []Route{
+			{Prefixes: []string{"+62"}, Sender: sender("indonesia")},
+			{Prefixes: []string{"+62 811"}, Sender: sender("telkomsel")},
+			{Prefixes: []string{"+1"}, Sender: sender("us")},
+		},
+		Default: sender("default"),
+	}
+
+	cases := []struct {
+		destination string
+		sender      string
+	}{
+		{destination: "+6285612345678", sender: "indonesia"},
+		{destination: "+62 811-2345-678", sender: "telkomsel"},
+		{destination: "0062811234567", sender: "telkomsel"},
+		{destination: "+1 (415) 555-0100", sender: "us"},
+		{destination: "+447700900000", sender: "default"},
+	}
+
+	for _, c := range cases {
+		sent = ""
+		if err := router.Send(c.destination, "your code is 123456"); err != nil || sent != c.sender {
+			t.Errorf("%v should be sent with %v, got %v %v", c.destination, c.sender, sent, err)
+		}
+	}
+
+	router.Default = nil
+	if err := router.Send("+447700900000", "your code is 123456"); !errors.Is(err, ErrInvalidDestination) {
+		t.Errorf("unmatched destination should be invalid without default sender, got %v", err)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	cases := []struct {
+		name      string
+		errs      []error
+		err       error
+		sent      int
+		failovers int
+	}{
+		{name: "sent with first sender", errs: []error{nil, nil}, sent: 1},
+		{name: "failed over after temporary error", errs: []error{ErrTemporary, nil}, sent: 2, failovers: 1},
+		{name: "failed over after rejected", errs: []error{ErrRejected, nil}, sent: 2, failovers: 1},
+		{name: "invalid destination", errs: []error{ErrInvalidDestination, nil}, err: ErrInvalidDestination, sent: 1},
+		{name: "all senders failed", errs: []error{ErrTemporary, ErrRejected}, err: ErrRejected, sent: 2, failovers: 1},
+	}
+
+	for _, c := range cases {
+		var (
+			sent      int
+			failovers int
+			failover  = &Failover{OnFailover: func(sender auth.SMSSender, destination string, err error) { failovers++ }}
+		)
+
+		for _, err := range c.errs {
+			err := err
+			failover.Senders = append(failover.Senders, senderFunc(func(destination string, content string) error {
+				sent++
+				return err
+			}))
+		}
+
+		if err := failover.Send("+6281234567890", "your code is 123456"); !errors.Is(err, c.err) {
+			t.Errorf("%v: should return %v, got %v", c.name, c.err, err)
+		}
+
+		if sent != c.sent || failovers != c.failovers {
+			t.Errorf("%v: should send %v times and fail over %v times, got %v, %v", c.name, c.sent, c.failovers, sent, failovers)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sms
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TwilioURL base URL of Twilio's REST API
+var TwilioURL = "https://api.twilio.com"
+
+// twilioInvalidDestinationCodes Twilio error codes that SMS can't be delivered to the destination
+var twilioInvalidDestinationCodes = map[int]bool{
+	21211: true, // invalid 'To' phone number
+	21214: true, // 'To' phone number cannot be reached
+	21408: true, // permission to send an SMS has not been enabled for the region
+	21610: true, // 'To' phone number has unsubscribed
+	21612: true, // 'To' phone number is not currently reachable
+	21614: true, // 'To' number is not a valid mobile number
+}
+
+// TwilioSender send SMS with Twilio or Twilio-compatible REST API
+type TwilioSender struct {
+	AccountSID string
+	AuthToken  string
+	// From sender's phone number or alphanumeric sender ID
+	From string
+	// MessagingServiceSID send with messaging service instead of From if not blank
+	MessagingServiceSID string
+	// BaseURL base URL of the API, default is TwilioURL, could be changed to Twilio-compatible APIs or test servers
+	BaseURL string
+	// Client HTTP client, default client's timeout is DefaultTimeout
+	Client *http.Client
+}
+
+// Send create message with Twilio's messages API
+func (sender *TwilioSender) Send(destination string, content string) error {
+	baseURL := sender.BaseURL
+	if baseURL == "" {
+		baseURL = TwilioURL
+	}
+
+	form := url.Values{"To": {destination}, "Body": {content}}
+	if sender.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", sender.MessagingServiceSID)
+	} else {
+		form.Set("From", sender.From)
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(baseURL, "/")+"/2010-04-01/Accounts/"+url.PathEscape(sender.AccountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return ErrRejected.Wrap(err)
+	}
+	req.SetBasicAuth(sender.AccountSID, sender.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient(sender.Client).Do(req)
+	if err != nil {
+		return ErrTemporary.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	var (
+		result struct {
+			Code    int
+			Message string
+		}
+		deliveryError = responseError("twilio", resp)
+	)
+
+	if json.Unmarshal([]byte(deliveryError.Message), &result) == nil && result.Code != 0 {
+		deliveryError.Code, deliveryError.Message = strconv.Itoa(result.Code), result.Message
+	}
+
+	if twilioInvalidDestinationCodes[result.Code] {
+		return ErrInvalidDestination.Wrap(deliveryError)
+	}
+	return classifyStatus(resp.StatusCode).Wrap(deliveryError)
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fahmibaswara/auth/auth_identity"
+)
+
+// DollarPlaceholder placeholder of PostgreSQL, like `$1`
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func sqlQuery(placeholder func(int) string, query string) string {
+	if placeholder == nil {
+		return query
+	}
+
+	var (
+		n      int
+		result strings.Builder
+	)
+	for _, r := range query {
+		if r == '?' {
+			n++
+			result.WriteString(placeholder(n))
+		} else {
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// sqlTenantScope add tenant condition into conditions if tenant is set
+func sqlTenantScope(req *http.Request, tenant func(*http.Request) string, conditions string, values []interface{}) (string, []interface{}) {
+	if tenant == nil {
+		return conditions, values
+	}
+	return conditions + " AND tenant_id = ?", append(values, tenant(req))
+}
+
+// SQLIdentityStore identity store based on database/sql, its table should have columns `provider`, `uid`, `encrypted_password`, `user_id`, `confirmed_at`, `locked_at`, `created_at`, `updated_at`, and `tenant_id` if Tenant is set, with unique index of `provider`, `uid`, `tenant_id` to reject concurrent duplicated registrations, tables migrated from auth_identity.AuthIdentity could be used
+type SQLIdentityStore struct {
+	DB *sql.DB
+	// Table table of auth identities, default value is `auth_identities`
+	Table string
+	// Placeholder return placeholder of n-th argument, default is `?`, use DollarPlaceholder for PostgreSQL
+	Placeholder func(n int) string
+	// Tenant return tenant of request, auth identities are scoped to it with column `tenant_id`, Auth will set it to GetTenant if it is multi-tenant and Tenant is blank
+	Tenant func(req *http.Request) string
+	// Clock current time used to set `created_at`, `updated_at`, default is time.Now, Auth will set it to its Clock if blank
+	Clock func() time.Time
+}
+
+func (store *SQLIdentityStore) now() time.Time {
+	if store.Clock != nil {
+		return store.Clock()
+	}
+	return time.Now()
+}
+
+func (store *SQLIdentityStore) query(query string) string {
+	table := store.Table
+	if table == "" {
+		table = "auth_identities"
+	}
+	return sqlQuery(store.Placeholder, strings.Replace(query, "{table}", table, -1))
+}
+
+// Find find auth identity with provider and uid
+func (store *SQLIdentityStore) Find(req *http.Request, provider string, uid string) (*auth_identity.Basic, error) {
+	return store.find(req, "provider = ? AND uid = ?", provider, uid)
+}
+
+// FindByUserID find user's auth identity of provider
+func (store *SQLIdentityStore) FindByUserID(req *http.Request, provider string, userID string) (*auth_identity.Basic, error) {
+	// auth identities without linked user shouldn't be found by blank user ID
+	if userID == "" {
+		return nil, ErrIdentityNotFound
+	}
+	return store.find(req, "provider = ? AND user_id = ?", provider, userID)
+}
+
+func (store *SQLIdentityStore) find(req *http.Request, conditions string, values ...interface{}) (*auth_identity.Basic, error) {
+	var (
+		identity                  auth_identity.Basic
+		encryptedPassword, userID sql.NullString
+	)
+
+	conditions, values = sqlTenantScope(req, store.Tenant, conditions, values)
+	err := store.DB.QueryRowContext(req.Context(), store.query(
+		"SELECT provider, uid, encrypted_password, user_id, confirmed_at, locked_at FROM {table} WHERE "+conditions,
+	), values...).Scan(&identity.Provider, &identity.UID, &encryptedPassword, &userID, &identity.ConfirmedAt, &identity.LockedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrIdentityNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	identity.EncryptedPassword, identity.UserID = encryptedPassword.String, userID.String
+	if store.Tenant != nil {
+		identity.TenantID = store.Tenant(req)
+	}
+	return &identity, nil
+}
+
+// Create create auth identity if there is no auth identity with same provider and uid
+func (store *SQLIdentityStore) Create(req *http.Request, identity *auth_identity.Basic) error {
+	if _, err := store.Find(req, identity.Provider, identity.UID); err != ErrIdentityNotFound {
+		return err
+	}
+
+	var (
+		now = store.now()
+		err error
+	)
+
+	if store.Tenant != nil {
+		identity.TenantID = store.Tenant(req)
+		_, err = store.DB.ExecContext(req.Context(), store.query(
+			"INSERT INTO {table} (provider, uid, encrypted_password, user_id, confirmed_at, locked_at, created_at, updated_at, tenant_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		), identity.Provider, identity.UID, identity.EncryptedPassword, identity.UserID, identity.ConfirmedAt, identity.LockedAt, now, now, identity.TenantID)
+	} else {
+		_, err = store.DB.ExecContext(req.Context(), store.query(
+			"INSERT INTO {table} (provider, uid, encrypted_password, user_id, confirmed_at, locked_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		), identity.Provider, identity.UID, identity.EncryptedPassword, identity.UserID, identity.ConfirmedAt, identity.LockedAt, now, now)
+	}
+
+	// auth identity created by a concurrent request is kept, like GormIdentityStore's FirstOrCreate
+	if err != nil && isUniqueViolation(err) {
+		return nil
+	}
+	return err
+}
+
+// Update save auth identity's encrypted password, user ID, confirmed at and locked at
+func (store *SQLIdentityStore) Update(req *http.Request, identity *auth_identity.Basic) error {
+	conditions, values := sqlTenantScope(req, store.Tenant, "provider = ? AND uid = ?", []interface{}{
+		identity.EncryptedPassword, identity.UserID, identity.ConfirmedAt, identity.LockedAt, store.now(), identity.Provider, identity.UID,
+	})
+
+	_, err := store.DB.ExecContext(req.Context(), store.query(
+		"UPDATE {table} SET encrypted_password = ?, user_id = ?, confirmed_at = ?, locked_at = ?, updated_at = ? WHERE "+conditions,
+	), values...)
+	return err
+}
+
+// Delete delete auth identity with provider and uid
+func (store *SQLIdentityStore) Delete(req *http.Request, provider string, uid string) error {
+	conditions, values := sqlTenantScope(req, store.Tenant, "provider = ? AND uid = ?", []interface{}{provider, uid})
+	_, err := store.DB.ExecContext(req.Context(), store.query("DELETE FROM {table} WHERE "+conditions), values...)
+	return err
+}
+
+// SQLTokenStore token store based on database/sql, its table should have columns `identity`, `token`, `valid_until`, `channel`, `attempts`, `sent_at`, and `tenant_id` if Tenant is set, tables migrated from auth_identity.AuthToken could be used
+type SQLTokenStore struct {
+	DB *sql.DB
+	// Table table of tokens, default value is `auth_tokens`
+	Table string
+	// Placeholder return placeholder of n-th argument, default is `?`, use DollarPlaceholder for PostgreSQL
+	Placeholder func(n int) string
+	// Tenant return tenant of request, tokens are scoped to it with column `tenant_id`, Auth will set it to GetTenant if it is multi-tenant and Tenant is blank
+	Tenant func(req *http.Request) string
+}
+
+func (store *SQLTokenStore) query(query string) string {
+	table := store.Table
+	if table == "" {
+		table = "auth_tokens"
+	}
+	return sqlQuery(store.Placeholder, strings.Replace(query, "{table}", table, -1))
+}
+
+// Find find token of identity
+func (store *SQLTokenStore) Find(req *http.Request, identity string) (*auth_identity.AuthToken, error) {
+	var (
+		token   auth_identity.AuthToken
+		channel sql.NullString
+	)
+
+	conditions, values := sqlTenantScope(req, store.Tenant, "identity = ?", []interface{}{identity})
+	err := store.DB.QueryRowContext(req.Context(), store.query(
+		"SELECT identity, token, valid_until, channel, attempts, sent_at FROM {table} WHERE "+conditions,
+	), values...).Scan(&token.Identity, &token.Token, &token.ValidUntil, &channel, &token.Attempts, &token.SentAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	token.Channel = channel.String
+	if store.Tenant != nil {
+		token.TenantID = store.Tenant(req)
+	}
+	return &token, nil
+}
+
+// Save save token, previous token of same identity will be replaced
+func (store *SQLTokenStore) Save(req *http.Request, token *auth_identity.AuthToken) error {
+	tx, err := store.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		return err
+	}
+
+	conditions, values := sqlTenantScope(req, store.Tenant, "identity = ?", []interface{}{token.Identity})
+	if _, err = tx.Exec(store.query("DELETE FROM {table} WHERE "+conditions), values...); err == nil {
+		if store.Tenant != nil {
+			token.TenantID = store.Tenant(req)
+			_, err = tx.Exec(store.query("INSERT INTO {table} (identity, token, valid_until, channel, attempts, sent_at, tenant_id) VALUES (?, ?, ?, ?, ?, ?, ?)"), token.Identity, token.Token, token.ValidUntil, token.Channel, token.Attempts, token.SentAt, token.TenantID)
+		} else {
+			_, err = tx.Exec(store.query("INSERT INTO {table} (identity, token, valid_until, channel, attempts, sent_at) VALUES (?, ?, ?, ?, ?, ?)"), token.Identity, token.Token, token.ValidUntil, token.Channel, token.Attempts, token.SentAt)
+		}
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete delete token of identity
+func (store *SQLTokenStore) Delete(req *http.Request, identity string) error {
+	conditions, values := sqlTenantScope(req, store.Tenant, "identity = ?", []interface{}{identity})
+	_, err := store.DB.ExecContext(req.Context(), store.query("DELETE FROM {table} WHERE "+conditions), values...)
+	return err
+}
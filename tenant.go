@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/qor/qor/utils"
+)
+
+// CurrentTenant context key to get current tenant from Request
+const CurrentTenant utils.ContextKey = "current_tenant"
+
+// TenantResolver is an interface that defined how to resolve tenant of requests, auth identities, tokens and sessions are isolated by tenant, Auth provides HostTenantResolver, HeaderTenantResolver, PathTenantResolver
+type TenantResolver interface {
+	// Resolve return tenant of request, returns ErrTenantNotFound if the request doesn't belong to any tenant
+	Resolve(req *http.Request) (string, error)
+}
+
+// TenantResolverFunc resolve tenant with a func
+type TenantResolverFunc func(req *http.Request) (string, error)
+
+// Resolve return tenant of request
+func (resolver TenantResolverFunc) Resolve(req *http.Request) (string, error) {
+	return resolver(req)
+}
+
+// HostTenantResolver resolve tenant from request's host, hosts are matched with Hosts first, then subdomains of Domain
+type HostTenantResolver struct {
+	// Hosts tenants of hosts, like `{"shop-a.com": "shop-a"}`
+	Hosts map[string]string
+	// Domain subdomains of Domain are tenants, like tenant `shop-a` of `shop-a.example.com` if Domain is `example.com`
+	Domain string
+}
+
+// Resolve return tenant of request's host
+func (resolver *HostTenantResolver) Resolve(req *http.Request) (string, error) {
+	host := strings.ToLower(req.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if tenant, ok := resolver.Hosts[host]; ok {
+		return tenant, nil
+	}
+
+	if resolver.Domain != "" {
+		if subdomain := strings.TrimSuffix(host, "."+strings.ToLower(resolver.Domain)); subdomain != host && subdomain != "" && !strings.Contains(subdomain, ".") {
+			return subdomain, nil
+		}
+	}
+	return "", ErrTenantNotFound
+}
+
+// HeaderTenantResolver resolve tenant from request's header, clients could send any header, so it should be set by trusted proxies, or restricted with Tenants
+type HeaderTenantResolver struct {
+	// Header default value is `X-Tenant-ID`
+	Header string
+	// Tenants allowed tenants, any tenant is allowed if blank
+	Tenants []string
+}
+
+// Resolve return tenant of request's header
+func (resolver *HeaderTenantResolver) Resolve(req *http.Request) (string, error) {
+	header := resolver.Header
+	if header == "" {
+		header = "X-Tenant-ID"
+	}
+
+	if tenant := strings.TrimSpace(req.Header.Get(header)); tenant != "" && allowedTenant(resolver.Tenants, tenant) {
+		return tenant, nil
+	}
+	return "", ErrTenantNotFound
+}
+
+// PathTenantResolver resolve tenant from request's path, like tenant `shop-a` of `/shop-a/auth/login`, TenantMiddleware strips tenant's path from requests, and Context.AuthURL prepends it to URLs
+type PathTenantResolver struct {
+	// Prefix path before tenant, like `/t` for `/t/shop-a/auth/login`, default is blank
+	Prefix string
+	// Tenants allowed tenants, any tenant is allowed if blank
+	Tenants []string
+}
+
+// Resolve return tenant of request's path
+func (resolver *PathTenantResolver) Resolve(req *http.Request) (string, error) {
+	var (
+		prefix   = splitPath(resolver.Prefix)
+		segments = splitPath(req.URL.Path)
+	)
+
+	if len(segments) <= len(prefix) || strings.Join(segments[:len(prefix)], "/") != strings.Join(prefix, "/") {
+		return "", ErrTenantNotFound
+	}
+
+	if tenant := segments[len(prefix)]; allowedTenant(resolver.Tenants, tenant) {
+		return tenant, nil
+	}
+	return "", ErrTenantNotFound
+}
+
+// TenantPath return path of tenant, like `/t/shop-a`
+func (resolver *PathTenantResolver) TenantPath(tenant string) string {
+	return path.Join("/", resolver.Prefix, tenant)
+}
+
+func allowedTenant(tenants []string, tenant string) bool {
+	if len(tenants) == 0 {
+		return true
+	}
+
+	for _, t := range tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTenant return a copy of context with tenant, e.g. access tenant's auth identities in background jobs
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, CurrentTenant, tenant)
+}
+
+// IsMultiTenant check if Auth isolates auth identities, tokens and sessions by tenant, which is true if TenantResolver is configured
+func (auth *Auth) IsMultiTenant() bool {
+	return auth.Config.TenantResolver != nil
+}
+
+// GetTenant return tenant of request, which is saved by TenantMiddleware, WithTenant, or resolved with TenantResolver, returns blank if TenantResolver is blank or the tenant couldn't be resolved
+func (auth *Auth) GetTenant(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+
+	if tenant, ok := req.Context().Value(CurrentTenant).(string); ok {
+		return tenant
+	}
+
+	if auth.IsMultiTenant() {
+		if tenant, err := auth.Config.TenantResolver.Resolve(req); err == nil {
+			return tenant
+		}
+	}
+	return ""
+}
+
+// ScopeTenant scope query of auth identities, tokens to request's tenant with column `tenant_id`, the query is not scoped if Auth isn't multi-tenant
+func (auth *Auth) ScopeTenant(req *http.Request, db *gorm.DB) *gorm.DB {
+	if auth.IsMultiTenant() {
+		return db.Where("tenant_id = ?", auth.GetTenant(req))
+	}
+	return db
+}
+
+// TenantMiddleware resolve tenant of requests with TenantResolver, and save it into request's context, tenant's path is stripped from requests for PathTenantResolver, requests without tenant are responded with ErrTenantNotFound
+func (auth *Auth) TenantMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req, err := auth.resolveTenant(req)
+		if err != nil {
+			auth.WriteError(w, req, err)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// resolveTenant save request's tenant into its context if it isn't resolved yet
+func (auth *Auth) resolveTenant(req *http.Request) (*http.Request, error) {
+	if !auth.IsMultiTenant() {
+		return req, nil
+	}
+
+	if _, ok := req.Context().Value(CurrentTenant).(string); ok {
+		return req, nil
+	}
+
+	tenant, err := auth.Config.TenantResolver.Resolve(req)
+	if err != nil {
+		return req, err
+	}
+
+	req = req.WithContext(WithTenant(req.Context(), tenant))
+	if resolver, ok := auth.Config.TenantResolver.(*PathTenantResolver); ok {
+		u := *req.URL
+		u.Path = "/" + strings.TrimLeft(strings.TrimPrefix(u.Path, resolver.TenantPath(tenant)), "/")
+		u.RawPath = ""
+		req.URL = &u
+	}
+	return req, nil
+}
+
+// initTenantStores scope session storer, memory and database/sql stores to request's tenant, gorm stores are scoped with TenantConditions
+func (auth *Auth) initTenantStores() {
+	if storer, ok := auth.Config.SessionStorer.(*SessionStorer); ok && storer.Tenant == nil {
+		storer.Tenant = auth.GetTenant
+	}
+
+	switch store := auth.Config.IdentityStore.(type) {
+	case *MemoryIdentityStore:
+		if store.Tenant == nil {
+			store.Tenant = auth.GetTenant
+		}
+	case *SQLIdentityStore:
+		if store.Tenant == nil {
+			store.Tenant = auth.GetTenant
+		}
+	}
+
+	switch store := auth.Config.TokenStore.(type) {
+	case *MemoryTokenStore:
+		if store.Tenant == nil {
+			store.Tenant = auth.GetTenant
+		}
+	case *SQLTokenStore:
+		if store.Tenant == nil {
+			store.Tenant = auth.GetTenant
+		}
+	}
+}
+
+// RequestAuthURL generate URL for auth of request's tenant, tenant's path is prepended for PathTenantResolver
+func (auth *Auth) RequestAuthURL(req *http.Request, pth string) string {
+	if resolver, ok := auth.Config.TenantResolver.(*PathTenantResolver); ok {
+		if tenant := auth.GetTenant(req); tenant != "" {
+			return path.Join(resolver.TenantPath(tenant), auth.AuthURL(pth))
+		}
+	}
+	return auth.AuthURL(pth)
+}
+
+// AuthURL generate URL for auth of current request's tenant, refer RequestAuthURL
+func (context Context) AuthURL(pth string) string {
+	return context.Auth.RequestAuthURL(context.Request, pth)
+}
+
+// tenantProviders providers and their routes registered for a tenant
+type tenantProviders struct {
+	providers []Provider
+	routes    []Route
+}
+
+// RegisterTenantProvider register provider for tenant, like Google provider with tenant's own client ID, it is used instead of provider with same name registered with RegisterProvider for requests of the tenant, its routes are only served for the tenant, provider's ConfigAuth is not run again if a provider with same name has been registered, so state of tenant's provider should be resolved from request's context
+func (auth *Auth) RegisterTenantProvider(tenant string, provider Provider) {
+	if auth.tenants == nil {
+		auth.tenants = map[string]*tenantProviders{}
+	}
+
+	registered, ok := auth.tenants[tenant]
+	if !ok {
+		registered = &tenantProviders{}
+		auth.tenants[tenant] = registered
+	}
+
+	name := provider.GetName()
+	for _, p := range registered.providers {
+		if p.GetName() == name {
+			fmt.Printf("warning: auth provider %v of tenant %v already registered", name, tenant)
+			return
+		}
+	}
+
+	// routes are added into a scoped Auth, so handlers of tenant's provider never replace routes of other tenants
+	scoped := &Auth{Config: auth.Config, SessionStorerInterface: auth.SessionStorerInterface, routes: registered.routes}
+	scoped.addProviderRoutes(provider)
+	auth.configProvider(provider)
+	if registrar, ok := provider.(RouteRegistrar); ok {
+		registrar.RegisterRoutes(scoped)
+	}
+
+	for idx := range scoped.routes {
+		scoped.routes[idx].tenant = tenant
+	}
+	registered.routes = scoped.routes
+	registered.providers = append(registered.providers, provider)
+}
+
+// GetTenantProvider get provider with name for request's tenant, provider registered with RegisterTenantProvider is preferred
+func (auth *Auth) GetTenantProvider(req *http.Request, name string) Provider {
+	if registered, ok := auth.tenants[auth.GetTenant(req)]; ok {
+		for _, provider := range registered.providers {
+			if provider.GetName() == name {
+				return provider
+			}
+		}
+	}
+	return auth.GetProvider(name)
+}
+
+// GetTenantProviders return providers of request's tenant, includes providers registered with RegisterProvider that aren't replaced by tenant's
+func (auth *Auth) GetTenantProviders(req *http.Request) (providers []Provider) {
+	for _, provider := range auth.GetProviders() {
+		providers = append(providers, auth.GetTenantProvider(req, provider.GetName()))
+	}
+
+	if registered, ok := auth.tenants[auth.GetTenant(req)]; ok {
+		for _, provider := range registered.providers {
+			if auth.GetProvider(provider.GetName()) == nil {
+				providers = append(providers, provider)
+			}
+		}
+	}
+	return
+}
+
+// GetTenantRoutes return routes only served for tenant, which are added by RegisterTenantProvider, sorted like GetRoutes
+func (auth *Auth) GetTenantRoutes(tenant string) []Route {
+	if registered, ok := auth.tenants[tenant]; ok {
+		return sortRoutes(registered.routes)
+	}
+	return nil
+}
+
+// tenantRoutes return routes only served for request's tenant
+func (auth *Auth) tenantRoutes(req *http.Request) []Route {
+	if registered, ok := auth.tenants[auth.GetTenant(req)]; ok {
+		return registered.routes
+	}
+	return nil
+}
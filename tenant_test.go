@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+type configCountingProvider struct {
+	name       string
+	configured *int
+}
+
+func (provider configCountingProvider) GetName() string { return provider.name }
+
+func (provider configCountingProvider) ConfigAuth(auth *Auth) {
+	*provider.configured++
+	auth.RegisterAccountEraser(func(*http.Request, *Account, bool) error { return nil })
+}
+
+func (configCountingProvider) Login(*Context) {}
+
+func TestRegisterTenantProviderConfigAuthOnce(t *testing.T) {
+	var (
+		Auth       = &Auth{Config: &Config{}}
+		configured int
+	)
+
+	Auth.RegisterProvider(configCountingProvider{name: "fake", configured: &configured})
+	Auth.RegisterTenantProvider("a", configCountingProvider{name: "fake", configured: &configured})
+	Auth.RegisterTenantProvider("b", configCountingProvider{name: "fake", configured: &configured})
+
+	if configured != 1 || len(Auth.accountErasers) != 1 {
+		t.Errorf("ConfigAuth should be run once for providers with same name, got %v, %v erasers", configured, len(Auth.accountErasers))
+	}
+
+	if routes := Auth.GetTenantRoutes("b"); len(routes) == 0 {
+		t.Errorf("routes of tenant's provider should be registered")
+	}
+}
@@ -3,10 +3,11 @@ package auth
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 
-	"github.com/fahmibaswara/auth/auth_identity"
 	"github.com/fahmibaswara/auth/claims"
 	"github.com/jinzhu/copier"
+	"github.com/jinzhu/gorm"
 	"github.com/qor/qor/utils"
 )
 
@@ -18,6 +19,15 @@ type UserStorerInterface interface {
 
 // UserStorer default user storer
 type UserStorer struct {
+	// PrimaryKey column used to find users with claims' UserID, default is UserModel's primary key
+	PrimaryKey string
+	// ParseUserID convert claims' UserID to value of PrimaryKey, by default, it is converted to integer if PrimaryKey is integer, otherwise it is kept as string, like UUIDs
+	ParseUserID func(userID string) (interface{}, error)
+	// FormatUserID generate user ID of saved user, which is saved into auth identities and claims, default is value of PrimaryKey
+	FormatUserID func(user interface{}) string
+	// Finder find user with claims' UserID, PrimaryKey, ParseUserID won't be used if it is configured, e.g. users with composite keys like `{tenant}:{id}`
+	Finder func(tx *gorm.DB, user interface{}, userID string) error
+
 	temp struct {
 		currentUser interface{}
 	}
@@ -28,41 +38,29 @@ func (u UserStorer) Get(Claims *claims.Claims, context *Context) (user interface
 	var tx = context.Auth.GetDB(context.Request)
 
 	if context.Auth.Config.UserModel != nil {
-		if Claims.UserID != "" {
-			currentUser := reflect.New(utils.ModelType(context.Auth.Config.UserModel)).Interface()
-			if err = tx.First(currentUser, Claims.UserID).Error; err == nil {
-				return currentUser, nil
+		userID := Claims.UserID
+		if userID == "" {
+			// claims issued before user linked, find user of its auth identity
+			authInfo, err := context.Auth.IdentityStore.Find(context.Request, Claims.Provider, Claims.Id)
+			if err != nil || authInfo.UserID == "" {
+				return nil, ErrInvalidAccount
 			}
-			return nil, ErrInvalidAccount
+			userID = authInfo.UserID
 		}
-	}
 
-	var (
-		authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
-		authInfo     = auth_identity.Basic{
-			Provider: Claims.Provider,
-			UID:      Claims.Id,
-		}
-	)
-
-	if !tx.Where(map[string]interface{}{
-		"provider": authInfo.Provider,
-		"uid":      authInfo.UID,
-	}).First(authIdentity).RecordNotFound() {
-		if context.Auth.Config.UserModel != nil {
-			if authBasicInfo, ok := authIdentity.(interface {
-				ToClaims() *claims.Claims
-			}); ok {
-				if authBasicInfo.ToClaims().UserID != "" {
-					currentUser := reflect.New(utils.ModelType(context.Auth.Config.UserModel)).Interface()
-					if err = tx.First(currentUser, authBasicInfo.ToClaims().UserID).Error; err == nil {
-						return currentUser, nil
-					}
-				}
-				return nil, ErrInvalidAccount
-			}
+		currentUser := reflect.New(utils.ModelType(context.Auth.Config.UserModel)).Interface()
+		if err = u.FindUser(tx, currentUser, userID); err == nil {
+			return currentUser, nil
 		}
+		return nil, ErrInvalidAccount
+	}
 
+	// auth identity is used as user if no UserModel, it is loaded from DB with its AuthIdentityModel
+	authIdentity := reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
+	if !tx.Where(context.Auth.TenantConditions(context.Request, map[string]interface{}{
+		"provider": Claims.Provider,
+		"uid":      Claims.Id,
+	})).First(authIdentity).RecordNotFound() {
 		return authIdentity, nil
 	}
 
@@ -70,14 +68,94 @@ func (u UserStorer) Get(Claims *claims.Claims, context *Context) (user interface
 }
 
 // Save defined how to save user
-func (UserStorer) Save(schema *Schema, context *Context) (user interface{}, userID string, err error) {
+func (u UserStorer) Save(schema *Schema, context *Context) (user interface{}, userID string, err error) {
 	var tx = context.Auth.GetDB(context.Request)
 
 	if context.Auth.Config.UserModel != nil {
 		currentUser := reflect.New(utils.ModelType(context.Auth.Config.UserModel)).Interface()
 		copier.Copy(currentUser, schema)
 		err = tx.Create(currentUser).Error
-		return currentUser, fmt.Sprint(tx.NewScope(currentUser).PrimaryKeyValue()), err
+		return currentUser, u.GetUserID(tx, currentUser), err
 	}
 	return nil, "", nil
 }
+
+// UpdateProfile update user's profile with schema synced from providers, implements ProfileUpdater
+func (u UserStorer) UpdateProfile(user interface{}, schema *Schema, context *Context) error {
+	if context.Auth.Config.UserModel == nil {
+		return nil
+	}
+
+	ApplySchema(user, schema)
+	return context.Auth.GetDB(context.Request).Save(user).Error
+}
+
+// FindUser find user with user ID into user, refer PrimaryKey, ParseUserID, Finder
+func (u UserStorer) FindUser(tx *gorm.DB, user interface{}, userID string) error {
+	if u.Finder != nil {
+		return u.Finder(tx, user, userID)
+	}
+
+	var (
+		scope  = tx.NewScope(user)
+		column = u.PrimaryKey
+		value  interface{}
+		err    error
+	)
+
+	if column == "" {
+		column = scope.PrimaryKey()
+	}
+
+	if u.ParseUserID != nil {
+		value, err = u.ParseUserID(userID)
+	} else {
+		value, err = parseUserID(scope, column, userID)
+	}
+
+	if err != nil {
+		return ErrInvalidAccount.Wrap(err)
+	}
+	return tx.Where(fmt.Sprintf("%v = ?", scope.Quote(column)), value).First(user).Error
+}
+
+// GetUserID get user ID of user, refer PrimaryKey, FormatUserID
+func (u UserStorer) GetUserID(tx *gorm.DB, user interface{}) string {
+	if u.FormatUserID != nil {
+		return u.FormatUserID(user)
+	}
+
+	var (
+		scope = tx.NewScope(user)
+		value = scope.PrimaryKeyValue()
+	)
+
+	if u.PrimaryKey != "" {
+		if field, ok := scope.FieldByName(u.PrimaryKey); ok {
+			value = field.Field.Interface()
+		}
+	}
+
+	if bytes, ok := value.([]byte); ok {
+		return string(bytes)
+	}
+	return fmt.Sprint(value)
+}
+
+// parseUserID convert user ID to integer if column is an integer field
+func parseUserID(scope *gorm.Scope, column string, userID string) (interface{}, error) {
+	if field, ok := scope.FieldByName(column); ok {
+		fieldType := field.Struct.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.ParseInt(userID, 10, 64)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.ParseUint(userID, 10, 64)
+		}
+	}
+	return userID, nil
+}
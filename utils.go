@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/fahmibaswara/auth/claims"
@@ -12,21 +13,41 @@ import (
 // CurrentUser context key to get current user from Request
 const CurrentUser utils.ContextKey = "current_user"
 
-// GetCurrentUser get current user from request
+// GetCurrentUser get current user from request, it is memoized if the request has cache, refer WithRequestCache
 func (auth *Auth) GetCurrentUser(req *http.Request) interface{} {
 	if currentUser := req.Context().Value(CurrentUser); currentUser != nil {
 		return currentUser
 	}
 
-	claims, err := auth.SessionStorer.Get(req)
-	if err == nil {
-		context := &Context{Auth: auth, Claims: claims, Request: req}
-		if user, err := auth.UserStorer.Get(claims, context); err == nil {
+	cache := getRequestCache(req)
+	if cache != nil {
+		cache.mutex.Lock()
+		user, loaded := cache.user, cache.userLoaded
+		cache.mutex.Unlock()
+
+		if loaded {
 			return user
 		}
 	}
 
-	return nil
+	var currentUser interface{}
+	if claims, err := auth.Get(req); err == nil {
+		if user, err := auth.loadUser(req, claims); err == nil {
+			currentUser = user
+		}
+	}
+
+	if cache != nil {
+		cache.mutex.Lock()
+		cache.user, cache.userLoaded = currentUser, true
+		cache.mutex.Unlock()
+	}
+	return currentUser
+}
+
+// Now return current time of Auth's Clock
+func (auth *Auth) Now() time.Time {
+	return auth.Config.Clock()
 }
 
 // GetDB get db from request
@@ -38,16 +59,40 @@ func (auth *Auth) GetDB(request *http.Request) *gorm.DB {
 	return auth.Config.DB
 }
 
-// Login sign user in
+// Login sign user in, load user's roles and permissions into claims, returns ErrAccountLocked if claims' auth identity has been locked, returns ErrTenantMismatch if claims belong to another tenant
 func (auth *Auth) Login(w http.ResponseWriter, req *http.Request, claimer claims.ClaimerInterface) error {
 	claims := claimer.ToClaims()
-	now := time.Now()
+	if auth.IsMultiTenant() {
+		if tenant := auth.GetTenant(req); claims.TenantID == "" {
+			claims.TenantID = tenant
+		} else if claims.TenantID != tenant {
+			return ErrTenantMismatch
+		}
+	}
+
+	if auth.IsLocked(req, claims) {
+		return ErrAccountLocked
+	}
+
+	auth.InvalidateUser(claims)
+	if err := auth.LoadRoles(req, claims); err != nil {
+		return err
+	}
+
+	now := auth.Now()
 	claims.LastLoginAt = &now
+	claims.IssuedAt = now.Unix()
 
-	return auth.SessionStorer.Update(w, req, claims)
+	return auth.Update(w, req, claims)
 }
 
 // Logout sign current user out
 func (auth *Auth) Logout(w http.ResponseWriter, req *http.Request) {
-	auth.SessionStorer.Delete(w, req)
+	auth.Delete(w, req)
+}
+
+// isUniqueViolation check if err is caused by violating unique index, errors of SQLite, MySQL and PostgreSQL drivers are recognized by their messages
+func isUniqueViolation(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unique") || strings.Contains(message, "duplicate")
 }